@@ -0,0 +1,305 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConfigurationAppliedCondition reports whether every field of the BtpManagerConfiguration spec was applied.
+const ConfigurationAppliedCondition = "Applied"
+
+// Reasons of the Applied condition.
+const (
+	ConfigurationAppliedReason        = "Applied"
+	ConfigurationFieldsRejectedReason = "FieldsRejected"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:categories={kyma-btp-operator}
+//+kubebuilder:printcolumn:name="Applied",type=string,JSONPath=".status.conditions[?(@.type==\"Applied\")].status"
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// BtpManagerConfiguration is the Schema for the btpmanagerconfigurations API.
+// BTP Manager reads the BtpManagerConfiguration named after the ConfigName setting in the ChartNamespace, by default kyma-system/sap-btp-manager.
+type BtpManagerConfiguration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:default={}
+	Spec   BtpManagerConfigurationSpec   `json:"spec,omitempty"`
+	Status BtpManagerConfigurationStatus `json:"status,omitempty"`
+}
+
+// BtpManagerConfigurationSpec defines the BTP Manager internal settings. Field names match the keys of the legacy configuration ConfigMap.
+// Only the fields that are set are applied. The omitted fields keep the values set by the CLI flags or the BTP Manager defaults.
+type BtpManagerConfigurationSpec struct {
+	// ChartNamespace is the namespace to install chart resources.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	ChartNamespace *string `json:"chartNamespace,omitempty"`
+
+	// SecretName is the name of the Secret with input values for sap-btp-operator chart templating.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	SecretName *string `json:"secretName,omitempty"`
+
+	// ConfigName is the name of the BtpManagerConfiguration and of the legacy configuration ConfigMap.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	ConfigName *string `json:"configName,omitempty"`
+
+	// DeploymentName is the name of the deployment of sap-btp-operator for deprovisioning.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	DeploymentName *string `json:"deploymentName,omitempty"`
+
+	// ProcessingStateRequeueInterval is the requeue interval for state "processing".
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	ProcessingStateRequeueInterval *metav1.Duration `json:"processingStateRequeueInterval,omitempty"`
+
+	// ReadyStateRequeueInterval is the requeue interval for state "ready".
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	ReadyStateRequeueInterval *metav1.Duration `json:"readyStateRequeueInterval,omitempty"`
+
+	// ReadyTimeout is the Helm chart timeout.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	ReadyTimeout *metav1.Duration `json:"readyTimeout,omitempty"`
+
+	// ReadyCheckInterval is the ready check retry interval.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	ReadyCheckInterval *metav1.Duration `json:"readyCheckInterval,omitempty"`
+
+	// HardDeleteTimeout is the hard delete timeout.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	HardDeleteTimeout *metav1.Duration `json:"hardDeleteTimeout,omitempty"`
+
+	// HardDeleteCheckInterval is the hard delete retry interval.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	HardDeleteCheckInterval *metav1.Duration `json:"hardDeleteCheckInterval,omitempty"`
+
+	// DeleteRequestTimeout is the delete request timeout in hard delete.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	DeleteRequestTimeout *metav1.Duration `json:"deleteRequestTimeout,omitempty"`
+
+	// HardDeleteConcurrency is the number of namespaces hard deleted in parallel.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	HardDeleteConcurrency *int32 `json:"hardDeleteConcurrency,omitempty"`
+
+	// HardDeleteQPS is the maximum number of delete requests per second during the hard delete. 0 disables the limit.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	HardDeleteQPS *int32 `json:"hardDeleteQPS,omitempty"`
+
+	// StatusUpdateTimeout is the status update timeout.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	StatusUpdateTimeout *metav1.Duration `json:"statusUpdateTimeout,omitempty"`
+
+	// StatusUpdateCheckInterval is the status update retry interval.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	StatusUpdateCheckInterval *metav1.Duration `json:"statusUpdateCheckInterval,omitempty"`
+
+	// CaCertificateExpiration is the validity of the self-signed CA certificate.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	CaCertificateExpiration *metav1.Duration `json:"caCertificateExpiration,omitempty"`
+
+	// WebhookCertificateExpiration is the validity of the webhook certificate.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	WebhookCertificateExpiration *metav1.Duration `json:"webhookCertificateExpiration,omitempty"`
+
+	// ExpirationBoundary is the offset to the expiry of a certificate from which the certificate is regenerated.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) <= duration('0s')",message="must not be positive"
+	ExpirationBoundary *metav1.Duration `json:"expirationBoundary,omitempty"`
+
+	// WebhookCertificateMode is the source of the admission webhook certificates.
+	// +optional
+	// +kubebuilder:validation:Enum=self-signed;cert-manager
+	WebhookCertificateMode *string `json:"webhookCertificateMode,omitempty"`
+
+	// RsaKeyBits is the size of the RSA keys of the admission webhook certificates.
+	// +optional
+	// +kubebuilder:validation:Minimum=2048
+	RsaKeyBits *int32 `json:"rsaKeyBits,omitempty"`
+
+	// KeyAlgorithm is the algorithm of the admission webhook certificate keys.
+	// +optional
+	// +kubebuilder:validation:Enum=rsa;ecdsa-p256;ecdsa-p384;ed25519
+	KeyAlgorithm *string `json:"keyAlgorithm,omitempty"`
+
+	// CaRotationAdvance is how long before ExpirationBoundary the staged CA rotation starts. 0 disables the staged rotation.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('0s')",message="must not be negative"
+	CaRotationAdvance *metav1.Duration `json:"caRotationAdvance,omitempty"`
+
+	// CaRotationReloadTimeout is how long the staged CA rotation waits for sap-btp-operator to serve the new webhook certificate before it drops the previous CA.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	CaRotationReloadTimeout *metav1.Duration `json:"caRotationReloadTimeout,omitempty"`
+
+	// CertificateRegenerationGracePeriod is how long the CA or webhook certificate may stay within the expiration boundary before the CertificatesValid condition reports that its regeneration keeps failing.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('0s')",message="must not be negative"
+	CertificateRegenerationGracePeriod *metav1.Duration `json:"certificateRegenerationGracePeriod,omitempty"`
+
+	// ExternalCaSecret is the name of the Secret with an external CA that signs the webhook certificate instead of a self-signed CA.
+	// +optional
+	ExternalCaSecret *string `json:"externalCaSecret,omitempty"`
+
+	// WebhookSelfTestInterval is how often a dry-run AdmissionReview is sent to the sap-btp-operator webhooks. 0 disables the self-test.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('0s')",message="must not be negative"
+	WebhookSelfTestInterval *metav1.Duration `json:"webhookSelfTestInterval,omitempty"`
+
+	// WebhookSelfTestTimeout is how long the webhook self-test retries after the certificates change before it reports the webhooks as unreachable.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="must be positive"
+	WebhookSelfTestTimeout *metav1.Duration `json:"webhookSelfTestTimeout,omitempty"`
+
+	// RestoreServiceInstancesAndBindings re-creates the service instances and bindings exported during the previous deprovisioning once the module is ready.
+	// +optional
+	RestoreServiceInstancesAndBindings *bool `json:"restoreServiceInstancesAndBindings,omitempty"`
+
+	// ForceDeleteConfirmationRequired requires the force-delete-confirmation annotation before force deleting service instances and bindings.
+	// +optional
+	ForceDeleteConfirmationRequired *bool `json:"forceDeleteConfirmationRequired,omitempty"`
+
+	// ChartPath is the path to the root directory inside the chart.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	ChartPath *string `json:"chartPath,omitempty"`
+
+	// ResourcesPath is the path to the directory with module resources to apply/delete.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	ResourcesPath *string `json:"resourcesPath,omitempty"`
+
+	// ManagerResourcesPath is the path to the directory with BTP Manager resources.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	ManagerResourcesPath *string `json:"managerResourcesPath,omitempty"`
+
+	// EnableLimitedCache enables the limited cache for sap-btp-operator.
+	// +optional
+	EnableLimitedCache *bool `json:"enableLimitedCache,omitempty"`
+
+	// ProbeInterval is the CA bundle probe interval. 0 disables the probe.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('0s')",message="must not be negative"
+	ProbeInterval *metav1.Duration `json:"probeInterval,omitempty"`
+
+	// ProbeMode is the CA bundle probe mode: Job runs the probe in a Job, InProcess runs it in BTP Manager.
+	// +optional
+	// +kubebuilder:validation:Enum=Job;InProcess
+	ProbeMode *string `json:"probeMode,omitempty"`
+
+	// ProbeHistoryLength is the number of CA bundle probe results kept in the BtpOperator status.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ProbeHistoryLength *int32 `json:"probeHistoryLength,omitempty"`
+
+	// ProbeAlertThreshold is the number of consecutive CA bundle probe alerts before the alert is reported.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ProbeAlertThreshold *int32 `json:"probeAlertThreshold,omitempty"`
+
+	// ProbeErrorThreshold is the number of consecutive CA bundle probe errors before the error is reported.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ProbeErrorThreshold *int32 `json:"probeErrorThreshold,omitempty"`
+
+	// ProbeRestartMinInterval is the minimum time between restarts of the sap-btp-operator Pods after the CA bundle changes.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('0s')",message="must not be negative"
+	ProbeRestartMinInterval *metav1.Duration `json:"probeRestartMinInterval,omitempty"`
+
+	// ProbeCAExpiryWarning is how long before their expiry the CA bundle probe reports certificates in the mounted CA bundle as expiring.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('0s')",message="must not be negative"
+	ProbeCAExpiryWarning *metav1.Duration `json:"probeCAExpiryWarning,omitempty"`
+
+	// HttpProxy is the HTTP_PROXY value passed to sap-btp-operator and the CA bundle probe.
+	// +optional
+	HttpProxy *string `json:"httpProxy,omitempty"`
+
+	// HttpsProxy is the HTTPS_PROXY value passed to sap-btp-operator and the CA bundle probe.
+	// +optional
+	HttpsProxy *string `json:"httpsProxy,omitempty"`
+
+	// NoProxy is the NO_PROXY value passed to sap-btp-operator and the CA bundle probe.
+	// +optional
+	NoProxy *string `json:"noProxy,omitempty"`
+
+	// TrustBundleConfigMap is the name of the ConfigMap with a custom CA trust bundle mounted into sap-btp-operator and the CA bundle probe.
+	// +optional
+	TrustBundleConfigMap *string `json:"trustBundleConfigMap,omitempty"`
+
+	// TrustBundleSecret is the name of the Secret with a custom CA trust bundle mounted into sap-btp-operator and the CA bundle probe.
+	// +optional
+	TrustBundleSecret *string `json:"trustBundleSecret,omitempty"`
+
+	// TrustBundleKey is the key of the custom CA trust bundle in the ConfigMap or Secret.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	TrustBundleKey *string `json:"trustBundleKey,omitempty"`
+}
+
+// BtpManagerConfigurationStatus defines the observed state of BtpManagerConfiguration.
+type BtpManagerConfigurationStatus struct {
+	// ObservedGeneration is the generation of the spec the status reports on.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions contain the Applied condition.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Fields reports for each spec field that is set whether its value was applied.
+	Fields []ConfigurationFieldStatus `json:"fields,omitempty"`
+}
+
+// ConfigurationFieldStatus reports whether the value of a spec field was applied.
+// The last accepted value of a rejected field stays in effect.
+type ConfigurationFieldStatus struct {
+	// Name is the name of the spec field.
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+	// Reason is the reason why the value was rejected.
+	Reason string `json:"reason,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BtpManagerConfigurationList contains a list of BtpManagerConfiguration
+type BtpManagerConfigurationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BtpManagerConfiguration `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BtpManagerConfiguration{}, &BtpManagerConfigurationList{})
+}
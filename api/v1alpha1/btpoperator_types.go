@@ -25,6 +25,13 @@ import (
 const componentName = "btp-operator"
 const DisableNetworkPoliciesAnnotation = "operator.kyma-project.io/btp-operator-disable-network-policies"
 
+// DeprovisioningPreviewAnnotation requests a report of what deleting the CR would remove. Change the value to regenerate the report.
+const DeprovisioningPreviewAnnotation = "operator.kyma-project.io/deprovisioning-preview"
+
+// ForceDeleteConfirmationAnnotation confirms the force delete of the existing ServiceInstances and ServiceBindings.
+// The value is either their exact counts, as "instances=<N>,bindings=<M>", or the token published in the status.
+const ForceDeleteConfirmationAnnotation = "operator.kyma-project.io/force-delete-confirmation"
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -82,6 +89,193 @@ type Status struct {
 
 	// Conditions associated with CustomStatus.
 	Conditions []*metav1.Condition `json:"conditions,omitempty"`
+
+	// Deprovisioning tracks the progress of the deletion, so that it resumes where it stopped after a restart.
+	// +optional
+	Deprovisioning *DeprovisioningStatus `json:"deprovisioning,omitempty"`
+
+	// ForceDeleteConfirmationToken confirms the force delete of the ServiceInstances and ServiceBindings existing when it was published.
+	// +optional
+	ForceDeleteConfirmationToken string `json:"forceDeleteConfirmationToken,omitempty"`
+
+	// RemainingResources breaks down the ServiceInstances and ServiceBindings blocking or left by the deletion, most numerous first.
+	// +optional
+	RemainingResources []RemainingResources `json:"remainingResources,omitempty"`
+
+	// Probe is the result of the last CA bundle probe run.
+	// +optional
+	Probe *ProbeStatus `json:"probe,omitempty"`
+}
+
+// RemainingResources is the number of ServiceInstances or ServiceBindings of a kind in a namespace and state.
+type RemainingResources struct {
+	// +kubebuilder:validation:Enum=ServiceInstance;ServiceBinding
+	Kind string `json:"kind"`
+
+	Namespace string `json:"namespace"`
+
+	// State is Deleting for resources marked for deletion, otherwise Failed, Ready, or NotReady based on the resource conditions.
+	State string `json:"state"`
+
+	Count int `json:"count"`
+}
+
+// Results of the CA bundle probe.
+const (
+	// ProbeResultOK signifies that the TLS connection to the target URL is trusted.
+	ProbeResultOK = "ok"
+	// ProbeResultAlert signifies that a CA bundle is mounted, but the certificate of the target URL is not trusted by it.
+	ProbeResultAlert = "alert"
+	// ProbeResultError signifies that the probe failed to connect to the target URL or could not run.
+	ProbeResultError = "error"
+)
+
+// ProbeStatus is written by the CA bundle probe, except for LastHash, which is managed by BTP Manager.
+type ProbeStatus struct {
+	// TargetURL is the URL the probe connected to.
+	// +optional
+	TargetURL string `json:"targetURL,omitempty"`
+
+	// TLSResult is the result of the TLS handshake with the target URL.
+	// +kubebuilder:validation:Enum=ok;failed-x509;failed-other
+	// +optional
+	TLSResult string `json:"tlsResult,omitempty"`
+
+	// Result is ok, alert when a mounted CA bundle does not trust the target URL, or error.
+	// +kubebuilder:validation:Enum=ok;alert;error
+	Result string `json:"result"`
+
+	// FailingCertificateSubjects are the subjects of the certificate chain presented by the target URL when it's not trusted, leaf first.
+	// +optional
+	FailingCertificateSubjects []string `json:"failingCertificateSubjects,omitempty"`
+
+	// MountPresent reports whether a CA bundle is mounted into the probe, either by rt-bootstrapper or as the managed trust bundle.
+	MountPresent bool `json:"mountPresent"`
+
+	// Hash identifies the set of certificates in the mounted CA bundle.
+	// +optional
+	Hash string `json:"hash,omitempty"`
+
+	// LastHash is the hash BTP Manager acted on last. The sap-btp-operator pods are restarted when Hash changes from it.
+	// +optional
+	LastHash string `json:"lastHash,omitempty"`
+
+	// Error describes why the probe could not run.
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// Checks are the connectivity checks run by the probe, in the order they were run.
+	// +optional
+	Checks []ProbeCheck `json:"checks,omitempty"`
+
+	// ExpiringCAs are the certificates in the mounted CA bundle that are expired or expire soon, earliest first.
+	// +optional
+	ExpiringCAs []ExpiringCA `json:"expiringCAs,omitempty"`
+
+	// UpdatedAt is the time the probe wrote the result.
+	UpdatedAt metav1.Time `json:"updatedAt"`
+
+	// History holds the latest probe results, newest first. It's managed by BTP Manager, not by the probe.
+	// +optional
+	History []ProbeHistoryEntry `json:"history,omitempty"`
+
+	// LastRestartTime is the time BTP Manager last restarted the sap-btp-operator pods because the CA bundle changed.
+	// +optional
+	LastRestartTime *metav1.Time `json:"lastRestartTime,omitempty"`
+}
+
+// KeepManagedFields copies the fields managed by BTP Manager from the previous probe status, so that a new probe result doesn't reset them.
+func (s *ProbeStatus) KeepManagedFields(previous *ProbeStatus) {
+	if previous == nil {
+		return
+	}
+	s.LastHash = previous.LastHash
+	s.History = previous.History
+	s.LastRestartTime = previous.LastRestartTime
+}
+
+// ExpiringCA is a certificate in the mounted CA bundle that is expired or expires soon.
+type ExpiringCA struct {
+	// Subject is the subject of the certificate.
+	Subject string `json:"subject"`
+
+	// NotAfter is the time the certificate expires.
+	NotAfter metav1.Time `json:"notAfter"`
+
+	// Expired reports whether the certificate has already expired.
+	Expired bool `json:"expired"`
+
+	// InTokenURLChain reports whether the certificate chain of the token URL depends on the certificate.
+	InTokenURLChain bool `json:"inTokenURLChain"`
+}
+
+// ProbeHistoryEntry is a probe result recorded by BTP Manager.
+type ProbeHistoryEntry struct {
+	// Result is the probe result.
+	Result string `json:"result"`
+
+	// TLSResult is the result of the TLS handshake with the target URL.
+	// +optional
+	TLSResult string `json:"tlsResult,omitempty"`
+
+	// Hash is the hash of the mounted CA bundle.
+	// +optional
+	Hash string `json:"hash,omitempty"`
+
+	// UpdatedAt is the time the probe wrote the result.
+	UpdatedAt metav1.Time `json:"updatedAt"`
+}
+
+// ProbeCheck is the result of a single connectivity check run by the CA bundle probe.
+type ProbeCheck struct {
+	// Name identifies the check, for example, dns-token-url or oauth-token.
+	Name string `json:"name"`
+
+	// Success reports whether the check passed.
+	Success bool `json:"success"`
+
+	// Duration is the time the check took.
+	Duration metav1.Duration `json:"duration"`
+
+	// Error describes why the check failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+type DeprovisioningPhase string
+
+// Deprovisioning phases, in the order they are run.
+const (
+	// DeprovisioningPhaseHardDeleting deletes ServiceInstances and ServiceBindings and waits for them to be gone.
+	DeprovisioningPhaseHardDeleting DeprovisioningPhase = "HardDeleting"
+
+	// DeprovisioningPhaseSoftDeleting removes finalizers from ServiceInstances and ServiceBindings when the hard delete fails or times out,
+	// and deletes the module resources.
+	DeprovisioningPhaseSoftDeleting DeprovisioningPhase = "SoftDeleting"
+
+	// DeprovisioningPhaseDeletingModuleResources deletes the module resources after a successful hard delete.
+	DeprovisioningPhaseDeletingModuleResources DeprovisioningPhase = "DeletingModuleResources"
+
+	// DeprovisioningPhaseCompleted signifies that the finalizer can be removed.
+	DeprovisioningPhaseCompleted DeprovisioningPhase = "Completed"
+)
+
+// DeprovisioningStatus is the persisted progress of the deprovisioning.
+type DeprovisioningStatus struct {
+	// +kubebuilder:validation:Enum=HardDeleting;SoftDeleting;DeletingModuleResources;Completed
+	Phase DeprovisioningPhase `json:"phase"`
+
+	// StartTime is the time the deprovisioning started.
+	StartTime metav1.Time `json:"startTime"`
+
+	// PhaseStartTime is the time the current phase started. The hard delete timeout is counted from it.
+	PhaseStartTime metav1.Time `json:"phaseStartTime"`
+
+	// RemainingServiceInstances is the number of ServiceInstances left at the last check.
+	RemainingServiceInstances int `json:"remainingServiceInstances"`
+
+	// RemainingServiceBindings is the number of ServiceBindings left at the last check.
+	RemainingServiceBindings int `json:"remainingServiceBindings"`
 }
 
 func (s *Status) WithState(state State) Status {
@@ -25,12 +25,343 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BtpManagerConfiguration) DeepCopyInto(out *BtpManagerConfiguration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BtpManagerConfiguration.
+func (in *BtpManagerConfiguration) DeepCopy() *BtpManagerConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(BtpManagerConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BtpManagerConfiguration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BtpManagerConfigurationList) DeepCopyInto(out *BtpManagerConfigurationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BtpManagerConfiguration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BtpManagerConfigurationList.
+func (in *BtpManagerConfigurationList) DeepCopy() *BtpManagerConfigurationList {
+	if in == nil {
+		return nil
+	}
+	out := new(BtpManagerConfigurationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BtpManagerConfigurationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BtpManagerConfigurationSpec) DeepCopyInto(out *BtpManagerConfigurationSpec) {
+	*out = *in
+	if in.ChartNamespace != nil {
+		in, out := &in.ChartNamespace, &out.ChartNamespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.SecretName != nil {
+		in, out := &in.SecretName, &out.SecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.ConfigName != nil {
+		in, out := &in.ConfigName, &out.ConfigName
+		*out = new(string)
+		**out = **in
+	}
+	if in.DeploymentName != nil {
+		in, out := &in.DeploymentName, &out.DeploymentName
+		*out = new(string)
+		**out = **in
+	}
+	if in.ProcessingStateRequeueInterval != nil {
+		in, out := &in.ProcessingStateRequeueInterval, &out.ProcessingStateRequeueInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ReadyStateRequeueInterval != nil {
+		in, out := &in.ReadyStateRequeueInterval, &out.ReadyStateRequeueInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ReadyTimeout != nil {
+		in, out := &in.ReadyTimeout, &out.ReadyTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ReadyCheckInterval != nil {
+		in, out := &in.ReadyCheckInterval, &out.ReadyCheckInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.HardDeleteTimeout != nil {
+		in, out := &in.HardDeleteTimeout, &out.HardDeleteTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.HardDeleteCheckInterval != nil {
+		in, out := &in.HardDeleteCheckInterval, &out.HardDeleteCheckInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DeleteRequestTimeout != nil {
+		in, out := &in.DeleteRequestTimeout, &out.DeleteRequestTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.HardDeleteConcurrency != nil {
+		in, out := &in.HardDeleteConcurrency, &out.HardDeleteConcurrency
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HardDeleteQPS != nil {
+		in, out := &in.HardDeleteQPS, &out.HardDeleteQPS
+		*out = new(int32)
+		**out = **in
+	}
+	if in.StatusUpdateTimeout != nil {
+		in, out := &in.StatusUpdateTimeout, &out.StatusUpdateTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.StatusUpdateCheckInterval != nil {
+		in, out := &in.StatusUpdateCheckInterval, &out.StatusUpdateCheckInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CaCertificateExpiration != nil {
+		in, out := &in.CaCertificateExpiration, &out.CaCertificateExpiration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.WebhookCertificateExpiration != nil {
+		in, out := &in.WebhookCertificateExpiration, &out.WebhookCertificateExpiration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExpirationBoundary != nil {
+		in, out := &in.ExpirationBoundary, &out.ExpirationBoundary
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.WebhookCertificateMode != nil {
+		in, out := &in.WebhookCertificateMode, &out.WebhookCertificateMode
+		*out = new(string)
+		**out = **in
+	}
+	if in.RsaKeyBits != nil {
+		in, out := &in.RsaKeyBits, &out.RsaKeyBits
+		*out = new(int32)
+		**out = **in
+	}
+	if in.KeyAlgorithm != nil {
+		in, out := &in.KeyAlgorithm, &out.KeyAlgorithm
+		*out = new(string)
+		**out = **in
+	}
+	if in.CaRotationAdvance != nil {
+		in, out := &in.CaRotationAdvance, &out.CaRotationAdvance
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CaRotationReloadTimeout != nil {
+		in, out := &in.CaRotationReloadTimeout, &out.CaRotationReloadTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CertificateRegenerationGracePeriod != nil {
+		in, out := &in.CertificateRegenerationGracePeriod, &out.CertificateRegenerationGracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExternalCaSecret != nil {
+		in, out := &in.ExternalCaSecret, &out.ExternalCaSecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.WebhookSelfTestInterval != nil {
+		in, out := &in.WebhookSelfTestInterval, &out.WebhookSelfTestInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.WebhookSelfTestTimeout != nil {
+		in, out := &in.WebhookSelfTestTimeout, &out.WebhookSelfTestTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RestoreServiceInstancesAndBindings != nil {
+		in, out := &in.RestoreServiceInstancesAndBindings, &out.RestoreServiceInstancesAndBindings
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ForceDeleteConfirmationRequired != nil {
+		in, out := &in.ForceDeleteConfirmationRequired, &out.ForceDeleteConfirmationRequired
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ChartPath != nil {
+		in, out := &in.ChartPath, &out.ChartPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.ResourcesPath != nil {
+		in, out := &in.ResourcesPath, &out.ResourcesPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.ManagerResourcesPath != nil {
+		in, out := &in.ManagerResourcesPath, &out.ManagerResourcesPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.EnableLimitedCache != nil {
+		in, out := &in.EnableLimitedCache, &out.EnableLimitedCache
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ProbeInterval != nil {
+		in, out := &in.ProbeInterval, &out.ProbeInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ProbeMode != nil {
+		in, out := &in.ProbeMode, &out.ProbeMode
+		*out = new(string)
+		**out = **in
+	}
+	if in.ProbeHistoryLength != nil {
+		in, out := &in.ProbeHistoryLength, &out.ProbeHistoryLength
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ProbeAlertThreshold != nil {
+		in, out := &in.ProbeAlertThreshold, &out.ProbeAlertThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ProbeErrorThreshold != nil {
+		in, out := &in.ProbeErrorThreshold, &out.ProbeErrorThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ProbeRestartMinInterval != nil {
+		in, out := &in.ProbeRestartMinInterval, &out.ProbeRestartMinInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ProbeCAExpiryWarning != nil {
+		in, out := &in.ProbeCAExpiryWarning, &out.ProbeCAExpiryWarning
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.HttpProxy != nil {
+		in, out := &in.HttpProxy, &out.HttpProxy
+		*out = new(string)
+		**out = **in
+	}
+	if in.HttpsProxy != nil {
+		in, out := &in.HttpsProxy, &out.HttpsProxy
+		*out = new(string)
+		**out = **in
+	}
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = new(string)
+		**out = **in
+	}
+	if in.TrustBundleConfigMap != nil {
+		in, out := &in.TrustBundleConfigMap, &out.TrustBundleConfigMap
+		*out = new(string)
+		**out = **in
+	}
+	if in.TrustBundleSecret != nil {
+		in, out := &in.TrustBundleSecret, &out.TrustBundleSecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.TrustBundleKey != nil {
+		in, out := &in.TrustBundleKey, &out.TrustBundleKey
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BtpManagerConfigurationSpec.
+func (in *BtpManagerConfigurationSpec) DeepCopy() *BtpManagerConfigurationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BtpManagerConfigurationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BtpManagerConfigurationStatus) DeepCopyInto(out *BtpManagerConfigurationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]ConfigurationFieldStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BtpManagerConfigurationStatus.
+func (in *BtpManagerConfigurationStatus) DeepCopy() *BtpManagerConfigurationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BtpManagerConfigurationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BtpOperator) DeepCopyInto(out *BtpOperator) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -99,6 +430,54 @@ func (in *BtpOperatorSpec) DeepCopy() *BtpOperatorSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigurationFieldStatus) DeepCopyInto(out *ConfigurationFieldStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigurationFieldStatus.
+func (in *ConfigurationFieldStatus) DeepCopy() *ConfigurationFieldStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigurationFieldStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeprovisioningStatus) DeepCopyInto(out *DeprovisioningStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.PhaseStartTime.DeepCopyInto(&out.PhaseStartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeprovisioningStatus.
+func (in *DeprovisioningStatus) DeepCopy() *DeprovisioningStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DeprovisioningStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpiringCA) DeepCopyInto(out *ExpiringCA) {
+	*out = *in
+	in.NotAfter.DeepCopyInto(&out.NotAfter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpiringCA.
+func (in *ExpiringCA) DeepCopy() *ExpiringCA {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpiringCA)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LastOperation) DeepCopyInto(out *LastOperation) {
 	*out = *in
@@ -131,6 +510,97 @@ func (in *Resource) DeepCopy() *Resource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeCheck) DeepCopyInto(out *ProbeCheck) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeCheck.
+func (in *ProbeCheck) DeepCopy() *ProbeCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeHistoryEntry) DeepCopyInto(out *ProbeHistoryEntry) {
+	*out = *in
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeHistoryEntry.
+func (in *ProbeHistoryEntry) DeepCopy() *ProbeHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeStatus) DeepCopyInto(out *ProbeStatus) {
+	*out = *in
+	if in.FailingCertificateSubjects != nil {
+		in, out := &in.FailingCertificateSubjects, &out.FailingCertificateSubjects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]ProbeCheck, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExpiringCAs != nil {
+		in, out := &in.ExpiringCAs, &out.ExpiringCAs
+		*out = make([]ExpiringCA, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]ProbeHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastRestartTime != nil {
+		in, out := &in.LastRestartTime, &out.LastRestartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeStatus.
+func (in *ProbeStatus) DeepCopy() *ProbeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemainingResources) DeepCopyInto(out *RemainingResources) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemainingResources.
+func (in *RemainingResources) DeepCopy() *RemainingResources {
+	if in == nil {
+		return nil
+	}
+	out := new(RemainingResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Status) DeepCopyInto(out *Status) {
 	*out = *in
@@ -145,6 +615,21 @@ func (in *Status) DeepCopyInto(out *Status) {
 			}
 		}
 	}
+	if in.Deprovisioning != nil {
+		in, out := &in.Deprovisioning, &out.Deprovisioning
+		*out = new(DeprovisioningStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RemainingResources != nil {
+		in, out := &in.RemainingResources, &out.RemainingResources
+		*out = make([]RemainingResources, len(*in))
+		copy(*out, *in)
+	}
+	if in.Probe != nil {
+		in, out := &in.Probe, &out.Probe
+		*out = new(ProbeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Status.
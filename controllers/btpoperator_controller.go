@@ -51,6 +51,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -123,6 +124,11 @@ type InstanceBindingSerivce interface {
 	EnableSISBController()
 }
 
+// OperandRestorer restores ServiceInstances and ServiceBindings exported during the previous deprovisioning.
+type OperandRestorer interface {
+	Restore(ctx context.Context) error
+}
+
 // BtpOperatorReconciler reconciles a BtpOperator object
 type BtpOperatorReconciler struct {
 	client.Client
@@ -138,6 +144,8 @@ type BtpOperatorReconciler struct {
 	configurator           configurator.SapBtpServiceOperatorConfigurator
 	watchHandlers          []config.WatchHandler
 	deprovisioningHandler  deprovisioning.Handler
+	operandRestorer        OperandRestorer
+	reconcileMetrics       *metrics.ReconcileMetrics
 }
 
 func NewBtpOperatorReconciler(client client.Client, apiServerClient client.Client, scheme *runtime.Scheme, instanceBindingSerivice InstanceBindingSerivce, metrics *metrics.WebhookMetrics, watchHandlers []config.WatchHandler, networkPolicyManager networkpolicy.NetworkPolicyManager, certManager certificate.CertificateManager, provisioningHandler provisioning.Handler, cfg configurator.SapBtpServiceOperatorConfigurator) *BtpOperatorReconciler {
@@ -159,9 +167,19 @@ func (r *BtpOperatorReconciler) SetDeprovisioningHandler(h deprovisioning.Handle
 	r.deprovisioningHandler = h
 }
 
+func (r *BtpOperatorReconciler) SetReconcileMetrics(m *metrics.ReconcileMetrics) {
+	r.reconcileMetrics = m
+}
+
+func (r *BtpOperatorReconciler) SetOperandRestorer(restorer OperandRestorer) {
+	r.operandRestorer = restorer
+}
+
 // RBAC neccessary for the operator itself
 //+kubebuilder:rbac:groups="operator.kyma-project.io",resources="btpoperators",verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups="operator.kyma-project.io",resources="btpoperators/status",verbs=get;update;patch
+//+kubebuilder:rbac:groups="operator.kyma-project.io",resources="btpmanagerconfigurations",verbs=get;list;watch;create
+//+kubebuilder:rbac:groups="operator.kyma-project.io",resources="btpmanagerconfigurations/status",verbs=get;update;patch
 //+kubebuilder:rbac:groups="services.cloud.sap.com",resources=serviceinstances;servicebindings,verbs=create;get;list;watch;update;patch;delete
 //+kubebuilder:rbac:groups="services.cloud.sap.com",resources=serviceinstances/status;servicebindings/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups="coordination.k8s.io",resources=leases,verbs=create;get;list;update
@@ -181,6 +199,7 @@ func (r *BtpOperatorReconciler) SetDeprovisioningHandler(h deprovisioning.Handle
 //+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources="clusterroles",verbs=get;list;watch;create;update;patch;delete;deletecollection;bind
 //+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources="rolebindings",verbs=get;create;update;patch;deletecollection
 //+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources="roles",verbs=get;list;watch;create;update;patch;delete;deletecollection;bind
+//+kubebuilder:rbac:groups="cert-manager.io",resources=certificates;issuers,verbs=get;list;watch;create;update;patch;delete;deletecollection
 //+kubebuilder:rbac:groups="",resources="configmaps",verbs=deletecollection
 //+kubebuilder:rbac:groups="",resources="secrets",verbs=deletecollection
 //+kubebuilder:rbac:groups="services.cloud.sap.com",resources="servicebindings",verbs=deletecollection
@@ -190,6 +209,7 @@ func (r *BtpOperatorReconciler) SetDeprovisioningHandler(h deprovisioning.Handle
 //+kubebuilder:rbac:groups="",resources="configmaps",verbs=create;delete;get;list;patch;update;watch
 //+kubebuilder:rbac:groups="",resources="configmaps/status",verbs=get;patch;update
 //+kubebuilder:rbac:groups="",resources="events",verbs=create
+//+kubebuilder:rbac:groups="events.k8s.io",resources="events",verbs=create;patch
 //+kubebuilder:rbac:groups="",resources="secrets",verbs=create;delete;get;list;patch;update;watch
 //+kubebuilder:rbac:groups="coordination.k8s.io",resources="leases",verbs=create;get;list;update
 //+kubebuilder:rbac:groups="services.cloud.sap.com",resources="servicebindings",verbs=create;delete;get;list;patch;update;watch
@@ -198,6 +218,7 @@ func (r *BtpOperatorReconciler) SetDeprovisioningHandler(h deprovisioning.Handle
 //+kubebuilder:rbac:groups="services.cloud.sap.com",resources="serviceinstances/status",verbs=get;patch;update
 
 func (r *BtpOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cfg := config.Current()
 	r.workqueueSize += 1
 	defer func() { r.workqueueSize -= 1 }()
 
@@ -207,6 +228,9 @@ func (r *BtpOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if err := r.Get(ctx, req.NamespacedName, reconcileCr); err != nil {
 		if k8serrors.IsNotFound(err) {
 			logger.Info(fmt.Sprintf("%s BtpOperator CR not found. Ignoring it since object has been deleted.", req.Name))
+			if req.Name == config.BtpOperatorCrName && req.Namespace == config.KymaSystemNamespaceName {
+				r.recordState("")
+			}
 			return ctrl.Result{}, nil
 		}
 		logger.Error(err, "unable to get BtpOperator CR")
@@ -217,11 +241,18 @@ func (r *BtpOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		logger.Info(fmt.Sprintf("BtpOperator CR %s/%s is not the one we are looking for. Ignoring it.", req.Namespace, req.Name))
 		return ctrl.Result{}, r.HandleWrongNamespaceOrName(ctx, reconcileCr)
 	}
+	r.recordState(reconcileCr.Status.State)
 
 	if ctrlutil.AddFinalizer(reconcileCr, deletionFinalizer) {
 		return ctrl.Result{}, r.Update(ctx, reconcileCr)
 	}
 
+	if reconcileCr.ObjectMeta.DeletionTimestamp.IsZero() && r.deprovisioningHandler != nil {
+		if err := r.deprovisioningHandler.WritePreview(ctx, reconcileCr); err != nil {
+			logger.Error(err, "while writing deprovisioning preview")
+		}
+	}
+
 	if !reconcileCr.ObjectMeta.DeletionTimestamp.IsZero() && reconcileCr.Status.State != v1alpha1.StateDeleting && !reconcileCr.IsReasonStringEqual(string(conditions.ServiceInstancesAndBindingsNotCleaned)) {
 		return ctrl.Result{}, r.UpdateBtpOperatorStatus(ctx, reconcileCr, v1alpha1.StateDeleting, conditions.HardDeleting, "BtpOperator is to be deleted")
 	}
@@ -230,7 +261,7 @@ func (r *BtpOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	case "":
 		return ctrl.Result{}, r.HandleInitialState(ctx, reconcileCr)
 	case v1alpha1.StateProcessing:
-		return ctrl.Result{RequeueAfter: config.ProcessingStateRequeueInterval}, r.HandleProcessingState(ctx, reconcileCr)
+		return ctrl.Result{RequeueAfter: cfg.ProcessingStateRequeueInterval}, r.HandleProcessingState(ctx, reconcileCr)
 	case v1alpha1.StateWarning:
 		return r.HandleWarningState(ctx, reconcileCr)
 	case v1alpha1.StateError:
@@ -238,11 +269,11 @@ func (r *BtpOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	case v1alpha1.StateDeleting:
 		err := r.HandleDeletingState(ctx, reconcileCr)
 		if reconcileCr.IsReasonStringEqual(string(conditions.ServiceInstancesAndBindingsNotCleaned)) {
-			return ctrl.Result{RequeueAfter: config.ReadyStateRequeueInterval}, err
+			return ctrl.Result{RequeueAfter: cfg.ReadyStateRequeueInterval}, err
 		}
 		return ctrl.Result{}, err
 	case v1alpha1.StateReady:
-		return ctrl.Result{RequeueAfter: config.ReadyStateRequeueInterval}, r.HandleReadyState(ctx, reconcileCr)
+		return ctrl.Result{RequeueAfter: cfg.ReadyStateRequeueInterval}, r.HandleReadyState(ctx, reconcileCr)
 	}
 
 	return ctrl.Result{}, nil
@@ -254,7 +285,8 @@ func (r *BtpOperatorReconciler) HandleWrongNamespaceOrName(ctx context.Context,
 
 func (r *BtpOperatorReconciler) UpdateBtpOperatorStatus(ctx context.Context, cr *v1alpha1.BtpOperator, newState v1alpha1.State, reason conditions.Reason, message string) error {
 	logger := log.FromContext(ctx)
-	timeout := time.Now().Add(config.StatusUpdateTimeout)
+	cfg := config.Current()
+	timeout := time.Now().Add(cfg.StatusUpdateTimeout)
 
 	var err error
 	for now := time.Now(); now.Before(timeout); now = time.Now() {
@@ -262,8 +294,8 @@ func (r *BtpOperatorReconciler) UpdateBtpOperatorStatus(ctx context.Context, cr
 			if k8serrors.IsNotFound(err) {
 				return nil
 			}
-			logger.Error(err, fmt.Sprintf("cannot get the BtpOperator to update the status. Retrying in %s...", config.StatusUpdateCheckInterval.String()))
-			time.Sleep(config.StatusUpdateCheckInterval)
+			logger.Error(err, fmt.Sprintf("cannot get the BtpOperator to update the status. Retrying in %s...", cfg.StatusUpdateCheckInterval.String()))
+			time.Sleep(cfg.StatusUpdateCheckInterval)
 			continue
 		}
 		if cr.Status.State == newState && cr.IsMsgForGivenReasonEqual(string(reason), message) {
@@ -275,17 +307,33 @@ func (r *BtpOperatorReconciler) UpdateBtpOperatorStatus(ctx context.Context, cr
 			conditions.SetStatusCondition(&cr.Status.Conditions, *newCondition)
 		}
 		if err = r.Status().Update(ctx, cr); err != nil {
-			logger.Error(err, fmt.Sprintf("cannot update the status of the BtpOperator. Retrying in %s...", config.StatusUpdateCheckInterval.String()))
-			time.Sleep(config.StatusUpdateCheckInterval)
+			logger.Error(err, fmt.Sprintf("cannot update the status of the BtpOperator. Retrying in %s...", cfg.StatusUpdateCheckInterval.String()))
+			time.Sleep(cfg.StatusUpdateCheckInterval)
 			continue
 		}
-		time.Sleep(config.StatusUpdateCheckInterval)
+		r.recordStatusUpdate(cr, newState, reason)
+		time.Sleep(cfg.StatusUpdateCheckInterval)
 	}
-	logger.Error(err, fmt.Sprintf("timed out while waiting %s for the BtpOperator status change.", config.StatusUpdateTimeout.String()))
+	logger.Error(err, fmt.Sprintf("timed out while waiting %s for the BtpOperator status change.", cfg.StatusUpdateTimeout.String()))
 
 	return err
 }
 
+func (r *BtpOperatorReconciler) recordState(state v1alpha1.State) {
+	if r.reconcileMetrics != nil {
+		r.reconcileMetrics.SetState(state)
+	}
+}
+
+// recordStatusUpdate records the state and the condition reason of the primary BtpOperator.
+func (r *BtpOperatorReconciler) recordStatusUpdate(cr *v1alpha1.BtpOperator, state v1alpha1.State, reason conditions.Reason) {
+	if r.reconcileMetrics == nil || cr.Name != config.BtpOperatorCrName || cr.Namespace != config.KymaSystemNamespaceName {
+		return
+	}
+	r.reconcileMetrics.SetState(state)
+	r.reconcileMetrics.IncrementConditionReason(reason)
+}
+
 func (r *BtpOperatorReconciler) HandleInitialState(ctx context.Context, cr *v1alpha1.BtpOperator) error {
 	logger := log.FromContext(ctx)
 	logger.Info("Handling Initial state")
@@ -314,7 +362,7 @@ func (r *BtpOperatorReconciler) HandleWarningState(ctx context.Context, cr *v1al
 		}
 		err := r.deprovisioningHandler.Deprovision(ctx, cr)
 		if cr.IsReasonStringEqual(string(conditions.ServiceInstancesAndBindingsNotCleaned)) {
-			return ctrl.Result{RequeueAfter: config.ReadyStateRequeueInterval}, err
+			return ctrl.Result{RequeueAfter: config.Current().ReadyStateRequeueInterval}, err
 		}
 		return ctrl.Result{}, err
 	}
@@ -369,6 +417,12 @@ func (r *BtpOperatorReconciler) HandleReadyState(ctx context.Context, cr *v1alph
 		return r.UpdateBtpOperatorStatus(ctx, cr, v1alpha1.StateError, conditions.ReconcileFailed, err.Error())
 	}
 
+	if r.operandRestorer != nil {
+		if err := r.operandRestorer.Restore(ctx); err != nil {
+			logger.Error(err, "while restoring service instances and bindings")
+		}
+	}
+
 	logger.Info("reconciliation succeeded")
 	return nil
 }
@@ -409,6 +463,15 @@ func (r *BtpOperatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		)
 
 	for _, watchHandler := range r.watchHandlers {
+		if cacheWatchHandler, ok := watchHandler.(config.CacheWatchHandler); ok {
+			controllerBuilder.WatchesRawSource(source.Kind(
+				cacheWatchHandler.Cache(),
+				watchHandler.Object(),
+				handler.EnqueueRequestsFromMapFunc(watchHandler.Reconcile),
+				watchHandler.Predicates(),
+			))
+			continue
+		}
 		controllerBuilder.Watches(
 			watchHandler.Object(),
 			handler.EnqueueRequestsFromMapFunc(watchHandler.Reconcile),
@@ -432,6 +495,10 @@ func (r *BtpOperatorReconciler) watchBtpOperatorUpdatePredicate() predicate.Func
 			if !ok {
 				return false
 			}
+			oldBtpOperator, ok := e.ObjectOld.(*v1alpha1.BtpOperator)
+			if ok && oldBtpOperator.GetAnnotations()[v1alpha1.DeprovisioningPreviewAnnotation] != newBtpOperator.GetAnnotations()[v1alpha1.DeprovisioningPreviewAnnotation] {
+				return true
+			}
 			state := newBtpOperator.GetStatus().State
 			if (state == v1alpha1.StateError || state == v1alpha1.StateWarning) && newBtpOperator.ObjectMeta.DeletionTimestamp.IsZero() {
 				return false
@@ -489,7 +556,8 @@ func deploymentConditionStatuses(d *appsv1.Deployment) (available, progressing s
 
 func (r *BtpOperatorReconciler) watchDeploymentPredicates() predicate.Funcs {
 	isManagedDeployment := func(obj client.Object) bool {
-		return obj.GetName() == config.DeploymentName && obj.GetNamespace() == config.ChartNamespace
+		cfg := config.Current()
+		return obj.GetName() == cfg.DeploymentName && obj.GetNamespace() == cfg.ChartNamespace
 	}
 	return predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
@@ -626,9 +694,10 @@ func (r *BtpOperatorReconciler) isManagedSecret(s *corev1.Secret) bool {
 }
 
 func (r *BtpOperatorReconciler) isCredentialsSecret(s *corev1.Secret) bool {
-	return s.Namespace == config.ChartNamespace && s.Name == config.SecretName
+	cfg := config.Current()
+	return s.Namespace == cfg.ChartNamespace && s.Name == cfg.SecretName
 }
 
 func (r *BtpOperatorReconciler) isCertSecret(s *corev1.Secret) bool {
-	return s.Namespace == config.ChartNamespace && (s.Name == certificate.CaCertSecretName || s.Name == certificate.WebhookCertSecretName)
+	return s.Namespace == config.Current().ChartNamespace && (s.Name == certificate.CaCertSecretName || s.Name == certificate.WebhookCertSecretName)
 }
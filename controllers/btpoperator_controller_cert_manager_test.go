@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/certs"
+	"github.com/kyma-project/btp-manager/internal/k8s/generic"
+	"github.com/kyma-project/btp-manager/internal/k8s/secrets"
+	btpmanagermetrics "github.com/kyma-project/btp-manager/internal/metrics"
+	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("BTP Operator controller - cert-manager resources", Label("cert-manager"), func() {
+	var certManager *certificate.Manager
+	var orgWebhookCertificateMode string
+
+	getCertManagerObject := func(kind, name string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: kind})
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: config.ChartNamespace, Name: name}, u)).To(Succeed())
+		return u
+	}
+
+	nestedString := func(u *unstructured.Unstructured, fields ...string) string {
+		value, _, err := unstructured.NestedString(u.Object, fields...)
+		Expect(err).NotTo(HaveOccurred())
+		return value
+	}
+
+	BeforeEach(func() {
+		orgWebhookCertificateMode = config.WebhookCertificateMode
+		config.WebhookCertificateMode = certificate.CertManagerMode
+
+		// the Secret cert-manager would write for the webhook Certificate, so that the manager doesn't wait for the issuer
+		issuedSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        certificate.WebhookCertSecretName,
+				Namespace:   config.ChartNamespace,
+				Annotations: map[string]string{"cert-manager.io/certificate-name": certificate.WebhookCertificateName},
+			},
+			Data: map[string][]byte{
+				certificate.WebhookCertSecretCertField: []byte("cert"),
+				certificate.WebhookCertSecretKeyField:  []byte("key"),
+				certificate.CertManagerCaField:         []byte("ca"),
+			},
+		}
+		Expect(k8sClient.Create(ctx, issuedSecret)).To(Succeed())
+
+		// strict field validation makes the API server reject the fields missing in the cert-manager CRDs instead of pruning them
+		strictClient := client.WithFieldValidation(k8sClient, metav1.FieldValidationStrict)
+		secretsManager := secrets.NewManager(generic.NewObjectManager[*corev1.Secret, *corev1.SecretList](k8sClient))
+		certManager = certificate.NewManager(secretsManager, btpmanagermetrics.NewWebhookMetrics(prometheus.NewRegistry()), strictClient)
+	})
+
+	AfterEach(func() {
+		config.WebhookCertificateMode = orgWebhookCertificateMode
+		for _, resourceType := range certificate.CertManagerResourceTypes() {
+			Expect(k8sClient.DeleteAllOf(ctx, resourceType, client.InNamespace(config.ChartNamespace))).To(Succeed())
+		}
+		issuedSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: certificate.WebhookCertSecretName, Namespace: config.ChartNamespace}}
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, issuedSecret))).To(Succeed())
+	})
+
+	It("creates Issuers and Certificates accepted by the cert-manager CRDs", func() {
+		_, err := certManager.PrepareAdmissionWebhooks(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		selfSignedIssuer := getCertManagerObject("Issuer", certificate.SelfSignedIssuerName)
+		_, found, _ := unstructured.NestedMap(selfSignedIssuer.Object, "spec", "selfSigned")
+		Expect(found).To(BeTrue())
+
+		caIssuer := getCertManagerObject("Issuer", certificate.CaIssuerName)
+		Expect(nestedString(caIssuer, "spec", "ca", "secretName")).To(Equal(certificate.CaCertSecretName))
+
+		caCertificate := getCertManagerObject("Certificate", certificate.CaCertificateName)
+		isCA, _, err := unstructured.NestedBool(caCertificate.Object, "spec", "isCA")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isCA).To(BeTrue())
+		Expect(nestedString(caCertificate, "spec", "secretName")).To(Equal(certificate.CaCertSecretName))
+		Expect(nestedString(caCertificate, "spec", "issuerRef", "name")).To(Equal(certificate.SelfSignedIssuerName))
+
+		webhookCertificate := getCertManagerObject("Certificate", certificate.WebhookCertificateName)
+		Expect(nestedString(webhookCertificate, "spec", "secretName")).To(Equal(certificate.WebhookCertSecretName))
+		Expect(nestedString(webhookCertificate, "spec", "issuerRef", "name")).To(Equal(certificate.CaIssuerName))
+		dnsNames, _, err := unstructured.NestedStringSlice(webhookCertificate.Object, "spec", "dnsNames")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dnsNames).To(Equal(certs.WebhookDnsNames()))
+		labels, _, err := unstructured.NestedStringMap(webhookCertificate.Object, "spec", "secretTemplate", "labels")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(labels).To(HaveKeyWithValue("app.kubernetes.io/managed-by", "btp-manager"))
+	})
+
+	It("updates the existing Issuers and Certificates accepted by the cert-manager CRDs", func() {
+		_, err := certManager.PrepareAdmissionWebhooks(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = certManager.PrepareAdmissionWebhooks(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
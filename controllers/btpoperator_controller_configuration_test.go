@@ -17,8 +17,6 @@ var _ = Describe("Configuration controller", func() {
 	var cr *v1alpha1.BtpOperator
 
 	Context("When EnableLimitedCache is created/updated", func() {
-		var originalValue string
-
 		BeforeEach(func() {
 			secret, err := createCorrectSecretFromYaml()
 			Expect(err).To(BeNil())
@@ -35,8 +33,6 @@ var _ = Describe("Configuration controller", func() {
 				EnableLimitedCacheConfigMapKey: "false",
 			}
 			Expect(k8sClient.Update(ctx, existing)).To(Succeed())
-
-			originalValue = config.EnableLimitedCache
 		})
 
 		AfterEach(func() {
@@ -50,7 +46,7 @@ var _ = Describe("Configuration controller", func() {
 			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: kymaNamespace, Name: config.SecretName}, deleteSecret)).To(Succeed())
 			Expect(k8sClient.Delete(ctx, deleteSecret)).To(Succeed())
 
-			config.EnableLimitedCache = originalValue
+			config.Reset()
 		})
 
 		It("should update EnableLimitedCache", func() {
@@ -59,7 +55,7 @@ var _ = Describe("Configuration controller", func() {
 			})
 
 			Eventually(func() string {
-				return config.EnableLimitedCache
+				return config.Current().EnableLimitedCache
 			}).Should(Equal("true"))
 
 			Eventually(func() map[string]string {
@@ -69,14 +65,8 @@ var _ = Describe("Configuration controller", func() {
 	})
 
 	Context("When ProcessingStateRequeueInterval is created/updated", func() {
-		var originalValue time.Duration
-
-		BeforeEach(func() {
-			originalValue = config.ProcessingStateRequeueInterval
-		})
-
 		AfterEach(func() {
-			config.ProcessingStateRequeueInterval = originalValue
+			config.Reset()
 		})
 
 		It("should update ProcessingStateRequeueInterval", func() {
@@ -85,7 +75,7 @@ var _ = Describe("Configuration controller", func() {
 			})
 
 			Eventually(func() time.Duration {
-				return config.ProcessingStateRequeueInterval
+				return config.Current().ProcessingStateRequeueInterval
 			}).Should(Equal(10 * time.Second))
 		})
 	})
@@ -39,6 +39,7 @@ var _ = Describe("BTP Operator controller - deprovisioning", func() {
 		AfterEach(func() {
 			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: kymaNamespace, Name: btpOperatorName}, cr)).To(Succeed())
 			cr.SetLabels(map[string]string{forceDeleteLabelKey: "true"})
+			cr.SetAnnotations(map[string]string{v1alpha1.ForceDeleteConfirmationAnnotation: "instances=1,bindings=1"})
 			Expect(k8sClient.Update(ctx, cr)).To(Succeed())
 			Eventually(func() (bool, error) {
 				err := k8sClient.Get(ctx, client.ObjectKey{Namespace: kymaNamespace, Name: btpOperatorName}, cr)
@@ -75,6 +76,7 @@ var _ = Describe("BTP Operator controller - deprovisioning", func() {
 			Expect(k8sClient.Create(ctx, secret, client.FieldOwner(operatorName))).To(Succeed())
 			cr = createDefaultBtpOperator()
 			cr.SetLabels(map[string]string{forceDeleteLabelKey: "true"})
+			cr.SetAnnotations(map[string]string{v1alpha1.ForceDeleteConfirmationAnnotation: "instances=1,bindings=1"})
 			Expect(k8sClient.Create(ctx, cr)).To(Succeed())
 			Eventually(updateCh).Should(Receive(matchState(v1alpha1.StateReady)))
 			btpServiceOperatorDeployment := &appsv1.Deployment{}
@@ -124,6 +126,24 @@ var _ = Describe("BTP Operator controller - deprovisioning", func() {
 			Eventually(updateCh).Should(Receive(matchDeleted()))
 			doChecks()
 		})
+
+		It("should wait for the confirmation", func() {
+			reconciler.Client = k8sClientFromManager
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: kymaNamespace, Name: btpOperatorName}, cr)).To(Succeed())
+			cr.SetAnnotations(map[string]string{v1alpha1.ForceDeleteConfirmationAnnotation: "instances=2,bindings=1"})
+			Expect(k8sClient.Update(ctx, cr)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, cr)).Should(Succeed())
+			Eventually(updateCh).Should(Receive(matchReadyCondition(v1alpha1.StateWarning, metav1.ConditionFalse, conditions.ServiceInstancesAndBindingsNotCleaned)))
+			ensureResourceExists(instanceGvk)
+
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: kymaNamespace, Name: btpOperatorName}, cr)).To(Succeed())
+			Expect(cr.Status.ForceDeleteConfirmationToken).NotTo(BeEmpty())
+			cr.SetAnnotations(map[string]string{v1alpha1.ForceDeleteConfirmationAnnotation: cr.Status.ForceDeleteConfirmationToken})
+			Expect(k8sClient.Update(ctx, cr)).To(Succeed())
+			Eventually(updateCh).Should(Receive(matchReadyCondition(v1alpha1.StateDeleting, metav1.ConditionFalse, conditions.HardDeleting)))
+			Eventually(updateCh).Should(Receive(matchDeleted()))
+			doChecks()
+		})
 	})
 
 	Describe("Deprovisioning with network policies", func() {
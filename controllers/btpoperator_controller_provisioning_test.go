@@ -89,21 +89,15 @@ var _ = Describe("BTP Operator controller - provisioning", func() {
 			})
 
 			Context("when EnableLimitedCache configuration is modified", func() {
-				var originalValue string
-
-				BeforeEach(func() {
-					originalValue = config.EnableLimitedCache
-				})
-
 				AfterEach(func() {
-					config.EnableLimitedCache = originalValue
+					config.Reset()
 				})
 
 				It("should set EnableLimitedCache to false in operator ConfigMap when configured", func() {
 
 					// set via reconciler to exercise production code path
 					createOrUpdateConfigMap(map[string]string{"EnableLimitedCache": "false"})
-					Eventually(func() string { return config.EnableLimitedCache }).Should(Equal("false"))
+					Eventually(func() string { return config.Current().EnableLimitedCache }).Should(Equal("false"))
 
 					secret, err := createCorrectSecretFromYaml()
 					Expect(err).To(BeNil())
@@ -118,7 +112,7 @@ var _ = Describe("BTP Operator controller - provisioning", func() {
 
 					// set via reconciler to exercise production code path
 					createOrUpdateConfigMap(map[string]string{"EnableLimitedCache": "false"})
-					Eventually(func() string { return config.EnableLimitedCache }).Should(Equal("false"))
+					Eventually(func() string { return config.Current().EnableLimitedCache }).Should(Equal("false"))
 
 					secret, err := createCorrectSecretFromYaml()
 					Expect(err).To(BeNil())
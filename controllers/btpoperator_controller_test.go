@@ -8,7 +8,9 @@ import (
 	"github.com/kyma-project/btp-manager/api/v1alpha1"
 	"github.com/kyma-project/btp-manager/controllers/config"
 	"github.com/kyma-project/btp-manager/internal/conditions"
+	"github.com/kyma-project/btp-manager/internal/metrics"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -177,4 +179,35 @@ func TestBtpOperatorReconciler_UpdateBtpOperatorStatus(t *testing.T) {
 		assert.Equal(t, 1, len(currentBtpOperator.Status.Conditions))
 		assert.True(t, currentBtpOperator.IsMsgForGivenReasonEqual(string(conditions.ReconcileSucceeded), conditionMsg3))
 	})
+
+	t.Run("should record the state and the condition reason", func(t *testing.T) {
+		// given
+		registry := prometheus.NewRegistry()
+		btpOperatorReconciler := NewBtpOperatorReconciler(newLazyK8sClient(fakeK8sClient, 0), fakeK8sClient, scheme, nil, nil, []config.WatchHandler{}, nil, nil, nil, nil)
+		btpOperatorReconciler.SetReconcileMetrics(metrics.NewReconcileMetrics(registry))
+
+		// when
+		err := btpOperatorReconciler.UpdateBtpOperatorStatus(ctx, btpOperator, v1alpha1.StateError, conditions.ReconcileFailed, "test")
+
+		// then
+		require.NoError(t, err)
+		families, err := registry.Gather()
+		require.NoError(t, err)
+		states := map[string]float64{}
+		counts := map[string]float64{}
+		for _, family := range families {
+			for _, m := range family.GetMetric() {
+				switch family.GetName() {
+				case "btpmanager_btpoperator_state":
+					states[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+				case "btpmanager_btpoperator_condition_reasons_total":
+					counts[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+				}
+			}
+		}
+		assert.Equal(t, map[string]float64{"Processing": 0, "Ready": 0, "Warning": 0, "Error": 1, "Deleting": 0}, states)
+		assert.Len(t, counts, len(conditions.Reasons))
+		assert.Equal(t, 1.0, counts[string(conditions.ReconcileFailed)])
+		assert.Equal(t, 0.0, counts[string(conditions.ReconcileSucceeded)])
+	})
 }
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	configMapIgnoredReason  = "ConfigMapIgnored"
+	configMapMigratedReason = "ConfigMapMigrated"
+	managedByLabelKey       = "app.kubernetes.io/managed-by"
+	managedByLabelValue     = "btp-manager"
+)
+
+// configurationWatchHandler watches the BtpManagerConfiguration, which takes precedence over the ConfigMap.
+type configurationWatchHandler struct {
+	handler *Handler
+}
+
+// ConfigurationWatchHandler returns the WatchHandler applying the BtpManagerConfiguration.
+func (r *Handler) ConfigurationWatchHandler() WatchHandler {
+	return &configurationWatchHandler{handler: r}
+}
+
+func (h *configurationWatchHandler) Object() client.Object {
+	return &v1alpha1.BtpManagerConfiguration{}
+}
+
+func (h *configurationWatchHandler) Predicates() predicate.Funcs {
+	nameMatches := func(o client.Object) bool {
+		cfg := Current()
+		return o.GetName() == cfg.ConfigName && o.GetNamespace() == cfg.ChartNamespace
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return nameMatches(e.Object)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			matches := nameMatches(e.Object)
+			if matches {
+				h.handler.configurationApplied.Store(false)
+				h.handler.configMetrics.ConfigMapNotApplied()
+				h.handler.configMetrics.SetRejectedKeys(0)
+				h.handler.recordRejected(nil)
+			}
+			return matches
+		},
+		// The status updates don't change the generation.
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return nameMatches(e.ObjectNew) && e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
+		},
+	}
+}
+
+func (h *configurationWatchHandler) Reconcile(ctx context.Context, obj client.Object) []reconcile.Request {
+	configuration, ok := obj.(*v1alpha1.BtpManagerConfiguration)
+	if !ok {
+		return []reconcile.Request{}
+	}
+	h.handler.applyConfiguration(ctx, configuration)
+	return btpOperatorRequest()
+}
+
+// applyConfiguration applies the fields of the BtpManagerConfiguration spec that are set and reports in its status whether each of them was applied.
+func (r *Handler) applyConfiguration(ctx context.Context, configuration *v1alpha1.BtpManagerConfiguration) {
+	log.FromContext(ctx).Info("reconciling configuration update", "BtpManagerConfiguration", client.ObjectKeyFromObject(configuration))
+	rejected := r.apply(ctx, configuration, specData(&configuration.Spec), SourceConfiguration)
+	r.configurationApplied.Store(true)
+	r.updateConfigurationStatus(ctx, configuration, rejected)
+}
+
+func (r *Handler) updateConfigurationStatus(ctx context.Context, configuration *v1alpha1.BtpManagerConfiguration, rejected []RejectedKey) {
+	reasons := make(map[string]string, len(rejected))
+	for _, k := range rejected {
+		reasons[k.Key] = k.Reason
+	}
+
+	updated := configuration.DeepCopy()
+	spec := reflect.ValueOf(updated.Spec)
+	fields := make([]v1alpha1.ConfigurationFieldStatus, 0, spec.NumField())
+	rejectedFields := make([]string, 0, len(rejected))
+	for i := range spec.NumField() {
+		if spec.Field(i).IsNil() {
+			continue
+		}
+		field := spec.Type().Field(i)
+		name := jsonName(field)
+		reason, isRejected := reasons[field.Name]
+		fields = append(fields, v1alpha1.ConfigurationFieldStatus{Name: name, Applied: !isRejected, Reason: reason})
+		if isRejected {
+			rejectedFields = append(rejectedFields, fmt.Sprintf("%s: %s", name, reason))
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConfigurationAppliedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             v1alpha1.ConfigurationAppliedReason,
+		Message:            "All fields are applied",
+		ObservedGeneration: updated.Generation,
+	}
+	if len(rejectedFields) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1alpha1.ConfigurationFieldsRejectedReason
+		condition.Message = fmt.Sprintf("Rejected fields, the last accepted values stay in effect: %s", strings.Join(rejectedFields, "; "))
+	}
+	meta.SetStatusCondition(&updated.Status.Conditions, condition)
+	updated.Status.ObservedGeneration = updated.Generation
+	updated.Status.Fields = fields
+
+	if err := r.Status().Update(ctx, updated); err != nil {
+		log.FromContext(ctx).Error(err, "while updating the BtpManagerConfiguration status")
+	}
+}
+
+// migrateConfigMap creates the BtpManagerConfiguration with the accepted keys of the ConfigMap,
+// so that the configuration is managed by the BtpManagerConfiguration from now on.
+// The keys missing in the ConfigMap stay unset, so that the CLI flags and the defaults keep applying to them.
+func (r *Handler) migrateConfigMap(ctx context.Context, cm *corev1.ConfigMap, rejected []RejectedKey) {
+	logger := log.FromContext(ctx)
+	isRejected := make(map[string]bool, len(rejected))
+	for _, k := range rejected {
+		isRejected[k.Key] = true
+	}
+	keys := make([]string, 0, len(cm.Data))
+	for key := range cm.Data {
+		if !isRejected[key] {
+			keys = append(keys, key)
+		}
+	}
+	configuration := &v1alpha1.BtpManagerConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cm.Name,
+			Namespace: cm.Namespace,
+			Labels:    map[string]string{managedByLabelKey: managedByLabelValue},
+		},
+		Spec: specFromConfig(Current(), keys),
+	}
+	if err := r.Create(ctx, configuration); err != nil {
+		if !k8serrors.IsAlreadyExists(err) {
+			logger.Error(err, "while migrating the ConfigMap to a BtpManagerConfiguration")
+		}
+		return
+	}
+
+	msg := fmt.Sprintf("Migrated the ConfigMap to the BtpManagerConfiguration %s/%s, which takes precedence over the ConfigMap", configuration.Namespace, configuration.Name)
+	logger.Info(msg)
+	if r.recorder != nil {
+		r.recorder.Eventf(cm, nil, corev1.EventTypeNormal, configMapMigratedReason, "Migrate", "%s", msg)
+	}
+}
+
+// configurationExists reports whether the BtpManagerConfiguration named like the ConfigMap exists.
+func configurationExists(ctx context.Context, reader client.Reader, cm *corev1.ConfigMap) (bool, error) {
+	err := reader.Get(ctx, types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, &v1alpha1.BtpManagerConfiguration{})
+	if err == nil {
+		return true, nil
+	}
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (r *Handler) reportConfigMapIgnored(ctx context.Context, cm *corev1.ConfigMap) {
+	msg := fmt.Sprintf("ConfigMap ignored, the BtpManagerConfiguration %s/%s takes precedence", cm.Namespace, cm.Name)
+	log.FromContext(ctx).Info(msg)
+	if r.recorder != nil {
+		r.recorder.Eventf(cm, nil, corev1.EventTypeWarning, configMapIgnoredReason, "Apply", "%s", msg)
+	}
+}
+
+// specData returns the fields of the spec that are set as ConfigMap data, so that they are validated and applied like the ConfigMap.
+func specData(spec *v1alpha1.BtpManagerConfigurationSpec) map[string]string {
+	v := reflect.ValueOf(spec).Elem()
+	data := make(map[string]string, v.NumField())
+	for i := range v.NumField() {
+		field := v.Field(i)
+		if field.IsNil() {
+			continue
+		}
+		key := v.Type().Field(i).Name
+		switch value := field.Elem().Interface().(type) {
+		case metav1.Duration:
+			data[key] = value.Duration.String()
+		default:
+			data[key] = fmt.Sprint(value)
+		}
+	}
+	return data
+}
+
+// specFromConfig returns the spec setting the fields named in keys to the value of the snapshot.
+func specFromConfig(c *Config, keys []string) v1alpha1.BtpManagerConfigurationSpec {
+	values := c.values()
+	spec := v1alpha1.BtpManagerConfigurationSpec{}
+	v := reflect.ValueOf(&spec).Elem()
+	for _, key := range keys {
+		field := v.FieldByName(key)
+		if !field.IsValid() {
+			continue
+		}
+		value := reflect.New(field.Type().Elem())
+		switch typed := values[key].(type) {
+		case time.Duration:
+			value.Elem().Set(reflect.ValueOf(metav1.Duration{Duration: typed}))
+		case int:
+			value.Elem().SetInt(int64(typed))
+		case bool:
+			value.Elem().SetBool(typed)
+		case string:
+			// EnableLimitedCache is a string in the ConfigMap and a boolean in the spec.
+			if value.Elem().Kind() == reflect.Bool {
+				value.Elem().SetBool(typed == "true")
+			} else {
+				value.Elem().SetString(typed)
+			}
+		default:
+			continue
+		}
+		field.Set(value)
+	}
+	return spec
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
+
+func btpOperatorRequest() []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: BtpOperatorCrName, Namespace: KymaSystemNamespaceName}}}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const configurationCRDPath = "../../config/crd/bases/operator.kyma-project.io_btpmanagerconfigurations.yaml"
+
+func TestSpecCoversConfigKeys(t *testing.T) {
+	specType := reflect.TypeOf(v1alpha1.BtpManagerConfigurationSpec{})
+	for key := range configKeys {
+		if _, exists := specType.FieldByName(key); !exists {
+			t.Errorf("configuration key %s has no BtpManagerConfiguration spec field", key)
+		}
+	}
+	if specType.NumField() != len(configKeys) {
+		t.Errorf("expected %d spec fields, got %d", len(configKeys), specType.NumField())
+	}
+
+	keys := make([]string, 0, len(configKeys))
+	for key := range configKeys {
+		keys = append(keys, key)
+	}
+	spec := specFromConfig(defaults(), keys)
+	changes, rejected := parseConfig(specData(&spec))
+	if len(rejected) != 0 {
+		t.Fatalf("the spec of the defaults is rejected: %v", rejected)
+	}
+	var got Config
+	for _, apply := range changes {
+		apply(&got)
+	}
+	if want := *defaults(); got != want {
+		t.Fatalf("the spec doesn't round-trip the defaults\nwant: %#v\ngot:  %#v", want, got)
+	}
+}
+
+func TestSpecDataSkipsUnsetFields(t *testing.T) {
+	spec := specFromConfig(defaults(), []string{"ReadyTimeout", "EnableLimitedCache"})
+
+	got := specData(&spec)
+
+	want := map[string]string{"ReadyTimeout": "5m0s", "EnableLimitedCache": "true"}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want: %v\ngot:  %v", want, got)
+	}
+	if data := specData(&v1alpha1.BtpManagerConfigurationSpec{}); len(data) != 0 {
+		t.Fatalf("an empty spec must not set any key, got %v", data)
+	}
+}
+
+// TestCRDHasNoSpecDefaults guards against defaults in the CRD, which would override the CLI flags with every BtpManagerConfiguration.
+func TestCRDHasNoSpecDefaults(t *testing.T) {
+	raw, err := os.ReadFile(configurationCRDPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(raw, crd); err != nil {
+		t.Fatal(err)
+	}
+	properties := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties
+
+	for name, property := range properties {
+		if property.Default != nil {
+			t.Errorf("spec field %s has the default %s", name, property.Default.Raw)
+		}
+	}
+	if want := reflect.TypeOf(v1alpha1.BtpManagerConfigurationSpec{}).NumField(); len(properties) != want {
+		t.Errorf("expected %d spec properties, got %d", want, len(properties))
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DebugConfigPath is the path of the metrics server endpoint that serves the effective configuration.
+const DebugConfigPath = "/debug/config"
+
+// Sources of the configuration values in effect.
+const (
+	SourceDefault   = "default"
+	SourceFlag      = "flag"
+	SourceConfigMap = "configmap"
+	// SourceConfiguration is the BtpManagerConfiguration.
+	SourceConfiguration = "configuration"
+)
+
+// EffectiveKey is the value in effect of a configuration key and where it came from.
+type EffectiveKey struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	// ChangedAt is the last time the ConfigMap or the BtpManagerConfiguration changed the value. It is not set for defaults and CLI parameters.
+	ChangedAt *time.Time `json:"changedAt,omitempty"`
+}
+
+// EffectiveConfig is the configuration in effect with the keys rejected from the ConfigMap or the BtpManagerConfiguration.
+type EffectiveConfig struct {
+	Keys     map[string]EffectiveKey `json:"keys"`
+	Rejected []RejectedKey           `json:"rejected"`
+}
+
+// WithFlags records the keys set by CLI parameters. Call it after the flags are parsed.
+// A flag matches the key with the same name without dashes, for example, --probe-interval sets ProbeInterval.
+func (r *Handler) WithFlags(fs *flag.FlagSet) *Handler {
+	keys := make(map[string]string, len(configKeys))
+	for key := range configKeys {
+		keys[strings.ToLower(key)] = key
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if key, found := keys[strings.ReplaceAll(f.Name, "-", "")]; found {
+			r.flagKeys[key] = true
+		}
+	})
+	return r
+}
+
+// Effective returns the configuration in effect with the origin of each value.
+func (r *Handler) Effective() EffectiveConfig {
+	r.provenanceMu.RLock()
+	defer r.provenanceMu.RUnlock()
+
+	values := Current().values()
+	effective := EffectiveConfig{
+		Keys:     make(map[string]EffectiveKey, len(values)),
+		Rejected: make([]RejectedKey, 0, len(r.rejected)),
+	}
+	for key, value := range values {
+		k := EffectiveKey{Value: redact(key, value), Source: SourceDefault}
+		source, applied := r.keySources[key]
+		switch {
+		case applied:
+			k.Source = source
+			if changedAt, changed := r.changedAt[key]; changed {
+				k.ChangedAt = &changedAt
+			}
+		case r.flagKeys[key]:
+			k.Source = SourceFlag
+		}
+		effective.Keys[key] = k
+	}
+	for _, rejected := range r.rejected {
+		rejected.Value = redact(rejected.Key, rejected.Value)
+		effective.Rejected = append(effective.Rejected, rejected)
+	}
+	return effective
+}
+
+// ServeHTTP serves the effective configuration as JSON.
+func (r *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(r.Effective())
+}
+
+// recordProvenance records that the keys accepted from the data of source are in effect, and when their values changed.
+func (r *Handler) recordProvenance(data map[string]string, source string, rejected []RejectedKey, changedKeys []string) {
+	now := r.now()
+	isRejected := make(map[string]bool, len(rejected))
+	for _, k := range rejected {
+		isRejected[k.Key] = true
+	}
+
+	r.provenanceMu.Lock()
+	defer r.provenanceMu.Unlock()
+	for key := range data {
+		if !isRejected[key] {
+			r.keySources[key] = source
+		}
+	}
+	for _, key := range changedKeys {
+		r.changedAt[key] = now
+	}
+	r.rejected = rejected
+}
+
+func (r *Handler) recordRejected(rejected []RejectedKey) {
+	r.provenanceMu.Lock()
+	defer r.provenanceMu.Unlock()
+	r.rejected = rejected
+}
+
+// redactedValue replaces a proxy value that can't be parsed as a URL with a host, because it may still hold a password.
+const redactedValue = "<redacted>"
+
+// redact hides the password in the proxy URLs.
+func redact(key string, value any) string {
+	s, isString := value.(string)
+	if !isString {
+		return fmt.Sprint(value)
+	}
+	if s == "" || (key != "HttpProxy" && key != "HttpsProxy") {
+		return s
+	}
+	// A value without a scheme, such as user:secret@proxy:3128, either fails to parse or parses as an opaque URL.
+	u, err := url.Parse(s)
+	if err != nil || u.Opaque != "" || u.Host == "" {
+		return redactedValue
+	}
+	return u.Redacted()
+}
@@ -2,17 +2,23 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sort"
-	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
 	"github.com/kyma-project/btp-manager/internal/certs"
 	"github.com/kyma-project/btp-manager/internal/metrics"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -23,6 +29,16 @@ import (
 const (
 	BtpOperatorCrName       = "btpoperator"
 	KymaSystemNamespaceName = "kyma-system"
+
+	configRejectedReason = "ConfigurationRejected"
+)
+
+// CA bundle probe modes.
+const (
+	// ProbeModeJob runs the probe in a Job, so that it sees the CA bundle injected into Pods by rt-bootstrapper.
+	ProbeModeJob = "Job"
+	// ProbeModeInProcess runs the probe in btp-manager with the system CA bundle and the managed trust bundle.
+	ProbeModeInProcess = "InProcess"
 )
 
 // Configuration options that can be overwritten either by CLI parameter or ConfigMap
@@ -39,12 +55,24 @@ var (
 	HardDeleteTimeout              = time.Minute * 20
 	HardDeleteCheckInterval        = time.Second * 10
 	DeleteRequestTimeout           = time.Minute * 5
+	HardDeleteConcurrency          = 10
+	HardDeleteQPS                  = 20
 	StatusUpdateTimeout            = time.Second * 10
 	StatusUpdateCheckInterval      = time.Millisecond * 500
 
-	CaCertificateExpiration      = time.Hour * 87600 // 10 years
-	WebhookCertificateExpiration = time.Hour * 8760  // 1 year
-	ExpirationBoundary           = time.Hour * -168  // 1 week
+	CaCertificateExpiration            = time.Hour * 87600 // 10 years
+	WebhookCertificateExpiration       = time.Hour * 8760  // 1 year
+	ExpirationBoundary                 = time.Hour * -168  // 1 week
+	WebhookCertificateMode             = "self-signed"
+	CaRotationAdvance                  = time.Hour * 24
+	CaRotationReloadTimeout            = time.Minute * 10
+	CertificateRegenerationGracePeriod = time.Hour
+	ExternalCaSecret                   = ""
+	WebhookSelfTestInterval            = time.Minute * 10
+	WebhookSelfTestTimeout             = time.Minute * 5
+
+	RestoreServiceInstancesAndBindings = false
+	ForceDeleteConfirmationRequired    = true
 
 	ChartPath            = "./module-chart/chart"
 	ResourcesPath        = "./module-resources"
@@ -52,7 +80,21 @@ var (
 
 	EnableLimitedCache = "true"
 
-	ProbeInterval = time.Hour
+	ProbeInterval           = time.Hour
+	ProbeMode               = ProbeModeJob
+	ProbeHistoryLength      = 10
+	ProbeAlertThreshold     = 1
+	ProbeErrorThreshold     = 1
+	ProbeRestartMinInterval = time.Hour * 6
+	ProbeCAExpiryWarning    = time.Hour * 24 * 30
+
+	HttpProxy  = ""
+	HttpsProxy = ""
+	NoProxy    = ""
+
+	TrustBundleConfigMap = ""
+	TrustBundleSecret    = ""
+	TrustBundleKey       = "ca-bundle.crt"
 )
 
 type WatchHandler interface {
@@ -61,37 +103,32 @@ type WatchHandler interface {
 	Reconcile(ctx context.Context, obj client.Object) []reconcile.Request
 }
 
+// CacheWatchHandler is a WatchHandler whose objects are watched through its own cache instead of the manager cache,
+// which holds only the ConfigMaps and Secrets labeled as managed by BTP Manager.
+type CacheWatchHandler interface {
+	WatchHandler
+	Cache() cache.Cache
+}
+
 type Handler struct {
 	client.Client
 	Scheme        *runtime.Scheme
 	configMetrics *metrics.ConfigMetrics
-}
+	recorder      events.EventRecorder
+	now           func() time.Time
 
-func configSnapshot() map[string]any {
-	return map[string]any{
-		"ChartNamespace":                 ChartNamespace,
-		"ChartPath":                      ChartPath,
-		"SecretName":                     SecretName,
-		"ConfigName":                     ConfigName,
-		"DeploymentName":                 DeploymentName,
-		"ProcessingStateRequeueInterval": ProcessingStateRequeueInterval,
-		"ReadyStateRequeueInterval":      ReadyStateRequeueInterval,
-		"ReadyTimeout":                   ReadyTimeout,
-		"HardDeleteCheckInterval":        HardDeleteCheckInterval,
-		"HardDeleteTimeout":              HardDeleteTimeout,
-		"ResourcesPath":                  ResourcesPath,
-		"ReadyCheckInterval":             ReadyCheckInterval,
-		"DeleteRequestTimeout":           DeleteRequestTimeout,
-		"CaCertificateExpiration":        CaCertificateExpiration,
-		"WebhookCertificateExpiration":   WebhookCertificateExpiration,
-		"ExpirationBoundary":             ExpirationBoundary,
-		"RsaKeyBits":                     certs.RsaKeyBits(),
-		"EnableLimitedCache":             EnableLimitedCache,
-		"ProbeInterval":                  ProbeInterval,
-		"StatusUpdateTimeout":            StatusUpdateTimeout,
-		"StatusUpdateCheckInterval":      StatusUpdateCheckInterval,
-		"ManagerResourcesPath":           ManagerResourcesPath,
-	}
+	// flagKeys are the keys set by CLI parameters.
+	flagKeys map[string]bool
+
+	// configurationApplied is set while the applied BtpManagerConfiguration exists, which takes precedence over the ConfigMap.
+	configurationApplied atomic.Bool
+
+	// provenanceMu guards the origin of the values in effect, which the debug endpoint reads.
+	provenanceMu sync.RWMutex
+	// keySources are the sources of the keys whose value in effect was set by the ConfigMap or the BtpManagerConfiguration.
+	keySources map[string]string
+	changedAt  map[string]time.Time
+	rejected   []RejectedKey
 }
 
 func changedSnapshotKeys(before, after map[string]any) []string {
@@ -120,46 +157,64 @@ func NewHandler(client client.Client, scheme *runtime.Scheme, configMetrics *met
 		Client:        client,
 		Scheme:        scheme,
 		configMetrics: configMetrics,
+		now:           time.Now,
+		flagKeys:      map[string]bool{},
+		keySources:    map[string]string{},
+		changedAt:     map[string]time.Time{},
 	}
 }
 
+// WithEventRecorder sets the recorder for the Events reporting rejected configuration keys.
+func (r *Handler) WithEventRecorder(recorder events.EventRecorder) *Handler {
+	r.recorder = recorder
+	return r
+}
+
 func (r *Handler) Object() client.Object {
 	return &corev1.ConfigMap{}
 }
 
 func (r *Handler) Predicates() predicate.Funcs {
 	nameMatches := func(o client.Object) bool {
-		return o.GetName() == ConfigName && o.GetNamespace() == ChartNamespace
+		cfg := Current()
+		return o.GetName() == cfg.ConfigName && o.GetNamespace() == cfg.ChartNamespace
 	}
 	return predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
-			matches := nameMatches(e.Object)
-			if matches {
-				r.configMetrics.ConfigMapApplied()
-			}
-			return matches
+			return nameMatches(e.Object)
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
 			matches := nameMatches(e.Object)
-			if matches {
+			if matches && !r.configurationApplied.Load() {
 				r.configMetrics.ConfigMapNotApplied()
+				r.configMetrics.SetRejectedKeys(0)
+				r.recordRejected(nil)
 			}
 			return matches
 		},
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			matches := nameMatches(e.ObjectNew)
-			if matches {
-				r.configMetrics.ConfigMapApplied()
-			}
-			return matches
+			return nameMatches(e.ObjectNew)
 		},
 	}
 }
 
 func (r *Handler) Start(ctx context.Context) error {
 	logger := log.FromContext(ctx)
+	cfg := Current()
+	configuration := &v1alpha1.BtpManagerConfiguration{}
+	err := r.Get(ctx, types.NamespacedName{Name: cfg.ConfigName, Namespace: cfg.ChartNamespace}, configuration)
+	if err == nil {
+		logger.Info("BtpManagerConfiguration found at startup, updating metrics")
+		changes, rejected := parseConfig(specData(&configuration.Spec))
+		r.configurationApplied.Store(true)
+		r.recordOutcome(len(changes), rejected)
+		return nil
+	}
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
 	cm := &corev1.ConfigMap{}
-	err := r.Get(ctx, types.NamespacedName{Name: ConfigName, Namespace: ChartNamespace}, cm)
+	err = r.Get(ctx, types.NamespacedName{Name: cfg.ConfigName, Namespace: cfg.ChartNamespace}, cm)
 	if err != nil {
 		if client.IgnoreNotFound(err) != nil {
 			return err
@@ -167,105 +222,112 @@ func (r *Handler) Start(ctx context.Context) error {
 		logger.Info("custom configuration ConfigMap not found at startup")
 		return nil
 	}
-	logger.Info("custom configuration ConfigMap found at startup, setting metric to 1")
-	r.configMetrics.ConfigMapApplied()
+	logger.Info("custom configuration ConfigMap found at startup, updating metrics")
+	changes, rejected := parseConfig(cm.Data)
+	r.recordOutcome(len(changes), rejected)
 	return nil
 }
 
-// ApplyFromAPI reads the config ConfigMap using a direct API reader (bypassing the cache)
-// and applies its values. Intended to be called before mgr.Start() to ensure config vars
-// are set before runnables start.
+// ApplyFromAPI reads the BtpManagerConfiguration, or the config ConfigMap if the BtpManagerConfiguration doesn't exist,
+// using a direct API reader (bypassing the cache) and applies its values. Intended to be called before mgr.Start()
+// to ensure the config snapshot is published before runnables start.
 func (r *Handler) ApplyFromAPI(ctx context.Context, reader client.Reader) error {
+	cfg := Current()
+	key := types.NamespacedName{Name: cfg.ConfigName, Namespace: cfg.ChartNamespace}
+	configuration := &v1alpha1.BtpManagerConfiguration{}
+	err := reader.Get(ctx, key, configuration)
+	if err == nil {
+		r.applyConfiguration(ctx, configuration)
+		return nil
+	}
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
 	cm := &corev1.ConfigMap{}
-	if err := reader.Get(ctx, types.NamespacedName{Name: ConfigName, Namespace: ChartNamespace}, cm); err != nil {
+	if err := reader.Get(ctx, key, cm); err != nil {
 		return err
 	}
-	r.Reconcile(ctx, cm)
+	r.reconcileConfigMap(ctx, reader, cm)
 	return nil
 }
 
 func (r *Handler) Reconcile(ctx context.Context, obj client.Object) []reconcile.Request {
-	logger := log.FromContext(ctx)
-	parseDuration := func(raw string, defaultValue time.Duration, key string) time.Duration {
-		parsed, err := time.ParseDuration(raw)
-		if err != nil {
-			logger.Info("failed to parse configuration update", key, err)
-			return defaultValue
-		}
-		return parsed
-	}
-
 	cm, ok := obj.(*corev1.ConfigMap)
 	if !ok {
 		return []reconcile.Request{}
 	}
+	if !r.reconcileConfigMap(ctx, r.Client, cm) {
+		return []reconcile.Request{}
+	}
+	return btpOperatorRequest()
+}
+
+// reconcileConfigMap applies the ConfigMap and migrates it to a BtpManagerConfiguration, unless the BtpManagerConfiguration
+// already exists. If the BtpManagerConfiguration can't be read, the ConfigMap is applied without the migration.
+func (r *Handler) reconcileConfigMap(ctx context.Context, reader client.Reader, cm *corev1.ConfigMap) bool {
+	logger := log.FromContext(ctx)
 
-	beforeConfig := configSnapshot()
+	exists, err := configurationExists(ctx, reader, cm)
+	if err != nil {
+		logger.Error(err, "while reading the BtpManagerConfiguration, applying the ConfigMap without migration")
+	}
+	if exists {
+		r.reportConfigMapIgnored(ctx, cm)
+		return false
+	}
 
 	logger.Info("reconciling configuration update", "config", cm.Data)
+	rejected := r.apply(ctx, cm, cm.Data, SourceConfigMap)
+	if err == nil {
+		r.migrateConfigMap(ctx, cm, rejected)
+	}
+	return true
+}
 
-	for k, v := range cm.Data {
-		var err error
-		switch k {
-		case "ChartNamespace":
-			ChartNamespace = v
-		case "ChartPath":
-			ChartPath = v
-		case "SecretName":
-			SecretName = v
-		case "ConfigName":
-			ConfigName = v
-		case "DeploymentName":
-			DeploymentName = v
-		case "ProcessingStateRequeueInterval":
-			ProcessingStateRequeueInterval = parseDuration(v, ProcessingStateRequeueInterval, k)
-		case "ReadyStateRequeueInterval":
-			ReadyStateRequeueInterval = parseDuration(v, ReadyStateRequeueInterval, k)
-		case "ReadyTimeout":
-			ReadyTimeout = parseDuration(v, ReadyTimeout, k)
-		case "HardDeleteCheckInterval":
-			HardDeleteCheckInterval = parseDuration(v, HardDeleteCheckInterval, k)
-		case "HardDeleteTimeout":
-			HardDeleteTimeout = parseDuration(v, HardDeleteTimeout, k)
-		case "ResourcesPath":
-			ResourcesPath = v
-		case "ReadyCheckInterval":
-			ReadyCheckInterval = parseDuration(v, ReadyCheckInterval, k)
-		case "DeleteRequestTimeout":
-			DeleteRequestTimeout = parseDuration(v, DeleteRequestTimeout, k)
-		case "CaCertificateExpiration":
-			CaCertificateExpiration = parseDuration(v, CaCertificateExpiration, k)
-		case "WebhookCertificateExpiration":
-			WebhookCertificateExpiration = parseDuration(v, WebhookCertificateExpiration, k)
-		case "ExpirationBoundary":
-			ExpirationBoundary = parseDuration(v, ExpirationBoundary, k)
-		case "RsaKeyBits":
-			var bits int
-			bits, err = strconv.Atoi(v)
-			if err == nil {
-				certs.SetRsaKeyBits(bits)
-			}
-		case "EnableLimitedCache":
-			EnableLimitedCache = v
-		case "ProbeInterval":
-			ProbeInterval = parseDuration(v, ProbeInterval, k)
-		case "StatusUpdateTimeout":
-			StatusUpdateTimeout = parseDuration(v, StatusUpdateTimeout, k)
-		case "StatusUpdateCheckInterval":
-			StatusUpdateCheckInterval = parseDuration(v, StatusUpdateCheckInterval, k)
-		case "ManagerResourcesPath":
-			ManagerResourcesPath = v
-		default:
-			logger.Info("unknown configuration update key", k, v)
-		}
-		if err != nil {
-			logger.Info("failed to parse configuration update", k, err)
-		}
+// apply publishes the snapshot with the accepted keys of data, and reports the rejected keys on obj.
+func (r *Handler) apply(ctx context.Context, obj client.Object, data map[string]string, source string) []RejectedKey {
+	previous := Current()
+	next := *previous
+	changes, rejected := parseConfig(data)
+	for _, apply := range changes {
+		apply(&next)
 	}
+	publish(&next)
+	// certs keeps its own key settings, as it does not depend on the configuration package.
+	certs.SetRsaKeyBits(next.RsaKeyBits)
+	_ = certs.SetKeyAlgorithm(next.KeyAlgorithm)
+	r.reportRejectedKeys(ctx, obj, len(changes), rejected)
 
-	afterConfig := configSnapshot()
-	changedFields := changedSnapshotKeys(beforeConfig, afterConfig)
-	logger.Info("configuration snapshot updated", "changedFields", changedFields)
+	changedFields := changedSnapshotKeys(previous.values(), next.values())
+	log.FromContext(ctx).Info("configuration snapshot updated", "changedFields", changedFields)
+	r.recordProvenance(data, source, rejected, changedFields)
+	return rejected
+}
 
-	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: BtpOperatorCrName, Namespace: KymaSystemNamespaceName}}}
+// reportRejectedKeys updates the metrics and, if any key was rejected, emits a Warning Event on obj naming each rejected key.
+// The configuration counts as applied unless every key in it was rejected.
+func (r *Handler) reportRejectedKeys(ctx context.Context, obj client.Object, accepted int, rejected []RejectedKey) {
+	r.recordOutcome(accepted, rejected)
+	if len(rejected) == 0 {
+		return
+	}
+
+	reasons := make([]string, 0, len(rejected))
+	for _, k := range rejected {
+		reasons = append(reasons, k.String())
+	}
+	msg := fmt.Sprintf("Rejected configuration keys, the last accepted values stay in effect: %s", strings.Join(reasons, "; "))
+	log.FromContext(ctx).Info(msg)
+	if r.recorder != nil {
+		r.recorder.Eventf(obj, nil, corev1.EventTypeWarning, configRejectedReason, "Apply", "%s", msg)
+	}
+}
+
+func (r *Handler) recordOutcome(accepted int, rejected []RejectedKey) {
+	if accepted == 0 && len(rejected) > 0 {
+		r.configMetrics.ConfigMapNotApplied()
+	} else {
+		r.configMetrics.ConfigMapApplied()
+	}
+	r.configMetrics.SetRejectedKeys(len(rejected))
 }
@@ -9,54 +9,102 @@ import (
 )
 
 type configState struct {
-	chartNamespace                 string
-	chartPath                      string
-	secretName                     string
-	configName                     string
-	deploymentName                 string
-	processingStateRequeueInterval time.Duration
-	readyStateRequeueInterval      time.Duration
-	readyTimeout                   time.Duration
-	hardDeleteCheckInterval        time.Duration
-	hardDeleteTimeout              time.Duration
-	resourcesPath                  string
-	readyCheckInterval             time.Duration
-	deleteRequestTimeout           time.Duration
-	caCertificateExpiration        time.Duration
-	webhookCertificateExpiration   time.Duration
-	expirationBoundary             time.Duration
-	rsaKeyBits                     int
-	enableLimitedCache             string
-	statusUpdateTimeout            time.Duration
-	statusUpdateCheckInterval      time.Duration
-	managerResourcesPath           string
-	probeInterval                  time.Duration
+	chartNamespace                     string
+	chartPath                          string
+	secretName                         string
+	configName                         string
+	deploymentName                     string
+	processingStateRequeueInterval     time.Duration
+	readyStateRequeueInterval          time.Duration
+	readyTimeout                       time.Duration
+	hardDeleteCheckInterval            time.Duration
+	hardDeleteTimeout                  time.Duration
+	resourcesPath                      string
+	readyCheckInterval                 time.Duration
+	deleteRequestTimeout               time.Duration
+	caCertificateExpiration            time.Duration
+	webhookCertificateExpiration       time.Duration
+	expirationBoundary                 time.Duration
+	webhookCertificateMode             string
+	rsaKeyBits                         int
+	keyAlgorithm                       string
+	enableLimitedCache                 string
+	statusUpdateTimeout                time.Duration
+	statusUpdateCheckInterval          time.Duration
+	managerResourcesPath               string
+	probeInterval                      time.Duration
+	httpProxy                          string
+	httpsProxy                         string
+	noProxy                            string
+	trustBundleConfigMap               string
+	trustBundleSecret                  string
+	trustBundleKey                     string
+	caRotationAdvance                  time.Duration
+	caRotationReloadTimeout            time.Duration
+	certificateRegenerationGracePeriod time.Duration
+	externalCaSecret                   string
+	webhookSelfTestInterval            time.Duration
+	webhookSelfTestTimeout             time.Duration
+	restoreServiceInstancesAndBindings bool
+	forceDeleteConfirmationRequired    bool
+	hardDeleteConcurrency              int
+	hardDeleteQPS                      int
+	probeMode                          string
+	probeHistoryLength                 int
+	probeAlertThreshold                int
+	probeErrorThreshold                int
+	probeRestartMinInterval            time.Duration
+	probeCAExpiryWarning               time.Duration
 }
 
 func captureConfigState() configState {
 	return configState{
-		chartNamespace:                 ChartNamespace,
-		chartPath:                      ChartPath,
-		secretName:                     SecretName,
-		configName:                     ConfigName,
-		deploymentName:                 DeploymentName,
-		processingStateRequeueInterval: ProcessingStateRequeueInterval,
-		readyStateRequeueInterval:      ReadyStateRequeueInterval,
-		readyTimeout:                   ReadyTimeout,
-		hardDeleteCheckInterval:        HardDeleteCheckInterval,
-		hardDeleteTimeout:              HardDeleteTimeout,
-		resourcesPath:                  ResourcesPath,
-		readyCheckInterval:             ReadyCheckInterval,
-		deleteRequestTimeout:           DeleteRequestTimeout,
-		caCertificateExpiration:        CaCertificateExpiration,
-		webhookCertificateExpiration:   WebhookCertificateExpiration,
-		expirationBoundary:             ExpirationBoundary,
-		rsaKeyBits:                     certs.RsaKeyBits(),
-		enableLimitedCache:             EnableLimitedCache,
-		statusUpdateTimeout:            StatusUpdateTimeout,
-		statusUpdateCheckInterval:      StatusUpdateCheckInterval,
-		managerResourcesPath:           ManagerResourcesPath,
-		probeInterval:                  ProbeInterval,
+		chartNamespace:                     ChartNamespace,
+		chartPath:                          ChartPath,
+		secretName:                         SecretName,
+		configName:                         ConfigName,
+		deploymentName:                     DeploymentName,
+		processingStateRequeueInterval:     ProcessingStateRequeueInterval,
+		readyStateRequeueInterval:          ReadyStateRequeueInterval,
+		readyTimeout:                       ReadyTimeout,
+		hardDeleteCheckInterval:            HardDeleteCheckInterval,
+		hardDeleteTimeout:                  HardDeleteTimeout,
+		resourcesPath:                      ResourcesPath,
+		readyCheckInterval:                 ReadyCheckInterval,
+		deleteRequestTimeout:               DeleteRequestTimeout,
+		caCertificateExpiration:            CaCertificateExpiration,
+		webhookCertificateExpiration:       WebhookCertificateExpiration,
+		expirationBoundary:                 ExpirationBoundary,
+		webhookCertificateMode:             WebhookCertificateMode,
+		rsaKeyBits:                         certs.RsaKeyBits(),
+		keyAlgorithm:                       certs.KeyAlgorithm(),
+		enableLimitedCache:                 EnableLimitedCache,
+		statusUpdateTimeout:                StatusUpdateTimeout,
+		statusUpdateCheckInterval:          StatusUpdateCheckInterval,
+		managerResourcesPath:               ManagerResourcesPath,
+		probeInterval:                      ProbeInterval,
+		httpProxy:                          HttpProxy,
+		httpsProxy:                         HttpsProxy,
+		noProxy:                            NoProxy,
+		trustBundleConfigMap:               TrustBundleConfigMap,
+		trustBundleSecret:                  TrustBundleSecret,
+		trustBundleKey:                     TrustBundleKey,
+		caRotationAdvance:                  CaRotationAdvance,
+		caRotationReloadTimeout:            CaRotationReloadTimeout,
+		certificateRegenerationGracePeriod: CertificateRegenerationGracePeriod,
+		externalCaSecret:                   ExternalCaSecret,
+		webhookSelfTestInterval:            WebhookSelfTestInterval,
+		webhookSelfTestTimeout:             WebhookSelfTestTimeout,
+		restoreServiceInstancesAndBindings: RestoreServiceInstancesAndBindings,
+		forceDeleteConfirmationRequired:    ForceDeleteConfirmationRequired,
+		hardDeleteConcurrency:              HardDeleteConcurrency,
+		hardDeleteQPS:                      HardDeleteQPS,
+		probeMode:                          ProbeMode,
+		probeHistoryLength:                 ProbeHistoryLength,
+		probeAlertThreshold:                ProbeAlertThreshold,
+		probeErrorThreshold:                ProbeErrorThreshold,
+		probeRestartMinInterval:            ProbeRestartMinInterval,
+		probeCAExpiryWarning:               ProbeCAExpiryWarning,
 	}
 }
 
@@ -77,12 +125,36 @@ func restoreConfigState(state configState) {
 	CaCertificateExpiration = state.caCertificateExpiration
 	WebhookCertificateExpiration = state.webhookCertificateExpiration
 	ExpirationBoundary = state.expirationBoundary
+	WebhookCertificateMode = state.webhookCertificateMode
 	certs.SetRsaKeyBits(state.rsaKeyBits)
+	_ = certs.SetKeyAlgorithm(state.keyAlgorithm)
 	EnableLimitedCache = state.enableLimitedCache
 	StatusUpdateTimeout = state.statusUpdateTimeout
 	StatusUpdateCheckInterval = state.statusUpdateCheckInterval
 	ManagerResourcesPath = state.managerResourcesPath
 	ProbeInterval = state.probeInterval
+	HttpProxy = state.httpProxy
+	HttpsProxy = state.httpsProxy
+	NoProxy = state.noProxy
+	TrustBundleConfigMap = state.trustBundleConfigMap
+	TrustBundleSecret = state.trustBundleSecret
+	TrustBundleKey = state.trustBundleKey
+	CaRotationAdvance = state.caRotationAdvance
+	CaRotationReloadTimeout = state.caRotationReloadTimeout
+	CertificateRegenerationGracePeriod = state.certificateRegenerationGracePeriod
+	ExternalCaSecret = state.externalCaSecret
+	WebhookSelfTestInterval = state.webhookSelfTestInterval
+	WebhookSelfTestTimeout = state.webhookSelfTestTimeout
+	RestoreServiceInstancesAndBindings = state.restoreServiceInstancesAndBindings
+	ForceDeleteConfirmationRequired = state.forceDeleteConfirmationRequired
+	HardDeleteConcurrency = state.hardDeleteConcurrency
+	HardDeleteQPS = state.hardDeleteQPS
+	ProbeMode = state.probeMode
+	ProbeHistoryLength = state.probeHistoryLength
+	ProbeAlertThreshold = state.probeAlertThreshold
+	ProbeErrorThreshold = state.probeErrorThreshold
+	ProbeRestartMinInterval = state.probeRestartMinInterval
+	ProbeCAExpiryWarning = state.probeCAExpiryWarning
 }
 
 func TestConfigSnapshot(t *testing.T) {
@@ -107,41 +179,91 @@ func TestConfigSnapshot(t *testing.T) {
 	CaCertificateExpiration = 18 * time.Hour
 	WebhookCertificateExpiration = 19 * time.Hour
 	ExpirationBoundary = -20 * time.Hour
+	WebhookCertificateMode = "cert-manager"
 	certs.SetRsaKeyBits(3072)
+	if err := certs.SetKeyAlgorithm(certs.EcdsaP256KeyAlgorithm); err != nil {
+		t.Fatal(err)
+	}
 	EnableLimitedCache = "false"
 	StatusUpdateTimeout = 21 * time.Second
 	StatusUpdateCheckInterval = 22 * time.Millisecond
 	ManagerResourcesPath = "./custom-manager-resources"
 	ProbeInterval = 23 * time.Minute
+	HttpProxy = "http://proxy.local:3128"
+	HttpsProxy = "http://proxy.local:3129"
+	NoProxy = "10.0.0.0/8,.svc"
+	TrustBundleConfigMap = "corporate-ca"
+	TrustBundleSecret = "corporate-ca-secret"
+	TrustBundleKey = "ca.pem"
+	CaRotationAdvance = 25 * time.Hour
+	CaRotationReloadTimeout = 26 * time.Minute
+	CertificateRegenerationGracePeriod = 27 * time.Minute
+	ExternalCaSecret = "org-intermediate-ca"
+	WebhookSelfTestInterval = 17 * time.Minute
+	WebhookSelfTestTimeout = 3 * time.Minute
+	RestoreServiceInstancesAndBindings = true
+	ForceDeleteConfirmationRequired = false
+	HardDeleteConcurrency = 24
+	HardDeleteQPS = 25
+	ProbeMode = "InProcess"
+	ProbeHistoryLength = 5
+	ProbeAlertThreshold = 3
+	ProbeErrorThreshold = 4
+	ProbeRestartMinInterval = time.Hour * 12
+	ProbeCAExpiryWarning = time.Hour * 24 * 7
 
-	got := configSnapshot()
+	got := defaults().values()
 	want := map[string]any{
-		"ChartNamespace":                 "custom-ns",
-		"ChartPath":                      "./custom-chart",
-		"SecretName":                     "custom-secret",
-		"ConfigName":                     "custom-config",
-		"DeploymentName":                 "custom-deployment",
-		"ProcessingStateRequeueInterval": 11 * time.Minute,
-		"ReadyStateRequeueInterval":      12 * time.Minute,
-		"ReadyTimeout":                   13 * time.Minute,
-		"HardDeleteCheckInterval":        14 * time.Second,
-		"HardDeleteTimeout":              15 * time.Minute,
-		"ResourcesPath":                  "./custom-resources",
-		"ReadyCheckInterval":             16 * time.Second,
-		"DeleteRequestTimeout":           17 * time.Minute,
-		"CaCertificateExpiration":        18 * time.Hour,
-		"WebhookCertificateExpiration":   19 * time.Hour,
-		"ExpirationBoundary":             -20 * time.Hour,
-		"RsaKeyBits":                     3072,
-		"EnableLimitedCache":             "false",
-		"StatusUpdateTimeout":            21 * time.Second,
-		"StatusUpdateCheckInterval":      22 * time.Millisecond,
-		"ManagerResourcesPath":           "./custom-manager-resources",
-		"ProbeInterval":                  23 * time.Minute,
+		"ChartNamespace":                     "custom-ns",
+		"ChartPath":                          "./custom-chart",
+		"SecretName":                         "custom-secret",
+		"ConfigName":                         "custom-config",
+		"DeploymentName":                     "custom-deployment",
+		"ProcessingStateRequeueInterval":     11 * time.Minute,
+		"ReadyStateRequeueInterval":          12 * time.Minute,
+		"ReadyTimeout":                       13 * time.Minute,
+		"HardDeleteCheckInterval":            14 * time.Second,
+		"HardDeleteTimeout":                  15 * time.Minute,
+		"ResourcesPath":                      "./custom-resources",
+		"ReadyCheckInterval":                 16 * time.Second,
+		"DeleteRequestTimeout":               17 * time.Minute,
+		"CaCertificateExpiration":            18 * time.Hour,
+		"WebhookCertificateExpiration":       19 * time.Hour,
+		"ExpirationBoundary":                 -20 * time.Hour,
+		"WebhookCertificateMode":             "cert-manager",
+		"RsaKeyBits":                         3072,
+		"KeyAlgorithm":                       "ecdsa-p256",
+		"EnableLimitedCache":                 "false",
+		"StatusUpdateTimeout":                21 * time.Second,
+		"StatusUpdateCheckInterval":          22 * time.Millisecond,
+		"ManagerResourcesPath":               "./custom-manager-resources",
+		"ProbeInterval":                      23 * time.Minute,
+		"HttpProxy":                          "http://proxy.local:3128",
+		"HttpsProxy":                         "http://proxy.local:3129",
+		"NoProxy":                            "10.0.0.0/8,.svc",
+		"TrustBundleConfigMap":               "corporate-ca",
+		"TrustBundleSecret":                  "corporate-ca-secret",
+		"TrustBundleKey":                     "ca.pem",
+		"CaRotationAdvance":                  25 * time.Hour,
+		"CaRotationReloadTimeout":            26 * time.Minute,
+		"CertificateRegenerationGracePeriod": 27 * time.Minute,
+		"ExternalCaSecret":                   "org-intermediate-ca",
+		"WebhookSelfTestInterval":            17 * time.Minute,
+		"WebhookSelfTestTimeout":             3 * time.Minute,
+		"RestoreServiceInstancesAndBindings": true,
+		"ForceDeleteConfirmationRequired":    false,
+		"HardDeleteConcurrency":              24,
+		"HardDeleteQPS":                      25,
+		"ProbeMode":                          "InProcess",
+		"ProbeHistoryLength":                 5,
+		"ProbeAlertThreshold":                3,
+		"ProbeErrorThreshold":                4,
+		"ProbeRestartMinInterval":            time.Hour * 12,
+		"ProbeCAExpiryWarning":               time.Hour * 24 * 7,
 	}
 
 	if !reflect.DeepEqual(want, got) {
-		t.Fatalf("configSnapshot mismatch\nwant: %#v\ngot:  %#v", want, got)
+		t.Fatalf("snapshot values mismatch\nwant: %#v\ngot:  %#v", want, got)
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
 	"github.com/kyma-project/btp-manager/controllers/config"
 	"github.com/kyma-project/btp-manager/internal/metrics"
 	. "github.com/onsi/ginkgo/v2"
@@ -16,11 +17,16 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
-const configAppliedMetricName = "btpmanager_custom_config_applied"
+const (
+	configAppliedMetricName      = "btpmanager_custom_config_applied"
+	configRejectedKeysMetricName = "btpmanager_custom_config_rejected_keys"
+)
 
 func TestHandler(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -41,9 +47,11 @@ var _ = Describe("ConfigMetrics", func() {
 
 		scheme = runtime.NewScheme()
 		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
 
 		fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
 		handler = config.NewHandler(fakeClient, scheme, configMetrics)
+		DeferCleanup(config.Reset)
 	})
 
 	Context("gauge metric initialization", func() {
@@ -56,7 +64,7 @@ var _ = Describe("ConfigMetrics", func() {
 	})
 
 	Context("ConfigMap lifecycle tracking", func() {
-		const chartNamespaceKey = "ChartNamespace"
+		const requeueIntervalKey = "ProcessingStateRequeueInterval"
 		const kymaNamespace = "kyma-system"
 		var configMap *corev1.ConfigMap
 
@@ -67,18 +75,19 @@ var _ = Describe("ConfigMetrics", func() {
 					Namespace: kymaNamespace,
 				},
 				Data: map[string]string{
-					chartNamespaceKey: "test-namespace",
+					requeueIntervalKey: "10m",
 				},
 			}
 		})
 
-		It("should set gauge to 1 when ConfigMap is created", func() {
+		It("should set gauge to 1 when ConfigMap is applied", func() {
 			predicates := handler.Predicates()
 
 			createEvent := event.CreateEvent{
 				Object: configMap,
 			}
 			result := predicates.CreateFunc(createEvent)
+			handler.Reconcile(context.Background(), configMap)
 
 			Expect(result).To(BeTrue(), "predicate should match the ConfigMap")
 
@@ -91,10 +100,7 @@ var _ = Describe("ConfigMetrics", func() {
 		It("should set gauge to 0 when ConfigMap is deleted", func() {
 			predicates := handler.Predicates()
 
-			createEvent := event.CreateEvent{
-				Object: configMap,
-			}
-			predicates.CreateFunc(createEvent)
+			handler.Reconcile(context.Background(), configMap)
 
 			deleteEvent := event.DeleteEvent{
 				Object: configMap,
@@ -112,21 +118,19 @@ var _ = Describe("ConfigMetrics", func() {
 		It("should keep gauge at 1 when ConfigMap is updated", func() {
 			predicates := handler.Predicates()
 
-			// First create the ConfigMap
-			createEvent := event.CreateEvent{
-				Object: configMap,
-			}
-			predicates.CreateFunc(createEvent)
+			// First apply the ConfigMap
+			handler.Reconcile(context.Background(), configMap)
 
 			// Simulate ConfigMap update
 			updatedConfigMap := configMap.DeepCopy()
-			updatedConfigMap.Data[chartNamespaceKey] = "updated-namespace"
+			updatedConfigMap.Data[requeueIntervalKey] = "20m"
 
 			updateEvent := event.UpdateEvent{
 				ObjectOld: configMap,
 				ObjectNew: updatedConfigMap,
 			}
 			result := predicates.UpdateFunc(updateEvent)
+			handler.Reconcile(context.Background(), updatedConfigMap)
 
 			Expect(result).To(BeTrue(), "predicate should match the ConfigMap")
 
@@ -136,6 +140,37 @@ var _ = Describe("ConfigMetrics", func() {
 			Expect(gauge.GetValue()).To(Equal(1.0))
 		})
 
+		It("should set gauge to 0 when every key is rejected", func() {
+			configMap.Data = map[string]string{
+				"ProbeInterval": "-1h",
+				"UnknownKey":    "value",
+			}
+
+			handler.Reconcile(context.Background(), configMap)
+
+			gauge, err := getGaugeMetricFromRegistryByName(testRegistry, configAppliedMetricName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gauge.GetValue()).To(Equal(0.0))
+
+			rejected, err := getGaugeMetricFromRegistryByName(testRegistry, configRejectedKeysMetricName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rejected.GetValue()).To(Equal(2.0))
+		})
+
+		It("should set gauge to 1 when some keys are accepted", func() {
+			configMap.Data["ProbeInterval"] = "not-a-duration"
+
+			handler.Reconcile(context.Background(), configMap)
+
+			gauge, err := getGaugeMetricFromRegistryByName(testRegistry, configAppliedMetricName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gauge.GetValue()).To(Equal(1.0))
+
+			rejected, err := getGaugeMetricFromRegistryByName(testRegistry, configRejectedKeysMetricName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rejected.GetValue()).To(Equal(1.0))
+		})
+
 		It("should not change gauge when non-matching ConfigMap is created", func() {
 			predicates := handler.Predicates()
 
@@ -174,6 +209,7 @@ var _ = Describe("Handler startup sync", func() {
 
 		scheme = runtime.NewScheme()
 		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
 	})
 
 	Context("Start method", func() {
@@ -195,6 +231,26 @@ var _ = Describe("Handler startup sync", func() {
 			Expect(gauge.GetValue()).To(Equal(1.0))
 		})
 
+		It("should set gauge to 0 when every key of the ConfigMap is rejected at startup", func() {
+			existingCM := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      config.ConfigName,
+					Namespace: config.ChartNamespace,
+				},
+				Data: map[string]string{"HardDeleteConcurrency": "0"},
+			}
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existingCM).Build()
+			handler := config.NewHandler(fakeClient, scheme, configMetrics)
+
+			Err := handler.Start(context.Background())
+			Expect(Err).NotTo(HaveOccurred())
+
+			gauge, err := getGaugeMetricFromRegistryByName(testRegistry, configAppliedMetricName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gauge.GetValue()).To(Equal(0.0))
+			Expect(config.Current().HardDeleteConcurrency).To(Equal(10), "Start must not apply the configuration")
+		})
+
 		It("should keep gauge at 0 when ConfigMap does not exist at startup", func() {
 			fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
 			handler := config.NewHandler(fakeClient, scheme, configMetrics)
@@ -236,19 +292,21 @@ var _ = Describe("ProbeInterval configuration", func() {
 
 	BeforeEach(func() {
 		config.ProbeInterval = time.Hour // reset to default before each test
+		DeferCleanup(config.Reset)
 
 		testRegistry := prometheus.NewRegistry()
 		configMetrics = metrics.NewConfigMetrics(testRegistry)
 
 		scheme = runtime.NewScheme()
 		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
 
 		fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
 		handler = config.NewHandler(fakeClient, scheme, configMetrics)
 	})
 
 	It("should default to 60 minutes", func() {
-		Expect(config.ProbeInterval).To(Equal(time.Hour))
+		Expect(config.Current().ProbeInterval).To(Equal(time.Hour))
 	})
 
 	It("should be overridable via ConfigMap", func() {
@@ -262,7 +320,8 @@ var _ = Describe("ProbeInterval configuration", func() {
 			},
 		}
 		handler.Reconcile(context.Background(), cm)
-		Expect(config.ProbeInterval).To(Equal(30 * time.Minute))
+		Expect(config.Current().ProbeInterval).To(Equal(30 * time.Minute))
+		Expect(config.ProbeInterval).To(Equal(time.Hour), "the ConfigMap must not overwrite the defaults")
 	})
 
 	It("should keep previous value when ConfigMap contains invalid ProbeInterval", func() {
@@ -276,7 +335,155 @@ var _ = Describe("ProbeInterval configuration", func() {
 			},
 		}
 		handler.Reconcile(context.Background(), cm)
-		Expect(config.ProbeInterval).To(Equal(time.Hour))
+		Expect(config.Current().ProbeInterval).To(Equal(time.Hour))
+	})
+
+	It("should keep previous value and report the key when ProbeInterval is negative", func() {
+		recorder := events.NewFakeRecorder(2)
+		handler.WithEventRecorder(recorder)
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      config.ConfigName,
+				Namespace: config.ChartNamespace,
+			},
+			Data: map[string]string{
+				"ProbeInterval": "-30m",
+			},
+		}
+		handler.Reconcile(context.Background(), cm)
+		Expect(config.Current().ProbeInterval).To(Equal(time.Hour))
+
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring("Warning ConfigurationRejected"),
+			ContainSubstring("ProbeInterval: must not be negative, got -30m0s"),
+		)))
+	})
+})
+
+var _ = Describe("BtpManagerConfiguration", func() {
+	var (
+		testRegistry *prometheus.Registry
+		fakeClient   client.Client
+		handler      *config.Handler
+		recorder     *events.FakeRecorder
+		configMap    *corev1.ConfigMap
+		ctx          = context.Background()
+	)
+
+	getConfiguration := func() *v1alpha1.BtpManagerConfiguration {
+		configuration := &v1alpha1.BtpManagerConfiguration{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(configMap), configuration)).To(Succeed())
+		return configuration
+	}
+
+	BeforeEach(func() {
+		DeferCleanup(config.Reset)
+		testRegistry = prometheus.NewRegistry()
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&v1alpha1.BtpManagerConfiguration{}).Build()
+
+		recorder = events.NewFakeRecorder(10)
+		handler = config.NewHandler(fakeClient, scheme, metrics.NewConfigMetrics(testRegistry)).WithEventRecorder(recorder)
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: config.ConfigName, Namespace: config.ChartNamespace},
+			Data:       map[string]string{"ProbeInterval": "30m", "HardDeleteQPS": "-1"},
+		}
+	})
+
+	It("should migrate only the accepted keys of the ConfigMap", func() {
+		requests := handler.Reconcile(ctx, configMap)
+		Expect(requests).To(HaveLen(1))
+
+		configuration := getConfiguration()
+		Expect(configuration.Spec.ProbeInterval.Duration).To(Equal(30 * time.Minute))
+		Expect(configuration.Spec.HardDeleteQPS).To(BeNil(), "a rejected key must not be migrated")
+		Expect(configuration.Spec.EnableLimitedCache).To(BeNil())
+		Expect(configuration.Spec.TrustBundleKey).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning ConfigurationRejected")))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Normal ConfigMapMigrated")))
+	})
+
+	It("should ignore the ConfigMap when the BtpManagerConfiguration exists", func() {
+		handler.Reconcile(ctx, configMap)
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("ConfigMapMigrated")))
+
+		configMap.Data = map[string]string{"ProbeInterval": "2h"}
+		requests := handler.Reconcile(ctx, configMap)
+
+		Expect(requests).To(BeEmpty())
+		Expect(config.Current().ProbeInterval).To(Equal(30 * time.Minute))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning ConfigMapIgnored")))
+	})
+
+	It("should apply the spec and report each field in the status", func() {
+		handler.Reconcile(ctx, configMap)
+		configuration := getConfiguration()
+		hardDeleteConcurrency := int32(0)
+		configuration.Spec.ReadyTimeout = &metav1.Duration{Duration: 7 * time.Minute}
+		configuration.Spec.HardDeleteConcurrency = &hardDeleteConcurrency
+		Expect(fakeClient.Update(ctx, configuration)).To(Succeed())
+
+		watchHandler := handler.ConfigurationWatchHandler()
+		requests := watchHandler.Reconcile(ctx, getConfiguration())
+
+		Expect(requests).To(HaveLen(1))
+		Expect(config.Current().ReadyTimeout).To(Equal(7 * time.Minute))
+		Expect(config.Current().HardDeleteConcurrency).To(Equal(10))
+		Expect(handler.Effective().Keys["ReadyTimeout"].Source).To(Equal(config.SourceConfiguration))
+
+		status := getConfiguration().Status
+		Expect(status.Fields).To(ConsistOf(
+			v1alpha1.ConfigurationFieldStatus{Name: "probeInterval", Applied: true},
+			v1alpha1.ConfigurationFieldStatus{Name: "readyTimeout", Applied: true},
+			v1alpha1.ConfigurationFieldStatus{Name: "hardDeleteConcurrency", Applied: false, Reason: "must be at least 1, got 0"},
+		))
+		Expect(status.Conditions).To(ContainElement(And(
+			HaveField("Type", v1alpha1.ConfigurationAppliedCondition),
+			HaveField("Status", metav1.ConditionFalse),
+			HaveField("Reason", v1alpha1.ConfigurationFieldsRejectedReason),
+		)))
+	})
+
+	It("should keep the values of the fields that aren't set", func() {
+		configuration := &v1alpha1.BtpManagerConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: config.ConfigName, Namespace: config.ChartNamespace},
+			Spec:       v1alpha1.BtpManagerConfigurationSpec{ProbeInterval: &metav1.Duration{Duration: 2 * time.Hour}},
+		}
+		Expect(fakeClient.Create(ctx, configuration)).To(Succeed())
+
+		handler.ConfigurationWatchHandler().Reconcile(ctx, getConfiguration())
+
+		Expect(config.Current().ProbeInterval).To(Equal(2 * time.Hour))
+		Expect(config.Current().ReadyTimeout).To(Equal(5 * time.Minute))
+		Expect(handler.Effective().Keys["ReadyTimeout"].Source).To(Equal(config.SourceDefault))
+		Expect(getConfiguration().Status.Fields).To(ConsistOf(v1alpha1.ConfigurationFieldStatus{Name: "probeInterval", Applied: true}))
+	})
+
+	It("should keep the metrics of the BtpManagerConfiguration when the ConfigMap is deleted", func() {
+		handler.Reconcile(ctx, configMap)
+		handler.ConfigurationWatchHandler().Reconcile(ctx, getConfiguration())
+
+		handler.Predicates().DeleteFunc(event.DeleteEvent{Object: configMap})
+
+		gauge, err := getGaugeMetricFromRegistryByName(testRegistry, configAppliedMetricName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gauge.GetValue()).To(Equal(1.0))
+	})
+
+	It("should react only to spec changes of the BtpManagerConfiguration", func() {
+		handler.Reconcile(ctx, configMap)
+		configuration := getConfiguration()
+		updated := configuration.DeepCopy()
+		updated.Status.ObservedGeneration = 1
+
+		predicates := handler.ConfigurationWatchHandler().Predicates()
+		Expect(predicates.UpdateFunc(event.UpdateEvent{ObjectOld: configuration, ObjectNew: updated})).To(BeFalse())
+
+		updated.Generation++
+		Expect(predicates.UpdateFunc(event.UpdateEvent{ObjectOld: configuration, ObjectNew: updated})).To(BeTrue())
 	})
 })
 
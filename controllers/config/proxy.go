@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	HttpProxyEnv  = "HTTP_PROXY"
+	HttpsProxyEnv = "HTTPS_PROXY"
+	NoProxyEnv    = "NO_PROXY"
+)
+
+// ParseProxyURL parses an HttpProxy or HttpsProxy value. Only http and https URLs with a host are accepted,
+// so the ConfigMap, the BtpManagerConfiguration and the CLI flags accept the same values.
+func ParseProxyURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("must be an http or https URL with a host")
+	}
+	return u, nil
+}
+
+// ValidateProxyFlags checks the HttpProxy and HttpsProxy values set by the CLI flags with ParseProxyURL.
+func ValidateProxyFlags() error {
+	for _, flag := range []struct{ name, value string }{{"http-proxy", HttpProxy}, {"https-proxy", HttpsProxy}} {
+		if flag.value == "" {
+			continue
+		}
+		if _, err := ParseProxyURL(flag.value); err != nil {
+			return fmt.Errorf("invalid -%s value: %w", flag.name, err)
+		}
+	}
+	return nil
+}
+
+// ProxyConfigured reports whether any egress proxy is set.
+// NoProxy alone does not count, as it only narrows down proxied traffic.
+func ProxyConfigured() bool {
+	cfg := Current()
+	return cfg.HttpProxy != "" || cfg.HttpsProxy != ""
+}
+
+// ProxyEnvVars returns the egress proxy settings as container environment variables.
+// Unset values are skipped and the order is fixed, so the result can be compared across reconciliations.
+func ProxyEnvVars() []corev1.EnvVar {
+	cfg := Current()
+	envs := make([]corev1.EnvVar, 0, 3)
+	for _, e := range []corev1.EnvVar{
+		{Name: HttpProxyEnv, Value: cfg.HttpProxy},
+		{Name: HttpsProxyEnv, Value: cfg.HttpsProxy},
+		{Name: NoProxyEnv, Value: cfg.NoProxy},
+	} {
+		if e.Value != "" {
+			envs = append(envs, e)
+		}
+	}
+	return envs
+}
+
+// ProxyForURL resolves the egress proxy for a target URL from the configured proxy settings, for connections made by btp-manager itself.
+func ProxyForURL(target *url.URL) (*url.URL, error) {
+	cfg := Current()
+	proxyConfig := &httpproxy.Config{HTTPProxy: cfg.HttpProxy, HTTPSProxy: cfg.HttpsProxy, NoProxy: cfg.NoProxy}
+	return proxyConfig.ProxyFunc()(target)
+}
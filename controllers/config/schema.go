@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kyma-project/btp-manager/internal/certs"
+)
+
+// configKey is a key of the configuration ConfigMap. parse validates the raw value and returns the parsed value,
+// which is set to the Config field named after the key.
+type configKey struct {
+	parse func(raw string) (any, error)
+}
+
+// RejectedKey is a configuration key whose value was rejected. The last accepted value of the key stays in effect.
+type RejectedKey struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+func (k RejectedKey) String() string {
+	return fmt.Sprintf("%s: %s", k.Key, k.Reason)
+}
+
+// configKeys is the schema of the configuration ConfigMap.
+var configKeys = map[string]configKey{
+	"ChartNamespace":                     stringKey(notEmpty),
+	"ChartPath":                          stringKey(notEmpty),
+	"SecretName":                         stringKey(notEmpty),
+	"ConfigName":                         stringKey(notEmpty),
+	"DeploymentName":                     stringKey(notEmpty),
+	"ProcessingStateRequeueInterval":     durationKey(positive),
+	"ReadyStateRequeueInterval":          durationKey(positive),
+	"ReadyTimeout":                       durationKey(positive),
+	"HardDeleteCheckInterval":            durationKey(positive),
+	"HardDeleteTimeout":                  durationKey(positive),
+	"ResourcesPath":                      stringKey(notEmpty),
+	"ReadyCheckInterval":                 durationKey(positive),
+	"DeleteRequestTimeout":               durationKey(positive),
+	"CaCertificateExpiration":            durationKey(positive),
+	"WebhookCertificateExpiration":       durationKey(positive),
+	"ExpirationBoundary":                 durationKey(notPositive),
+	"WebhookCertificateMode":             stringKey(oneOf("self-signed", "cert-manager")),
+	"RsaKeyBits":                         {parse: parseRsaKeyBits},
+	"KeyAlgorithm":                       {parse: parseKeyAlgorithm},
+	"EnableLimitedCache":                 stringKey(oneOf("true", "false")),
+	"ProbeInterval":                      durationKey(notNegative),
+	"StatusUpdateTimeout":                durationKey(positive),
+	"StatusUpdateCheckInterval":          durationKey(positive),
+	"ManagerResourcesPath":               stringKey(notEmpty),
+	"HttpProxy":                          stringKey(proxyURL),
+	"HttpsProxy":                         stringKey(proxyURL),
+	"NoProxy":                            stringKey(nil),
+	"TrustBundleConfigMap":               stringKey(nil),
+	"TrustBundleSecret":                  stringKey(nil),
+	"TrustBundleKey":                     stringKey(notEmpty),
+	"CaRotationAdvance":                  durationKey(notNegative),
+	"CaRotationReloadTimeout":            durationKey(positive),
+	"CertificateRegenerationGracePeriod": durationKey(notNegative),
+	"ExternalCaSecret":                   stringKey(nil),
+	"WebhookSelfTestInterval":            durationKey(notNegative),
+	"WebhookSelfTestTimeout":             durationKey(positive),
+	"RestoreServiceInstancesAndBindings": boolKey(),
+	"ForceDeleteConfirmationRequired":    boolKey(),
+	"HardDeleteConcurrency":              intKey(1),
+	"HardDeleteQPS":                      intKey(0),
+	"ProbeMode":                          stringKey(oneOf(ProbeModeJob, ProbeModeInProcess)),
+	"ProbeHistoryLength":                 intKey(1),
+	"ProbeAlertThreshold":                intKey(1),
+	"ProbeErrorThreshold":                intKey(1),
+	"ProbeRestartMinInterval":            durationKey(notNegative),
+	"ProbeCAExpiryWarning":               durationKey(notNegative),
+}
+
+// parseConfig validates the ConfigMap data against the schema. It returns the changes of the accepted keys
+// and the rejected keys, sorted by key. Unknown keys are rejected.
+func parseConfig(data map[string]string) ([]func(*Config), []RejectedKey) {
+	changes := make([]func(*Config), 0, len(data))
+	rejected := make([]RejectedKey, 0)
+	for k, v := range data {
+		key, known := configKeys[k]
+		if !known {
+			rejected = append(rejected, RejectedKey{Key: k, Value: v, Reason: "unknown key"})
+			continue
+		}
+		parsed, err := key.parse(v)
+		if err != nil {
+			rejected = append(rejected, RejectedKey{Key: k, Value: v, Reason: err.Error()})
+			continue
+		}
+		changes = append(changes, setField(k, parsed))
+	}
+	sort.Slice(rejected, func(i, j int) bool { return rejected[i].Key < rejected[j].Key })
+	return changes, rejected
+}
+
+func setField(key string, value any) func(*Config) {
+	return func(c *Config) {
+		reflect.ValueOf(c).Elem().FieldByName(key).Set(reflect.ValueOf(value))
+	}
+}
+
+func durationKey(validate func(time.Duration) error) configKey {
+	return configKey{parse: func(raw string) (any, error) {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q", raw)
+		}
+		if err := validate(parsed); err != nil {
+			return nil, err
+		}
+		return parsed, nil
+	}}
+}
+
+func intKey(minValue int) configKey {
+	return configKey{parse: func(raw string) (any, error) {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", raw)
+		}
+		if parsed < minValue {
+			return nil, fmt.Errorf("must be at least %d, got %d", minValue, parsed)
+		}
+		return parsed, nil
+	}}
+}
+
+func boolKey() configKey {
+	return configKey{parse: func(raw string) (any, error) {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", raw)
+		}
+		return parsed, nil
+	}}
+}
+
+// stringKey accepts any value if validate is nil.
+func stringKey(validate func(string) error) configKey {
+	return configKey{parse: func(raw string) (any, error) {
+		if validate != nil {
+			if err := validate(raw); err != nil {
+				return nil, err
+			}
+		}
+		return raw, nil
+	}}
+}
+
+func parseRsaKeyBits(raw string) (any, error) {
+	const minRsaKeyBits = 2048
+	bits, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integer %q", raw)
+	}
+	if bits < minRsaKeyBits {
+		return nil, fmt.Errorf("must be at least %d, got %d", minRsaKeyBits, bits)
+	}
+	return bits, nil
+}
+
+func parseKeyAlgorithm(raw string) (any, error) {
+	if err := oneOf(certs.RsaKeyAlgorithm, certs.EcdsaP256KeyAlgorithm, certs.EcdsaP384KeyAlgorithm, certs.Ed25519KeyAlgorithm)(raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+func positive(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("must be positive, got %s", d)
+	}
+	return nil
+}
+
+func notNegative(d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("must not be negative, got %s", d)
+	}
+	return nil
+}
+
+func notPositive(d time.Duration) error {
+	if d > 0 {
+		return fmt.Errorf("must not be positive, got %s", d)
+	}
+	return nil
+}
+
+func notEmpty(s string) error {
+	if s == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	return nil
+}
+
+// proxyURL accepts an empty value, which disables the proxy, or a value accepted by ParseProxyURL.
+func proxyURL(s string) error {
+	if s == "" {
+		return nil
+	}
+	_, err := ParseProxyURL(s)
+	return err
+}
+
+func oneOf(allowed ...string) func(string) error {
+	return func(s string) error {
+		if !slices.Contains(allowed, s) {
+			return fmt.Errorf("must be one of %s, got %q", strings.Join(allowed, ", "), s)
+		}
+		return nil
+	}
+}
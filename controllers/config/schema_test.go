@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfigKeysCoverSnapshot(t *testing.T) {
+	values := defaults().values()
+	for key := range values {
+		if _, exists := configKeys[key]; !exists {
+			t.Errorf("configuration key %s has no schema entry", key)
+		}
+	}
+	for key, schema := range configKeys {
+		value, exists := values[key]
+		if !exists {
+			t.Errorf("schema entry %s has no Config field", key)
+			continue
+		}
+		raw := fmt.Sprint(value)
+		parsed, err := schema.parse(raw)
+		if err != nil {
+			t.Errorf("configuration key %s rejects its default %q: %s", key, raw, err)
+			continue
+		}
+		if reflect.TypeOf(parsed) != reflect.TypeOf(value) {
+			t.Errorf("configuration key %s parses to %T, the Config field is %T", key, parsed, value)
+		}
+	}
+}
+
+func TestParseConfigRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		key, value, reason string
+	}{
+		{"ReadyTimeout", "soon", `invalid duration "soon"`},
+		{"ReadyTimeout", "0s", "must be positive, got 0s"},
+		{"ProbeInterval", "-1m", "must not be negative, got -1m0s"},
+		{"ExpirationBoundary", "168h", "must not be positive, got 168h0m0s"},
+		{"HardDeleteConcurrency", "0", "must be at least 1, got 0"},
+		{"HardDeleteQPS", "many", `invalid integer "many"`},
+		{"RsaKeyBits", "1024", "must be at least 2048, got 1024"},
+		{"KeyAlgorithm", "dsa", `must be one of rsa, ecdsa-p256, ecdsa-p384, ed25519, got "dsa"`},
+		{"ProbeMode", "Cron", `must be one of Job, InProcess, got "Cron"`},
+		{"EnableLimitedCache", "yes", `must be one of true, false, got "yes"`},
+		{"RestoreServiceInstancesAndBindings", "maybe", `invalid boolean "maybe"`},
+		{"SecretName", "", "must not be empty"},
+		{"HttpProxy", "proxy:3128", "must be an http or https URL with a host"},
+		{"HttpsProxy", "socks5://proxy:1080", "must be an http or https URL with a host"},
+		{"HttpsProxy", "http://", "must be an http or https URL with a host"},
+		{"FeatureX", "on", "unknown key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			changes, rejected := parseConfig(map[string]string{tt.key: tt.value})
+
+			want := []RejectedKey{{Key: tt.key, Value: tt.value, Reason: tt.reason}}
+			if len(changes) != 0 || !reflect.DeepEqual(want, rejected) {
+				t.Fatalf("parseConfig mismatch\nwant: %#v\ngot:  %d changes, %#v", want, len(changes), rejected)
+			}
+		})
+	}
+}
+
+func TestParseConfigAppliesOnlyAcceptedKeys(t *testing.T) {
+	cfg := Config{ReadyTimeout: time.Minute, HardDeleteConcurrency: 10}
+
+	changes, rejected := parseConfig(map[string]string{
+		"ReadyTimeout":          "2m",
+		"HardDeleteConcurrency": "-5",
+		"ProbeMode":             "Cron",
+	})
+	for _, apply := range changes {
+		apply(&cfg)
+	}
+
+	if cfg.ReadyTimeout != 2*time.Minute {
+		t.Errorf("expected the accepted ReadyTimeout 2m, got %s", cfg.ReadyTimeout)
+	}
+	if cfg.HardDeleteConcurrency != 10 {
+		t.Errorf("expected the last accepted HardDeleteConcurrency 10, got %d", cfg.HardDeleteConcurrency)
+	}
+	wantKeys := []string{"HardDeleteConcurrency", "ProbeMode"}
+	gotKeys := make([]string, 0, len(rejected))
+	for _, k := range rejected {
+		gotKeys = append(gotKeys, k.Key)
+	}
+	if !reflect.DeepEqual(wantKeys, gotKeys) {
+		t.Fatalf("rejected keys mismatch\nwant: %#v\ngot:  %#v", wantKeys, gotKeys)
+	}
+}
+
+func TestValidateProxyFlags(t *testing.T) {
+	originalHttpProxy, originalHttpsProxy := HttpProxy, HttpsProxy
+	t.Cleanup(func() { HttpProxy, HttpsProxy = originalHttpProxy, originalHttpsProxy })
+
+	HttpProxy, HttpsProxy = "http://proxy:3128", ""
+	if err := ValidateProxyFlags(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	HttpsProxy = "proxy:3128"
+	err := ValidateProxyFlags()
+	if want := "invalid -https-proxy value: must be an http or https URL with a host"; err == nil || err.Error() != want {
+		t.Fatalf("want %q, got %v", want, err)
+	}
+}
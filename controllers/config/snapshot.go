@@ -0,0 +1,183 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kyma-project/btp-manager/internal/certs"
+)
+
+// Config is an immutable snapshot of the configuration. Field names match the ConfigMap keys.
+// A snapshot must not be modified after it is published, a configuration change publishes a new snapshot instead.
+type Config struct {
+	ChartNamespace string
+	SecretName     string
+	ConfigName     string
+	DeploymentName string
+
+	ProcessingStateRequeueInterval time.Duration
+	ReadyStateRequeueInterval      time.Duration
+	ReadyTimeout                   time.Duration
+	ReadyCheckInterval             time.Duration
+	HardDeleteTimeout              time.Duration
+	HardDeleteCheckInterval        time.Duration
+	DeleteRequestTimeout           time.Duration
+	HardDeleteConcurrency          int
+	HardDeleteQPS                  int
+	StatusUpdateTimeout            time.Duration
+	StatusUpdateCheckInterval      time.Duration
+
+	CaCertificateExpiration            time.Duration
+	WebhookCertificateExpiration       time.Duration
+	ExpirationBoundary                 time.Duration
+	WebhookCertificateMode             string
+	RsaKeyBits                         int
+	KeyAlgorithm                       string
+	CaRotationAdvance                  time.Duration
+	CaRotationReloadTimeout            time.Duration
+	CertificateRegenerationGracePeriod time.Duration
+	ExternalCaSecret                   string
+	WebhookSelfTestInterval            time.Duration
+	WebhookSelfTestTimeout             time.Duration
+
+	RestoreServiceInstancesAndBindings bool
+	ForceDeleteConfirmationRequired    bool
+
+	ChartPath            string
+	ResourcesPath        string
+	ManagerResourcesPath string
+
+	EnableLimitedCache string
+
+	ProbeInterval           time.Duration
+	ProbeMode               string
+	ProbeHistoryLength      int
+	ProbeAlertThreshold     int
+	ProbeErrorThreshold     int
+	ProbeRestartMinInterval time.Duration
+	ProbeCAExpiryWarning    time.Duration
+
+	HttpProxy  string
+	HttpsProxy string
+	NoProxy    string
+
+	TrustBundleConfigMap string
+	TrustBundleSecret    string
+	TrustBundleKey       string
+}
+
+var (
+	current atomic.Pointer[Config]
+
+	subscribersMu    sync.Mutex
+	subscribers      = map[int]func(previous, current *Config){}
+	nextSubscriberID int
+)
+
+// Current returns the configuration snapshot in effect. Until a ConfigMap is applied, it is built from the package-level
+// variables, which hold the defaults overwritten by CLI parameters. The package-level variables must not be changed
+// after the manager starts.
+func Current() *Config {
+	if c := current.Load(); c != nil {
+		return c
+	}
+	return defaults()
+}
+
+// Subscribe registers fn to be called with the previous and the new snapshot whenever a changed configuration is published.
+// fn is called synchronously by the publisher, so it must neither block nor subscribe. The returned function cancels the subscription.
+func Subscribe(fn func(previous, current *Config)) (unsubscribe func()) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	id := nextSubscriberID
+	nextSubscriberID++
+	subscribers[id] = fn
+	return func() {
+		subscribersMu.Lock()
+		defer subscribersMu.Unlock()
+		delete(subscribers, id)
+	}
+}
+
+// Reset drops the snapshot applied from the ConfigMap, so that Current reflects the package-level variables again.
+func Reset() {
+	current.Store(nil)
+}
+
+// publish swaps the snapshot in effect and notifies the subscribers if the configuration changed.
+// The subscribers are called while subscribersMu is held, so concurrent publishes reach them in order.
+func publish(next *Config) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	previous := Current()
+	current.Store(next)
+	if *previous == *next {
+		return
+	}
+	for _, fn := range subscribers {
+		fn(previous, next)
+	}
+}
+
+func defaults() *Config {
+	return &Config{
+		ChartNamespace:                     ChartNamespace,
+		SecretName:                         SecretName,
+		ConfigName:                         ConfigName,
+		DeploymentName:                     DeploymentName,
+		ProcessingStateRequeueInterval:     ProcessingStateRequeueInterval,
+		ReadyStateRequeueInterval:          ReadyStateRequeueInterval,
+		ReadyTimeout:                       ReadyTimeout,
+		ReadyCheckInterval:                 ReadyCheckInterval,
+		HardDeleteTimeout:                  HardDeleteTimeout,
+		HardDeleteCheckInterval:            HardDeleteCheckInterval,
+		DeleteRequestTimeout:               DeleteRequestTimeout,
+		HardDeleteConcurrency:              HardDeleteConcurrency,
+		HardDeleteQPS:                      HardDeleteQPS,
+		StatusUpdateTimeout:                StatusUpdateTimeout,
+		StatusUpdateCheckInterval:          StatusUpdateCheckInterval,
+		CaCertificateExpiration:            CaCertificateExpiration,
+		WebhookCertificateExpiration:       WebhookCertificateExpiration,
+		ExpirationBoundary:                 ExpirationBoundary,
+		WebhookCertificateMode:             WebhookCertificateMode,
+		RsaKeyBits:                         certs.RsaKeyBits(),
+		KeyAlgorithm:                       certs.KeyAlgorithm(),
+		CaRotationAdvance:                  CaRotationAdvance,
+		CaRotationReloadTimeout:            CaRotationReloadTimeout,
+		CertificateRegenerationGracePeriod: CertificateRegenerationGracePeriod,
+		ExternalCaSecret:                   ExternalCaSecret,
+		WebhookSelfTestInterval:            WebhookSelfTestInterval,
+		WebhookSelfTestTimeout:             WebhookSelfTestTimeout,
+		RestoreServiceInstancesAndBindings: RestoreServiceInstancesAndBindings,
+		ForceDeleteConfirmationRequired:    ForceDeleteConfirmationRequired,
+		ChartPath:                          ChartPath,
+		ResourcesPath:                      ResourcesPath,
+		ManagerResourcesPath:               ManagerResourcesPath,
+		EnableLimitedCache:                 EnableLimitedCache,
+		ProbeInterval:                      ProbeInterval,
+		ProbeMode:                          ProbeMode,
+		ProbeHistoryLength:                 ProbeHistoryLength,
+		ProbeAlertThreshold:                ProbeAlertThreshold,
+		ProbeErrorThreshold:                ProbeErrorThreshold,
+		ProbeRestartMinInterval:            ProbeRestartMinInterval,
+		ProbeCAExpiryWarning:               ProbeCAExpiryWarning,
+		HttpProxy:                          HttpProxy,
+		HttpsProxy:                         HttpsProxy,
+		NoProxy:                            NoProxy,
+		TrustBundleConfigMap:               TrustBundleConfigMap,
+		TrustBundleSecret:                  TrustBundleSecret,
+		TrustBundleKey:                     TrustBundleKey,
+	}
+}
+
+// values returns the snapshot as a map of ConfigMap keys to values.
+func (c *Config) values() map[string]any {
+	v := reflect.ValueOf(c).Elem()
+	values := make(map[string]any, v.NumField())
+	for i := range v.NumField() {
+		values[v.Type().Field(i).Name] = v.Field(i).Interface()
+	}
+	return values
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCurrentFallsBackToDefaults(t *testing.T) {
+	Reset()
+	original := ProbeInterval
+	t.Cleanup(func() { ProbeInterval = original })
+
+	ProbeInterval = 7 * time.Minute
+	if got := Current().ProbeInterval; got != 7*time.Minute {
+		t.Fatalf("expected ProbeInterval 7m from the defaults, got %s", got)
+	}
+}
+
+func TestPublishNotifiesSubscribersOfChanges(t *testing.T) {
+	t.Cleanup(Reset)
+	publish(&Config{ProbeInterval: time.Hour})
+
+	var notified []time.Duration
+	unsubscribe := Subscribe(func(previous, current *Config) {
+		notified = append(notified, previous.ProbeInterval, current.ProbeInterval)
+	})
+
+	publish(&Config{ProbeInterval: time.Hour})
+	if len(notified) != 0 {
+		t.Fatalf("expected no notification for an unchanged configuration, got %v", notified)
+	}
+
+	next := &Config{ProbeInterval: time.Minute}
+	publish(next)
+	if len(notified) != 2 || notified[0] != time.Hour || notified[1] != time.Minute {
+		t.Fatalf("expected a notification from 1h to 1m, got %v", notified)
+	}
+	if Current() != next {
+		t.Fatalf("expected the published snapshot to be current")
+	}
+
+	unsubscribe()
+	publish(&Config{ProbeInterval: time.Second})
+	if len(notified) != 2 {
+		t.Fatalf("expected no notification after unsubscribing, got %v", notified)
+	}
+}
+
+func TestCurrentIsSafeForConcurrentPublish(t *testing.T) {
+	t.Cleanup(Reset)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 1000 {
+			publish(&Config{HardDeleteConcurrency: i + 1})
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			if got := Current().HardDeleteConcurrency; got != 1000 {
+				t.Fatalf("expected the last published HardDeleteConcurrency 1000, got %d", got)
+			}
+			return
+		default:
+			if Current().HardDeleteConcurrency < 0 {
+				t.Fatalf("unexpected HardDeleteConcurrency")
+			}
+		}
+	}
+}
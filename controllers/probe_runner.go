@@ -4,10 +4,17 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kyma-project/btp-manager/api/v1alpha1"
 	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	"github.com/kyma-project/btp-manager/internal/manager/moduleresource"
+	"github.com/kyma-project/btp-manager/internal/tlsprobe"
+	"github.com/kyma-project/btp-manager/internal/trustbundle"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -22,27 +29,29 @@ import (
 
 //+kubebuilder:rbac:groups="batch",resources="jobs",verbs=get;list;watch;create;delete
 
+const probeJobName = "btp-manager-ca-bundle-probe"
+
+// Results of the sap-btp-operator pod restarts after the CA bundle changed.
 const (
-	probeJobName             = "btp-manager-ca-bundle-probe"
-	probeAnnotationStatus    = "tls-probe-status"
-	probeAnnotationHash      = "tls-probe-hash"
-	probeAnnotationLastHash  = "tls-probe-last-hash"
-	probeAnnotationUpdatedAt = "tls-probe-updated-at"
-
-	probeStatusOK    = "ok"
-	probeStatusAlert = "alert"
+	probeRestartDone        = "restarted"
+	probeRestartRateLimited = "rate-limited"
 )
 
 var jobWaitTimeout = 5 * time.Minute
 
-// ProbeRunner is a controller-runtime Runnable that periodically spawns a tls-probe Job
-// and reads back signals from BtpOperator CR annotations. It is disabled when ProbeInterval is 0.
+// ProbeRunner is a controller-runtime Runnable that periodically spawns a tls-probe Job, or runs the probe in-process
+// depending on ProbeMode, and reads back the result from the BtpOperator CR status. It is disabled while ProbeInterval is 0.
 type ProbeRunner struct {
 	client           client.Client
+	apiReader        client.Reader
 	probeImage       string
 	tokenURLOverride string
 	forceHash        string
 	statusGauge      prometheus.Gauge
+	checkDuration    *prometheus.HistogramVec
+	checkSuccess     *prometheus.GaugeVec
+	restarts         *prometheus.CounterVec
+	expiringCAs      *prometheus.GaugeVec
 }
 
 func NewProbeRunner(c client.Client, registry prometheus.Registerer) *ProbeRunner {
@@ -56,44 +65,97 @@ func NewProbeRunner(c client.Client, registry prometheus.Registerer) *ProbeRunne
 		Help:      "CA bundle probe status: 0=ok or error, 1=alert (CA mounted but cert not trusted)",
 	})
 
+	checkDuration := promauto.With(registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "btpmanager",
+		Name:      "credential_probe_check_duration_seconds",
+		Help:      "Duration of the CA bundle probe connectivity checks",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"check"})
+	checkSuccess := promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "btpmanager",
+		Name:      "credential_probe_check_success",
+		Help:      "Result of the CA bundle probe connectivity checks in the last probe run: 1=succeeded, 0=failed",
+	}, []string{"check"})
+	restarts := promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "btpmanager",
+		Name:      "credential_probe_restarts_total",
+		Help:      "Number of sap-btp-operator pod restarts after the CA bundle changed, by result: restarted or rate-limited",
+	}, []string{"result"})
+	expiringCAs := promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "btpmanager",
+		Name:      "credential_probe_expiring_cas",
+		Help:      "Number of expired or soon expiring certificates in the mounted CA bundle, by state and whether the token URL chain depends on them",
+	}, []string{"state", "token_url_chain"})
+
 	return &ProbeRunner{
 		client:           c,
+		apiReader:        c,
 		probeImage:       image,
 		tokenURLOverride: override,
 		forceHash:        forceHash,
 		statusGauge:      gauge,
+		checkDuration:    checkDuration,
+		checkSuccess:     checkSuccess,
+		restarts:         restarts,
+		expiringCAs:      expiringCAs,
 	}
 }
 
-// Start implements manager.Runnable. Returns immediately if probe is disabled.
-//
-//nolint:cyclop
+// WithAPIReader sets the reader for the trust bundle in the in-process mode. It should bypass the cache,
+// because the cached client only sees ConfigMaps and Secrets managed by btp-manager.
+func (r *ProbeRunner) WithAPIReader(reader client.Reader) *ProbeRunner {
+	r.apiReader = reader
+	return r
+}
+
+// Start implements manager.Runnable. It runs a probe cycle at startup and then every ProbeInterval. The ticker is reset
+// when ProbeInterval changes, so the next cycle runs one interval after the change. Cycles are skipped while the probe is disabled.
 func (r *ProbeRunner) Start(ctx context.Context) error {
 	logger := log.FromContext(ctx).WithName("probe-runner")
 
-	interval := config.ProbeInterval
-	if interval == 0 || r.probeImage == "" {
-		logger.Info("CA bundle probe disabled", "interval", interval, "image", r.probeImage)
-		return nil
-	}
-
-	logger.Info("CA bundle probe runner started", "interval", interval, "image", r.probeImage)
-
-	if err := r.runCycle(ctx); err != nil {
-		logger.Error(err, "probe cycle failed")
-		if ctx.Err() != nil {
-			return nil
+	intervalChanged := make(chan time.Duration, 1)
+	unsubscribe := config.Subscribe(func(previous, current *config.Config) {
+		if previous.ProbeInterval == current.ProbeInterval {
+			return
+		}
+		// Only the latest interval matters, so an interval not received yet is replaced.
+		select {
+		case <-intervalChanged:
+		default:
+		}
+		intervalChanged <- current.ProbeInterval
+	})
+	defer unsubscribe()
+
+	cfg := config.Current()
+	interval := cfg.ProbeInterval
+	if r.enabled(interval) {
+		logger.Info("CA bundle probe runner started", "interval", interval, "mode", cfg.ProbeMode, "image", r.probeImage)
+		if err := r.runCycle(ctx); err != nil {
+			logger.Error(err, "probe cycle failed")
+			if ctx.Err() != nil {
+				return nil
+			}
 		}
+	} else {
+		logger.Info("CA bundle probe disabled", "interval", interval, "mode", cfg.ProbeMode, "image", r.probeImage)
 	}
 
-	ticker := time.NewTicker(interval)
+	ticker := time.NewTicker(time.Hour)
 	defer ticker.Stop()
+	resetTicker(ticker, interval)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
+		case interval = <-intervalChanged:
+			logger.Info("CA bundle probe interval changed", "interval", interval)
+			resetTicker(ticker, interval)
 		case <-ticker.C:
+			if !r.enabled(interval) {
+				continue
+			}
 			if err := r.runCycle(ctx); err != nil {
 				logger.Error(err, "probe cycle failed")
 			}
@@ -101,114 +163,351 @@ func (r *ProbeRunner) Start(ctx context.Context) error {
 	}
 }
 
-func (r *ProbeRunner) runCycle(ctx context.Context) error {
-	logger := log.FromContext(ctx).WithName("probe-runner")
+// enabled reports whether the probe runs: ProbeInterval must not be 0, and the Job mode requires the probe image.
+func (r *ProbeRunner) enabled(interval time.Duration) bool {
+	return interval != 0 && (inProcessMode() || r.probeImage != "")
+}
 
-	if err := r.deleteOldJob(ctx); err != nil {
-		return fmt.Errorf("deleting old probe job: %w", err)
+// resetTicker makes the ticker fire every interval, or stops it when the interval is 0.
+func resetTicker(ticker *time.Ticker, interval time.Duration) {
+	if interval == 0 {
+		ticker.Stop()
+		return
 	}
+	ticker.Reset(interval)
+}
 
-	// Record tls-probe-updated-at before running the job so we can detect whether the probe
-	// wrote new annotations this cycle (it silently exits without writing when no mount + TLS ok).
-	prevUpdatedAt, err := r.getUpdatedAt(ctx)
+func (r *ProbeRunner) runCycle(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("probe-runner")
+
+	// Record the probe updatedAt before running the probe so we can detect whether the probe
+	// wrote a new result this cycle (it doesn't when the Job fails before reaching the API server).
+	previous, err := r.getProbeStatus(ctx)
 	if err != nil {
-		return fmt.Errorf("reading tls-probe-updated-at: %w", err)
+		return fmt.Errorf("reading probe status: %w", err)
 	}
 
-	if err := r.createJob(ctx); err != nil {
-		return fmt.Errorf("creating probe job: %w", err)
+	if inProcessMode() {
+		if err := r.runInProcess(ctx); err != nil {
+			return fmt.Errorf("running in-process probe: %w", err)
+		}
+	} else if err := r.runJob(ctx); err != nil {
+		return err
 	}
 
-	if err := r.waitForJob(ctx); err != nil {
-		return fmt.Errorf("waiting for probe job: %w", err)
+	probe, err := r.getProbeStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("reading probe status: %w", err)
 	}
-
-	cr := &v1alpha1.BtpOperator{}
-	if err := r.client.Get(ctx, types.NamespacedName{
-		Name:      config.BtpOperatorCrName,
-		Namespace: config.KymaSystemNamespaceName,
-	}, cr); err != nil {
-		return fmt.Errorf("getting BtpOperator CR: %w", err)
+	if probe == nil || (previous != nil && probe.UpdatedAt.Equal(&previous.UpdatedAt)) {
+		logger.Info("probe did not write a result this cycle")
+		return nil
 	}
 
-	annotations := cr.GetAnnotations()
-	if annotations == nil {
-		annotations = map[string]string{}
+	probe, err = r.recordHistory(ctx, probe)
+	if err != nil {
+		return fmt.Errorf("recording probe history: %w", err)
 	}
-
-	status := annotations[probeAnnotationStatus]
-	hash := annotations[probeAnnotationHash]
-	lastHash := annotations[probeAnnotationLastHash]
-	updatedAt := annotations[probeAnnotationUpdatedAt]
+	consecutive := consecutiveResults(probe.History)
+	reportable := thresholdReached(probe.Result, consecutive)
 
 	logger.Info("probe cycle result",
-		"status", status,
-		"hash", hash,
-		"lastHash", lastHash,
-		"updatedAt", updatedAt,
+		"result", probe.Result,
+		"consecutive", consecutive,
+		"reportable", reportable,
+		"tlsResult", probe.TLSResult,
+		"targetURL", probe.TargetURL,
+		"mountPresent", probe.MountPresent,
+		"hash", probe.Hash,
+		"lastHash", probe.LastHash,
+		"expiringCAs", len(probe.ExpiringCAs),
+		"updatedAt", probe.UpdatedAt,
 	)
 
-	// If tls-probe-updated-at did not advance, the probe silently exited (no mount + TLS ok).
-	// Nothing to process this cycle.
-	if updatedAt == prevUpdatedAt {
-		return nil
-	}
-
-	// Update metric: 1 if alert (CA mounted but cert not trusted — actionable), 0 otherwise.
+	// Update metric: 1 if alert (CA mounted but cert not trusted — actionable) for ProbeAlertThreshold consecutive cycles, 0 otherwise.
 	// error signals (connectivity failures, no mount) are logged but do not fire the metric.
-	if status == probeStatusAlert {
+	if probe.Result == v1alpha1.ProbeResultAlert && reportable {
 		r.statusGauge.Set(1)
 	} else {
 		r.statusGauge.Set(0)
 	}
+	r.recordChecks(probe.Checks)
+	r.recordExpiringCAs(probe.ExpiringCAs)
 
-	// Advance tls-probe-last-hash only when probe wrote a non-empty hash.
+	// A failure below its threshold keeps the previous condition, so that a single failed cycle doesn't flip it.
+	if reportable {
+		if err := r.setCondition(ctx, probe); err != nil {
+			return err
+		}
+	}
+
+	// Advance lastHash only when probe wrote a non-empty hash.
 	// Overwriting with empty would erase the previous hash and suppress future restart detection.
-	if hash == "" {
+	if probe.Hash == "" || probe.Hash == probe.LastHash {
 		return nil
 	}
 
-	// Restart btp-operator pods when: TLS ok (status=ok), hash changed, and lastHash was non-empty (not first run).
-	// Do this before patching lastHash so that a restart failure leaves lastHash un-advanced:
+	// Restart btp-operator pods when: TLS ok, hash changed, and lastHash was non-empty (not first run).
+	// Do this before storing lastHash so that a restart failure leaves lastHash un-advanced:
 	// the next cycle will see hash != lastHash again and retry the restart.
-	if status == probeStatusOK && hash != lastHash && lastHash != "" {
+	var restartTime *metav1.Time
+	if probe.Result == v1alpha1.ProbeResultOK && probe.LastHash != "" {
+		now := metav1.Now()
+		if !restartAllowed(probe.LastRestartTime, now.Time) {
+			// lastHash is not advanced, so the restart is retried once ProbeRestartMinInterval has passed.
+			// If the bundle flaps back to lastHash in the meantime, no restart is needed at all.
+			logger.Info("CA bundle hash changed with healthy TLS, but btp-operator pods were restarted recently — postponing the restart",
+				"lastRestartTime", probe.LastRestartTime, "minInterval", config.Current().ProbeRestartMinInterval)
+			r.restarts.WithLabelValues(probeRestartRateLimited).Inc()
+			return nil
+		}
 		logger.Info("CA bundle hash changed with healthy TLS — restarting btp-operator pods")
 		if err := r.restartBtpOperatorPods(ctx); err != nil {
 			return fmt.Errorf("restarting btp-operator pods: %w", err)
 		}
+		r.restarts.WithLabelValues(probeRestartDone).Inc()
+		restartTime = &now
+	}
+
+	hash := probe.Hash
+	if err := r.patchStatus(ctx, func(status *v1alpha1.Status) {
+		if status.Probe != nil {
+			status.Probe.LastHash = hash
+			if restartTime != nil {
+				status.Probe.LastRestartTime = restartTime
+			}
+		}
+	}); err != nil {
+		return fmt.Errorf("storing probe lastHash: %w", err)
+	}
+
+	return nil
+}
+
+// inProcessMode reports whether the probe runs in btp-manager. Any ProbeMode other than InProcess runs the probe Job.
+func inProcessMode() bool {
+	return config.Current().ProbeMode == config.ProbeModeInProcess
+}
+
+func (r *ProbeRunner) runJob(ctx context.Context) error {
+	if err := r.deleteOldJob(ctx); err != nil {
+		return fmt.Errorf("deleting old probe job: %w", err)
+	}
+	if err := r.createJob(ctx); err != nil {
+		return fmt.Errorf("creating probe job: %w", err)
+	}
+	if err := r.waitForJob(ctx); err != nil {
+		return fmt.Errorf("waiting for probe job: %w", err)
+	}
+	return nil
+}
+
+// runInProcess probes the token URL with the CA bundle sap-btp-operator uses: the system CA bundle and the managed trust bundle.
+// The CA bundle injected by rt-bootstrapper is not visible to btp-manager, so the result has no hash and never triggers a restart.
+func (r *ProbeRunner) runInProcess(ctx context.Context) error {
+	probe := r.probeInProcess(ctx)
+	return r.patchStatus(ctx, func(status *v1alpha1.Status) {
+		probe.KeepManagedFields(status.Probe)
+		status.Probe = probe
+	})
+}
+
+func (r *ProbeRunner) probeInProcess(ctx context.Context) *v1alpha1.ProbeStatus {
+	failed := func(err error) *v1alpha1.ProbeStatus {
+		return &v1alpha1.ProbeStatus{Result: v1alpha1.ProbeResultError, Error: err.Error(), UpdatedAt: metav1.Now()}
+	}
+
+	mount := tlsprobe.MountSignal{ExpiryWarning: config.Current().ProbeCAExpiryWarning}
+	bundle, err := trustbundle.NewManager(r.apiReader).Load(ctx)
+	if err != nil {
+		return failed(fmt.Errorf("while loading the trust bundle: %w", err))
+	}
+	if bundle != nil {
+		mount = mount.WithTrustBundle(bundle.Data)
+	}
+
+	target, err := r.target(ctx)
+	if err != nil {
+		return failed(err)
+	}
+	return tlsprobe.Run(ctx, target, mount, config.ProxyForURL)
+}
+
+// target reads the SAP Service Manager URLs and client credentials from the sap-btp-manager secret. The token URL override replaces the token URL only.
+func (r *ProbeRunner) target(ctx context.Context) (tlsprobe.Target, error) {
+	cfg := config.Current()
+	secret := &corev1.Secret{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: cfg.SecretName, Namespace: cfg.ChartNamespace}, secret); err != nil {
+		if r.tokenURLOverride != "" {
+			return tlsprobe.Target{TokenURL: r.tokenURLOverride}, nil
+		}
+		return tlsprobe.Target{}, fmt.Errorf("while getting %s secret: %w", cfg.SecretName, err)
+	}
+	target := tlsprobe.Target{
+		TokenURL:     string(secret.Data[moduleresource.TokenUrlSecretKey]),
+		SMURL:        string(secret.Data[moduleresource.SmUrlSecretKey]),
+		ClientID:     string(secret.Data[moduleresource.ClientIdSecretKey]),
+		ClientSecret: string(secret.Data[moduleresource.ClientSecretKey]),
+	}
+	if r.tokenURLOverride != "" {
+		target.TokenURL = r.tokenURLOverride
 	}
+	if target.TokenURL == "" {
+		return tlsprobe.Target{}, fmt.Errorf("%s secret does not contain the %s key", cfg.SecretName, moduleresource.TokenUrlSecretKey)
+	}
+	return target, nil
+}
 
-	// Re-fetch the CR immediately before patching to avoid overwriting the probe-written
-	// annotations (tls-probe-status, tls-probe-hash, tls-probe-updated-at) with the stale
-	// base captured before the Job ran.
-	freshCR := &v1alpha1.BtpOperator{}
+// recordHistory adds the probe result to the history in the CR status, newest first, and returns the updated probe status.
+// A result that is already in the history, because a previous cycle failed after recording it, isn't added again.
+func (r *ProbeRunner) recordHistory(ctx context.Context, probe *v1alpha1.ProbeStatus) (*v1alpha1.ProbeStatus, error) {
+	entry := v1alpha1.ProbeHistoryEntry{
+		Result:    probe.Result,
+		TLSResult: probe.TLSResult,
+		Hash:      probe.Hash,
+		UpdatedAt: probe.UpdatedAt,
+	}
+	updated := probe
+	err := r.patchStatus(ctx, func(status *v1alpha1.Status) {
+		if status.Probe == nil {
+			return
+		}
+		history := status.Probe.History
+		if len(history) == 0 || !history[0].UpdatedAt.Equal(&entry.UpdatedAt) {
+			history = append([]v1alpha1.ProbeHistoryEntry{entry}, history...)
+		}
+		if length := historyLength(); len(history) > length {
+			history = history[:length]
+		}
+		status.Probe.History = history
+		updated = status.Probe.DeepCopy()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// historyLength returns the number of probe results kept in the history. It's never lower than the thresholds, so that they can be reached.
+func historyLength() int {
+	cfg := config.Current()
+	return max(cfg.ProbeHistoryLength, cfg.ProbeAlertThreshold, cfg.ProbeErrorThreshold, 1)
+}
+
+// consecutiveResults returns the number of the latest results in the history equal to the newest one.
+func consecutiveResults(history []v1alpha1.ProbeHistoryEntry) int {
+	if len(history) == 0 {
+		return 0
+	}
+	count := 1
+	for count < len(history) && history[count].Result == history[0].Result {
+		count++
+	}
+	return count
+}
+
+// thresholdReached reports whether the result was returned by enough consecutive probe runs to be reported. An ok result is reported immediately.
+func thresholdReached(result string, consecutive int) bool {
+	cfg := config.Current()
+	switch result {
+	case v1alpha1.ProbeResultAlert:
+		return consecutive >= cfg.ProbeAlertThreshold
+	case v1alpha1.ProbeResultError:
+		return consecutive >= cfg.ProbeErrorThreshold
+	default:
+		return true
+	}
+}
+
+// restartAllowed reports whether ProbeRestartMinInterval has passed since the last restart of the sap-btp-operator pods.
+func restartAllowed(lastRestartTime *metav1.Time, now time.Time) bool {
+	return lastRestartTime == nil || !now.Before(lastRestartTime.Add(config.Current().ProbeRestartMinInterval))
+}
+
+// recordChecks exports the connectivity checks of the probe run. Checks that did not run in this run are removed from the success gauge.
+func (r *ProbeRunner) recordChecks(checks []v1alpha1.ProbeCheck) {
+	r.checkSuccess.Reset()
+	for _, check := range checks {
+		r.checkDuration.WithLabelValues(check.Name).Observe(check.Duration.Seconds())
+		success := 0.0
+		if check.Success {
+			success = 1
+		}
+		r.checkSuccess.WithLabelValues(check.Name).Set(success)
+	}
+}
+
+// recordExpiringCAs exports the number of expired and expiring CAs of the probe run.
+func (r *ProbeRunner) recordExpiringCAs(cas []v1alpha1.ExpiringCA) {
+	r.expiringCAs.Reset()
+	for _, ca := range cas {
+		state := "expiring"
+		if ca.Expired {
+			state = "expired"
+		}
+		r.expiringCAs.WithLabelValues(state, strconv.FormatBool(ca.InTokenURLChain)).Inc()
+	}
+}
+
+func (r *ProbeRunner) getProbeStatus(ctx context.Context) (*v1alpha1.ProbeStatus, error) {
+	cr := &v1alpha1.BtpOperator{}
 	if err := r.client.Get(ctx, types.NamespacedName{
 		Name:      config.BtpOperatorCrName,
 		Namespace: config.KymaSystemNamespaceName,
-	}, freshCR); err != nil {
-		return fmt.Errorf("re-fetching BtpOperator CR for patch: %w", err)
+	}, cr); err != nil {
+		return nil, fmt.Errorf("getting BtpOperator CR: %w", err)
 	}
-	patch := client.MergeFrom(freshCR.DeepCopy())
-	if freshCR.Annotations == nil {
-		freshCR.Annotations = map[string]string{}
+	return cr.Status.Probe, nil
+}
+
+// setCondition sets the CaBundleTrusted condition from the probe result. The condition doesn't affect the Ready condition or the CR state.
+func (r *ProbeRunner) setCondition(ctx context.Context, probe *v1alpha1.ProbeStatus) error {
+	condition := metav1.Condition{
+		Type:    conditions.CaBundleTrustedType,
+		Status:  metav1.ConditionTrue,
+		Reason:  string(conditions.CaBundleTrusted),
+		Message: fmt.Sprintf("TLS connection to %s is trusted", probe.TargetURL),
 	}
-	freshCR.Annotations[probeAnnotationLastHash] = hash
-	if err := r.client.Patch(ctx, freshCR, patch); err != nil {
-		return fmt.Errorf("patching tls-probe-last-hash: %w", err)
+	switch probe.Result {
+	case v1alpha1.ProbeResultAlert:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = string(conditions.CaBundleNotTrusted)
+		condition.Message = fmt.Sprintf("The mounted CA bundle does not trust the certificate of %s", probe.TargetURL)
+		if len(probe.FailingCertificateSubjects) > 0 {
+			condition.Message += fmt.Sprintf(": %s", strings.Join(probe.FailingCertificateSubjects, ", "))
+		}
+	case v1alpha1.ProbeResultError:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = string(conditions.CaBundleProbeFailed)
+		condition.Message = probe.Error
+		if condition.Message == "" {
+			condition.Message = fmt.Sprintf("TLS connection to %s failed: %s", probe.TargetURL, probe.TLSResult)
+		}
 	}
 
-	return nil
+	return r.patchStatus(ctx, func(status *v1alpha1.Status) {
+		conditions.SetStatusCondition(&status.Conditions, condition)
+	})
 }
 
-func (r *ProbeRunner) getUpdatedAt(ctx context.Context) (string, error) {
+// patchStatus applies the change to the status of the latest BtpOperator CR. Nothing is written if the status doesn't change.
+func (r *ProbeRunner) patchStatus(ctx context.Context, change func(status *v1alpha1.Status)) error {
 	cr := &v1alpha1.BtpOperator{}
 	if err := r.client.Get(ctx, types.NamespacedName{
 		Name:      config.BtpOperatorCrName,
 		Namespace: config.KymaSystemNamespaceName,
 	}, cr); err != nil {
-		return "", fmt.Errorf("getting BtpOperator CR: %w", err)
+		return fmt.Errorf("getting BtpOperator CR: %w", err)
+	}
+	original := cr.DeepCopy()
+	change(&cr.Status)
+	if reflect.DeepEqual(original.Status, cr.Status) {
+		return nil
+	}
+	if err := r.client.Status().Patch(ctx, cr, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("while patching BtpOperator status: %w", err)
 	}
-	return cr.GetAnnotations()[probeAnnotationUpdatedAt], nil
+	return nil
 }
 
 func (r *ProbeRunner) deleteOldJob(ctx context.Context) error {
@@ -268,6 +567,16 @@ func (r *ProbeRunner) createJob(ctx context.Context) error {
 	if r.forceHash != "" {
 		env = append(env, corev1.EnvVar{Name: "PROBE_FORCE_HASH", Value: r.forceHash})
 	}
+	env = append(env, corev1.EnvVar{Name: "PROBE_CA_EXPIRY_WARNING", Value: config.Current().ProbeCAExpiryWarning.String()})
+	env = append(env, config.ProxyEnvVars()...)
+
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+	if volume, ok := trustbundle.ConfiguredVolume(); ok {
+		volumes = append(volumes, volume)
+		volumeMounts = append(volumeMounts, trustbundle.VolumeMount())
+		env = append(env, corev1.EnvVar{Name: "PROBE_TRUST_BUNDLE_FILE", Value: trustbundle.MountPath + "/" + trustbundle.FileName})
+	}
 
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
@@ -285,11 +594,13 @@ func (r *ProbeRunner) createJob(ctx context.Context) error {
 				Spec: corev1.PodSpec{
 					RestartPolicy:      corev1.RestartPolicyNever,
 					ServiceAccountName: probeJobName,
+					Volumes:            volumes,
 					Containers: []corev1.Container{
 						{
-							Name:  "probe",
-							Image: r.probeImage,
-							Env:   env,
+							Name:         "probe",
+							Image:        r.probeImage,
+							Env:          env,
+							VolumeMounts: volumeMounts,
 						},
 					},
 				},
@@ -326,7 +637,7 @@ func (r *ProbeRunner) waitForJob(ctx context.Context) error {
 			return nil
 		}
 		if job.Status.Failed > 0 {
-			logger.Info("probe job failed; reading annotations anyway")
+			logger.Info("probe job failed; reading the probe status anyway")
 			return nil
 		}
 
@@ -1,17 +1,34 @@
 package controllers
 
 import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
 	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	"github.com/kyma-project/btp-manager/internal/metrics"
+	"github.com/kyma-project/btp-manager/internal/tlsprobe"
+	"github.com/kyma-project/btp-manager/internal/trustbundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var _ = Describe("ProbeRunner", Label("probe-runner"), func() {
@@ -93,4 +110,444 @@ var _ = Describe("ProbeRunner", Label("probe-runner"), func() {
 			Expect(runner.createJob(ctx)).To(Succeed())
 		})
 	})
+
+	Describe("createJob", func() {
+		It("passes the configured proxy settings to the probe container", func() {
+			Expect(publishConfig(map[string]string{"HttpsProxy": "http://proxy.local:3128", "NoProxy": ".svc"})).To(Succeed())
+			DeferCleanup(config.Reset)
+
+			runner.probeImage = "busybox:latest"
+			Expect(runner.createJob(ctx)).To(Succeed())
+
+			job := &batchv1.Job{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: probeJobName, Namespace: config.KymaSystemNamespaceName}, job)).To(Succeed())
+			env := job.Spec.Template.Spec.Containers[0].Env
+			Expect(env).To(ContainElements(
+				corev1.EnvVar{Name: config.HttpsProxyEnv, Value: "http://proxy.local:3128"},
+				corev1.EnvVar{Name: config.NoProxyEnv, Value: ".svc"},
+			))
+			Expect(env).NotTo(ContainElement(HaveField("Name", config.HttpProxyEnv)))
+		})
+
+		It("mounts the configured trust bundle into the probe container", func() {
+			Expect(publishConfig(map[string]string{"TrustBundleConfigMap": "corporate-ca"})).To(Succeed())
+			DeferCleanup(config.Reset)
+
+			runner.probeImage = "busybox:latest"
+			Expect(runner.createJob(ctx)).To(Succeed())
+
+			job := &batchv1.Job{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: probeJobName, Namespace: config.KymaSystemNamespaceName}, job)).To(Succeed())
+			podSpec := job.Spec.Template.Spec
+			Expect(podSpec.Volumes).To(HaveLen(1))
+			Expect(podSpec.Volumes[0].ConfigMap).NotTo(BeNil())
+			Expect(podSpec.Volumes[0].ConfigMap.Name).To(Equal("corporate-ca"))
+			Expect(podSpec.Containers[0].VolumeMounts).To(ConsistOf(trustbundle.VolumeMount()))
+			Expect(podSpec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "PROBE_TRUST_BUNDLE_FILE", Value: "/etc/ssl/custom-ca/ca-bundle.crt"}))
+		})
+	})
 })
+
+func TestProbeRunner_SetCondition(t *testing.T) {
+	ctx := context.Background()
+	scheme := clientgoscheme.Scheme
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	cr := createDefaultBtpOperator()
+	cr.Status.Probe = &v1alpha1.ProbeStatus{Result: v1alpha1.ProbeResultOK, LastHash: "abc"}
+	fakeK8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cr).WithStatusSubresource(cr).Build()
+	runner := &ProbeRunner{client: fakeK8sClient}
+	key := client.ObjectKeyFromObject(cr)
+
+	t.Run("should set the condition to false with the untrusted chain", func(t *testing.T) {
+		// when
+		err := runner.setCondition(ctx, &v1alpha1.ProbeStatus{
+			Result:                     v1alpha1.ProbeResultAlert,
+			TargetURL:                  "https://token.example.com",
+			FailingCertificateSubjects: []string{"CN=token.example.com", "CN=Intermediate CA"},
+		})
+
+		// then
+		require.NoError(t, err)
+		updated := &v1alpha1.BtpOperator{}
+		require.NoError(t, fakeK8sClient.Get(ctx, key, updated))
+		condition := caBundleTrustedCondition(updated)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, string(conditions.CaBundleNotTrusted), condition.Reason)
+		assert.Contains(t, condition.Message, "CN=token.example.com, CN=Intermediate CA")
+		assert.Equal(t, "abc", updated.Status.Probe.LastHash)
+	})
+
+	t.Run("should not write an unchanged condition", func(t *testing.T) {
+		// given
+		probe := &v1alpha1.ProbeStatus{Result: v1alpha1.ProbeResultOK, TargetURL: "https://token.example.com"}
+		require.NoError(t, runner.setCondition(ctx, probe))
+		before := &v1alpha1.BtpOperator{}
+		require.NoError(t, fakeK8sClient.Get(ctx, key, before))
+
+		// when
+		require.NoError(t, runner.setCondition(ctx, probe))
+
+		// then
+		after := &v1alpha1.BtpOperator{}
+		require.NoError(t, fakeK8sClient.Get(ctx, key, after))
+		assert.Equal(t, before.ResourceVersion, after.ResourceVersion)
+		condition := caBundleTrustedCondition(after)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	})
+
+	t.Run("should report the probe error", func(t *testing.T) {
+		// when
+		err := runner.setCondition(ctx, &v1alpha1.ProbeStatus{Result: v1alpha1.ProbeResultError, Error: "resolve token URL: secret not found"})
+
+		// then
+		require.NoError(t, err)
+		updated := &v1alpha1.BtpOperator{}
+		require.NoError(t, fakeK8sClient.Get(ctx, key, updated))
+		condition := caBundleTrustedCondition(updated)
+		require.NotNil(t, condition)
+		assert.Equal(t, string(conditions.CaBundleProbeFailed), condition.Reason)
+		assert.Equal(t, "resolve token URL: secret not found", condition.Message)
+	})
+}
+
+func caBundleTrustedCondition(cr *v1alpha1.BtpOperator) *metav1.Condition {
+	for _, condition := range cr.Status.Conditions {
+		if condition != nil && condition.Type == conditions.CaBundleTrustedType {
+			return condition
+		}
+	}
+	return nil
+}
+
+func TestProbeRunner_RunInProcess(t *testing.T) {
+	ctx := context.Background()
+	scheme := clientgoscheme.Scheme
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	newRunner := func(objs ...client.Object) (*ProbeRunner, client.Client) {
+		cr := createDefaultBtpOperator()
+		cr.Status.Probe = &v1alpha1.ProbeStatus{Result: v1alpha1.ProbeResultOK, Hash: "abc", LastHash: "abc"}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: config.SecretName, Namespace: config.ChartNamespace},
+			Data:       map[string][]byte{"tokenurl": []byte(srv.URL + "/oauth/token")},
+		}
+		fakeK8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objs, cr, secret)...).WithStatusSubresource(cr).Build()
+		return &ProbeRunner{client: fakeK8sClient, apiReader: fakeK8sClient}, fakeK8sClient
+	}
+
+	t.Run("should trust the token URL with the managed trust bundle", func(t *testing.T) {
+		// given
+		setConfig(t, map[string]string{"TrustBundleConfigMap": "corporate-ca"})
+		runner, fakeK8sClient := newRunner(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "corporate-ca", Namespace: config.ChartNamespace},
+			Data:       map[string]string{config.TrustBundleKey: string(serverCA)},
+		})
+
+		// when
+		require.NoError(t, runner.runInProcess(ctx))
+
+		// then
+		updated := &v1alpha1.BtpOperator{}
+		require.NoError(t, fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(createDefaultBtpOperator()), updated))
+		probe := updated.Status.Probe
+		require.NotNil(t, probe)
+		assert.Equal(t, v1alpha1.ProbeResultOK, probe.Result)
+		assert.Equal(t, srv.URL+"/oauth/token", probe.TargetURL)
+		assert.True(t, probe.MountPresent)
+		assert.Empty(t, probe.Hash)
+		assert.Equal(t, "abc", probe.LastHash)
+		require.NotEmpty(t, probe.Checks)
+		assert.Equal(t, tlsprobe.CheckTLSTokenURL, probe.Checks[len(probe.Checks)-1].Name)
+		assert.True(t, probe.Checks[len(probe.Checks)-1].Success)
+	})
+
+	t.Run("should report an error when the trust bundle is missing", func(t *testing.T) {
+		// given
+		setConfig(t, map[string]string{"TrustBundleConfigMap": "missing"})
+		runner, fakeK8sClient := newRunner()
+
+		// when
+		require.NoError(t, runner.runInProcess(ctx))
+
+		// then
+		updated := &v1alpha1.BtpOperator{}
+		require.NoError(t, fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(createDefaultBtpOperator()), updated))
+		probe := updated.Status.Probe
+		require.NotNil(t, probe)
+		assert.Equal(t, v1alpha1.ProbeResultError, probe.Result)
+		assert.Contains(t, probe.Error, "trust bundle ConfigMap missing not found")
+	})
+}
+
+func TestProbeRunner_RecordChecks(t *testing.T) {
+	// given
+	registry := prometheus.NewRegistry()
+	runner := NewProbeRunner(fake.NewClientBuilder().Build(), registry)
+
+	// when
+	runner.recordChecks([]v1alpha1.ProbeCheck{
+		{Name: tlsprobe.CheckDNSTokenURL, Success: true, Duration: metav1.Duration{Duration: 10 * time.Millisecond}},
+		{Name: tlsprobe.CheckTLSTokenURL, Success: false, Duration: metav1.Duration{Duration: 20 * time.Millisecond}, Error: "TLS handshake failed"},
+	})
+	runner.recordChecks([]v1alpha1.ProbeCheck{
+		{Name: tlsprobe.CheckTLSTokenURL, Success: true, Duration: metav1.Duration{Duration: 30 * time.Millisecond}},
+	})
+
+	// then
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	success := map[string]float64{}
+	observations := map[string]uint64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch family.GetName() {
+			case "btpmanager_credential_probe_check_success":
+				success[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+			case "btpmanager_credential_probe_check_duration_seconds":
+				observations[metric.GetLabel()[0].GetValue()] = metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{tlsprobe.CheckTLSTokenURL: 1}, success, "checks that did not run in the last probe run are removed")
+	assert.Equal(t, map[string]uint64{tlsprobe.CheckDNSTokenURL: 1, tlsprobe.CheckTLSTokenURL: 2}, observations)
+}
+
+func TestProbeRunner_RecordHistory(t *testing.T) {
+	ctx := context.Background()
+	scheme := clientgoscheme.Scheme
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	setConfig(t, map[string]string{"ProbeHistoryLength": "2"})
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cr := createDefaultBtpOperator()
+	cr.Status.Probe = &v1alpha1.ProbeStatus{Result: v1alpha1.ProbeResultOK, LastHash: "abc"}
+	fakeK8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cr).WithStatusSubresource(cr).Build()
+	runner := &ProbeRunner{client: fakeK8sClient}
+
+	record := func(result string, minutes int) *v1alpha1.ProbeStatus {
+		probe := &v1alpha1.ProbeStatus{Result: result, UpdatedAt: metav1.NewTime(start.Add(time.Duration(minutes) * time.Minute))}
+		updated, err := runner.recordHistory(ctx, probe)
+		require.NoError(t, err)
+		return updated
+	}
+
+	t.Run("should add the results newest first", func(t *testing.T) {
+		record(v1alpha1.ProbeResultOK, 0)
+		probe := record(v1alpha1.ProbeResultAlert, 1)
+
+		require.Len(t, probe.History, 2)
+		assert.Equal(t, v1alpha1.ProbeResultAlert, probe.History[0].Result)
+		assert.Equal(t, v1alpha1.ProbeResultOK, probe.History[1].Result)
+		assert.Equal(t, "abc", probe.LastHash)
+	})
+
+	t.Run("should not add the same result twice", func(t *testing.T) {
+		probe := record(v1alpha1.ProbeResultAlert, 1)
+
+		require.Len(t, probe.History, 2)
+		assert.Equal(t, v1alpha1.ProbeResultOK, probe.History[1].Result)
+	})
+
+	t.Run("should drop the oldest results", func(t *testing.T) {
+		probe := record(v1alpha1.ProbeResultAlert, 2)
+
+		require.Len(t, probe.History, 2)
+		assert.Equal(t, 2, consecutiveResults(probe.History))
+	})
+
+	t.Run("should keep enough results to reach the thresholds", func(t *testing.T) {
+		setConfig(t, map[string]string{"ProbeHistoryLength": "2", "ProbeAlertThreshold": "3"})
+
+		probe := record(v1alpha1.ProbeResultAlert, 3)
+
+		require.Len(t, probe.History, 3)
+		assert.True(t, thresholdReached(probe.Result, consecutiveResults(probe.History)))
+	})
+}
+
+func TestProbeRunner_Thresholds(t *testing.T) {
+	setConfig(t, map[string]string{"ProbeAlertThreshold": "3", "ProbeErrorThreshold": "2"})
+
+	history := func(results ...string) []v1alpha1.ProbeHistoryEntry {
+		entries := make([]v1alpha1.ProbeHistoryEntry, 0, len(results))
+		for _, result := range results {
+			entries = append(entries, v1alpha1.ProbeHistoryEntry{Result: result})
+		}
+		return entries
+	}
+
+	assert.Equal(t, 0, consecutiveResults(nil))
+	assert.Equal(t, 2, consecutiveResults(history(v1alpha1.ProbeResultAlert, v1alpha1.ProbeResultAlert, v1alpha1.ProbeResultOK, v1alpha1.ProbeResultAlert)))
+
+	assert.True(t, thresholdReached(v1alpha1.ProbeResultOK, 1), "ok is reported immediately")
+	assert.False(t, thresholdReached(v1alpha1.ProbeResultAlert, 2))
+	assert.True(t, thresholdReached(v1alpha1.ProbeResultAlert, 3))
+	assert.False(t, thresholdReached(v1alpha1.ProbeResultError, 1))
+	assert.True(t, thresholdReached(v1alpha1.ProbeResultError, 2))
+}
+
+func TestProbeRunner_RestartAllowed(t *testing.T) {
+	setConfig(t, map[string]string{"ProbeRestartMinInterval": "1h"})
+	lastRestart := metav1.NewTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.True(t, restartAllowed(nil, lastRestart.Time), "the first restart is allowed")
+	assert.False(t, restartAllowed(&lastRestart, lastRestart.Add(59*time.Minute)))
+	assert.True(t, restartAllowed(&lastRestart, lastRestart.Add(time.Hour)))
+}
+
+func TestProbeRunner_RunCycleErrorThreshold(t *testing.T) {
+	ctx := context.Background()
+	scheme := clientgoscheme.Scheme
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	setConfig(t, map[string]string{"ProbeMode": config.ProbeModeInProcess, "ProbeErrorThreshold": "2"})
+
+	// The sap-btp-manager secret is missing, so every in-process probe run returns an error.
+	newRunner := func(history ...v1alpha1.ProbeHistoryEntry) (*ProbeRunner, client.Client) {
+		cr := createDefaultBtpOperator()
+		cr.Status.Probe = &v1alpha1.ProbeStatus{Result: v1alpha1.ProbeResultOK, History: history}
+		fakeK8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cr).WithStatusSubresource(cr).Build()
+		return NewProbeRunner(fakeK8sClient, prometheus.NewRegistry()), fakeK8sClient
+	}
+
+	t.Run("should not report the first error", func(t *testing.T) {
+		// given
+		runner, fakeK8sClient := newRunner()
+
+		// when
+		require.NoError(t, runner.runCycle(ctx))
+
+		// then
+		updated := &v1alpha1.BtpOperator{}
+		require.NoError(t, fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(createDefaultBtpOperator()), updated))
+		require.Len(t, updated.Status.Probe.History, 1)
+		assert.Equal(t, v1alpha1.ProbeResultError, updated.Status.Probe.History[0].Result)
+		assert.Nil(t, caBundleTrustedCondition(updated))
+	})
+
+	t.Run("should report consecutive errors at the threshold", func(t *testing.T) {
+		// given
+		runner, fakeK8sClient := newRunner(v1alpha1.ProbeHistoryEntry{Result: v1alpha1.ProbeResultError, UpdatedAt: metav1.NewTime(time.Now().Add(-time.Hour))})
+
+		// when
+		require.NoError(t, runner.runCycle(ctx))
+
+		// then
+		updated := &v1alpha1.BtpOperator{}
+		require.NoError(t, fakeK8sClient.Get(ctx, client.ObjectKeyFromObject(createDefaultBtpOperator()), updated))
+		require.Len(t, updated.Status.Probe.History, 2)
+		condition := caBundleTrustedCondition(updated)
+		require.NotNil(t, condition)
+		assert.Equal(t, string(conditions.CaBundleProbeFailed), condition.Reason)
+	})
+}
+
+func TestProbeRunner_RecordExpiringCAs(t *testing.T) {
+	// given
+	registry := prometheus.NewRegistry()
+	runner := NewProbeRunner(fake.NewClientBuilder().Build(), registry)
+	notAfter := metav1.NewTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	// when
+	runner.recordExpiringCAs([]v1alpha1.ExpiringCA{{Subject: "CN=Old Root", NotAfter: notAfter, Expired: true}})
+	runner.recordExpiringCAs([]v1alpha1.ExpiringCA{
+		{Subject: "CN=Root A", NotAfter: notAfter, InTokenURLChain: true},
+		{Subject: "CN=Root B", NotAfter: notAfter},
+		{Subject: "CN=Root C", NotAfter: notAfter},
+	})
+
+	// then
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	counts := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "btpmanager_credential_probe_expiring_cas" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			counts[labels["state"]+"/"+labels["token_url_chain"]] = metric.GetGauge().GetValue()
+		}
+	}
+	assert.Equal(t, map[string]float64{"expiring/true": 1, "expiring/false": 2}, counts, "CAs reported by a previous probe run are removed")
+}
+
+func TestProbeRunner_StartResetsTickerOnIntervalChange(t *testing.T) {
+	scheme := clientgoscheme.Scheme
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	origInterval, origMode := config.ProbeInterval, config.ProbeMode
+	defer func() { config.ProbeInterval, config.ProbeMode = origInterval, origMode }()
+	config.ProbeInterval, config.ProbeMode = 0, config.ProbeModeInProcess
+	config.Reset()
+	defer config.Reset()
+
+	// Every probe cycle starts by reading the BtpOperator CR, which is missing, so the cycle fails right away.
+	cycles := make(chan struct{}, 100)
+	fakeK8sClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*v1alpha1.BtpOperator); ok {
+				cycles <- struct{}{}
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	}).Build()
+	runner := NewProbeRunner(fakeK8sClient, prometheus.NewRegistry())
+	handler := config.NewHandler(fakeK8sClient, scheme, metrics.NewConfigMetrics(prometheus.NewRegistry()))
+	setInterval := func(interval string) {
+		handler.Reconcile(context.Background(), &corev1.ConfigMap{Data: map[string]string{"ProbeInterval": interval}})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- runner.Start(ctx) }()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+
+	assert.Never(t, func() bool { return len(cycles) > 0 }, 100*time.Millisecond, 10*time.Millisecond, "the probe is disabled")
+
+	setInterval("10ms")
+	assert.Eventually(t, func() bool { return len(cycles) >= 3 }, time.Second, 10*time.Millisecond, "the probe runs with the new interval")
+
+	setInterval("0s")
+	time.Sleep(50 * time.Millisecond)
+	for len(cycles) > 0 {
+		<-cycles
+	}
+	assert.Never(t, func() bool { return len(cycles) > 0 }, 100*time.Millisecond, 10*time.Millisecond, "the probe is disabled again")
+}
+
+// publishConfig publishes a configuration snapshot with data applied over the defaults, the same way as the ConfigMap.
+// The code under test reads config.Current(), so setting the package-level variables has no effect once any snapshot is published.
+// Call config.Reset after the test to restore the defaults.
+func publishConfig(data map[string]string) error {
+	config.Reset()
+	scheme := clientgoscheme.Scheme
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return err
+	}
+	handler := config.NewHandler(fake.NewClientBuilder().WithScheme(scheme).Build(), scheme, metrics.NewConfigMetrics(prometheus.NewRegistry()))
+	handler.Reconcile(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.ConfigName, Namespace: config.ChartNamespace},
+		Data:       data,
+	})
+	if rejected := handler.Effective().Rejected; len(rejected) > 0 {
+		return fmt.Errorf("configuration keys rejected: %v", rejected)
+	}
+	return nil
+}
+
+// setConfig publishes a configuration snapshot with data for the duration of the test.
+func setConfig(t *testing.T, data map[string]string) {
+	t.Helper()
+	require.NoError(t, publishConfig(data))
+	t.Cleanup(config.Reset)
+}
@@ -36,7 +36,9 @@ import (
 	"github.com/kyma-project/btp-manager/internal/manager/moduleresource"
 	"github.com/kyma-project/btp-manager/internal/manifest"
 	btpmanagermetrics "github.com/kyma-project/btp-manager/internal/metrics"
+	"github.com/kyma-project/btp-manager/internal/operandexport"
 	"github.com/kyma-project/btp-manager/internal/provisioning"
+	"github.com/kyma-project/btp-manager/internal/trustbundle"
 	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -157,7 +159,7 @@ var _ = SynchronizedBeforeSuite(func() {
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases")},
+		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases"), filepath.Join("testdata", "cert-manager-crds")},
 		ErrorIfCRDPathMissing: true,
 	}
 
@@ -188,15 +190,18 @@ var _ = SynchronizedBeforeSuite(func() {
 
 	testRegistry := prometheus.NewRegistry()
 	metrics := btpmanagermetrics.NewWebhookMetrics(testRegistry)
+	deprovisioningMetrics := btpmanagermetrics.NewDeprovisioningMetrics(testRegistry)
 	configMetrics := btpmanagermetrics.NewConfigMetrics(testRegistry)
+	reconcileMetrics := btpmanagermetrics.NewReconcileMetrics(testRegistry)
+	configHandler := config.NewHandler(k8sManager.GetClient(), k8sManager.GetScheme(), configMetrics)
 	cleanupReconciler := NewInstanceBindingControllerManager(ctx, k8sManager.GetClient(), k8sManager.GetScheme(), cfg)
 	manifestHandler := &manifest.Handler{Scheme: k8sManager.GetScheme()}
 	networkPolicyManager := networkpolicy.NewManager(k8sManager.GetClient(), manifestHandler)
 	driftDetector := drift.NewDetector(k8sManager.GetClient(), k8sClient)
 	moduleResourceManager := moduleresource.NewManager(k8sManager.GetClient(), k8sManager.GetScheme(), driftDetector)
 	secretsManager := secrets.NewManager(generic.NewObjectManager[*corev1.Secret, *corev1.SecretList](k8sManager.GetClient()))
-	certManager := certificate.NewManager(secretsManager, metrics)
-	provisioningHandler := provisioning.NewHandler(k8sManager.GetClient(), driftDetector, moduleResourceManager, networkPolicyManager, certManager, cleanupReconciler)
+	certManager := certificate.NewManager(secretsManager, metrics, k8sManager.GetClient())
+	provisioningHandler := provisioning.NewHandler(k8sManager.GetClient(), driftDetector, moduleResourceManager, networkPolicyManager, certManager, trustbundle.NewManager(k8sClient), cleanupReconciler, reconcileMetrics)
 	sapBtpConfigurator := configurator.NewConfigurator(driftDetector)
 	reconciler = NewBtpOperatorReconciler(
 		k8sManager.GetClient(),
@@ -204,15 +209,14 @@ var _ = SynchronizedBeforeSuite(func() {
 		k8sManager.GetScheme(),
 		cleanupReconciler,
 		metrics,
-		[]config.WatchHandler{
-			config.NewHandler(k8sManager.GetClient(), k8sManager.GetScheme(), configMetrics),
-		},
+		[]config.WatchHandler{configHandler, configHandler.ConfigurationWatchHandler()},
 		networkPolicyManager,
 		certManager,
 		provisioningHandler,
 		sapBtpConfigurator,
 	)
-	reconciler.SetDeprovisioningHandler(deprovisioning.NewHandler(k8sManager.GetClient(), k8sClient, reconciler, reconciler, cleanupReconciler, driftDetector, moduleResourceManager, networkPolicyManager))
+	reconciler.SetReconcileMetrics(reconcileMetrics)
+	reconciler.SetDeprovisioningHandler(deprovisioning.NewHandler(k8sManager.GetClient(), k8sClient, reconciler, reconciler, cleanupReconciler, driftDetector, moduleResourceManager, networkPolicyManager, operandexport.NewExporter(k8sManager.GetClient(), k8sClient), deprovisioningMetrics))
 
 	k8sClientFromManager = k8sManager.GetClient()
 
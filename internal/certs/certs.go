@@ -2,6 +2,10 @@ package certs
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -10,33 +14,69 @@ import (
 	"fmt"
 	"math/big"
 	mathrand "math/rand"
+	"sync"
 	"time"
 )
 
+const (
+	RsaKeyAlgorithm       = "rsa"
+	EcdsaP256KeyAlgorithm = "ecdsa-p256"
+	EcdsaP384KeyAlgorithm = "ecdsa-p384"
+	Ed25519KeyAlgorithm   = "ed25519"
+)
+
 var (
-	rsaKeyBits = 4096
-	randMax    = 10000
+	// keySettingsMu guards rsaKeyBits and keyAlgorithm, which the configuration handler sets while certificates are generated.
+	keySettingsMu sync.RWMutex
+	rsaKeyBits    = 4096
+	keyAlgorithm  = RsaKeyAlgorithm
+	randMax       = 10000
 )
 
 func RsaKeyBits() int {
+	keySettingsMu.RLock()
+	defer keySettingsMu.RUnlock()
 	return rsaKeyBits
 }
 
 func SetRsaKeyBits(newValue int) {
+	keySettingsMu.Lock()
+	defer keySettingsMu.Unlock()
 	rsaKeyBits = newValue
 }
 
+// KeyAlgorithm returns the algorithm of the private keys generated for new certificates.
+func KeyAlgorithm() string {
+	keySettingsMu.RLock()
+	defer keySettingsMu.RUnlock()
+	return keyAlgorithm
+}
+
+// SetKeyAlgorithm sets the algorithm of the private keys generated for new certificates.
+// RSA keys are sized with RsaKeyBits, the other algorithms have a fixed key size.
+func SetKeyAlgorithm(newValue string) error {
+	switch newValue {
+	case RsaKeyAlgorithm, EcdsaP256KeyAlgorithm, EcdsaP384KeyAlgorithm, Ed25519KeyAlgorithm:
+		keySettingsMu.Lock()
+		defer keySettingsMu.Unlock()
+		keyAlgorithm = newValue
+		return nil
+	}
+	return fmt.Errorf("unsupported key algorithm %q, expected one of: %s, %s, %s, %s",
+		newValue, RsaKeyAlgorithm, EcdsaP256KeyAlgorithm, EcdsaP384KeyAlgorithm, Ed25519KeyAlgorithm)
+}
+
 func getRandomInt() *big.Int {
 	return big.NewInt(int64(mathrand.Intn(randMax)))
 }
 
 func GenerateSelfSignedCertificate(expiration time.Time) ([]byte, []byte, error) {
-	newCertificatePrivateKey, err := rsa.GenerateKey(rand.Reader, RsaKeyBits())
+	newCertificatePrivateKey, err := generatePrivateKey()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	pubKeyBytes, err := x509.MarshalPKIXPublicKey(&newCertificatePrivateKey.PublicKey)
+	pubKeyBytes, err := x509.MarshalPKIXPublicKey(newCertificatePrivateKey.Public())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -51,12 +91,10 @@ func GenerateSelfSignedCertificate(expiration time.Time) ([]byte, []byte, error)
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
 		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
 		BasicConstraintsValid: true,
-		SignatureAlgorithm:    x509.SHA256WithRSA,
-		PublicKeyAlgorithm:    x509.RSA,
 		SubjectKeyId:          subjectKeyId[:],
 	}
 
-	newCertificate, err := x509.CreateCertificate(rand.Reader, newCertificateTemplate, newCertificateTemplate, &newCertificatePrivateKey.PublicKey, newCertificatePrivateKey)
+	newCertificate, err := x509.CreateCertificate(rand.Reader, newCertificateTemplate, newCertificateTemplate, newCertificatePrivateKey.Public(), newCertificatePrivateKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -69,39 +107,34 @@ func GenerateSelfSignedCertificate(expiration time.Time) ([]byte, []byte, error)
 		return nil, nil, err
 	}
 
-	newCertificatePrivateKeyPem := new(bytes.Buffer)
-	if err = pem.Encode(newCertificatePrivateKeyPem, &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(newCertificatePrivateKey),
-	}); err != nil {
+	newCertificatePrivateKeyPem, err := encodePrivateKey(newCertificatePrivateKey)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	return newCertificatePem.Bytes(), newCertificatePrivateKeyPem.Bytes(), nil
+	return newCertificatePem.Bytes(), newCertificatePrivateKeyPem, nil
 }
 
 func GenerateSignedCertificate(expiration time.Time, sourceCertificate, sourcePrivateKey []byte) ([]byte, []byte, error) {
-	newCertificatePrivateKey, err := rsa.GenerateKey(rand.Reader, RsaKeyBits())
+	newCertificatePrivateKey, err := generatePrivateKey()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	pubKeyBytes, err := x509.MarshalPKIXPublicKey(&newCertificatePrivateKey.PublicKey)
+	pubKeyBytes, err := x509.MarshalPKIXPublicKey(newCertificatePrivateKey.Public())
 	if err != nil {
 		return nil, nil, err
 	}
 	subjectKeyId := sha256.Sum256(pubKeyBytes)
 
 	newCertificateTemplate := &x509.Certificate{
-		SerialNumber:       getRandomInt(),
-		DNSNames:           getDns(),
-		NotBefore:          time.Now().UTC(),
-		NotAfter:           expiration,
-		ExtKeyUsage:        []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		KeyUsage:           x509.KeyUsageDigitalSignature,
-		SignatureAlgorithm: x509.SHA256WithRSA,
-		PublicKeyAlgorithm: x509.RSA,
-		SubjectKeyId:       subjectKeyId[:],
+		SerialNumber: getRandomInt(),
+		DNSNames:     getDns(),
+		NotBefore:    time.Now().UTC(),
+		NotAfter:     expiration,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		SubjectKeyId: subjectKeyId[:],
 	}
 
 	decodedSourceCertificate, err := DecodeCertificate(sourceCertificate)
@@ -112,16 +145,12 @@ func GenerateSignedCertificate(expiration time.Time, sourceCertificate, sourcePr
 	if err != nil {
 		return nil, nil, err
 	}
-	decodedSourcePrivateKey, err := DecodeCertificate(sourcePrivateKey)
-	if err != nil {
-		return nil, nil, err
-	}
-	parsedSourcePrivateKey, err := x509.ParsePKCS1PrivateKey(decodedSourcePrivateKey.Bytes)
+	parsedSourcePrivateKey, err := ParsePrivateKey(sourcePrivateKey)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	newCertificate, err := x509.CreateCertificate(rand.Reader, newCertificateTemplate, parsedSourceCertificate, &newCertificatePrivateKey.PublicKey, parsedSourcePrivateKey)
+	newCertificate, err := x509.CreateCertificate(rand.Reader, newCertificateTemplate, parsedSourceCertificate, newCertificatePrivateKey.Public(), parsedSourcePrivateKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -134,15 +163,116 @@ func GenerateSignedCertificate(expiration time.Time, sourceCertificate, sourcePr
 		return nil, nil, err
 	}
 
-	newCertificatePrivateKeyPem := new(bytes.Buffer)
-	if err := pem.Encode(newCertificatePrivateKeyPem, &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(newCertificatePrivateKey),
-	}); err != nil {
+	newCertificatePrivateKeyPem, err := encodePrivateKey(newCertificatePrivateKey)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	return newCertificatePem.Bytes(), newCertificatePrivateKeyPem.Bytes(), nil
+	return newCertificatePem.Bytes(), newCertificatePrivateKeyPem, nil
+}
+
+func generatePrivateKey() (crypto.Signer, error) {
+	switch KeyAlgorithm() {
+	case EcdsaP256KeyAlgorithm:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EcdsaP384KeyAlgorithm:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case Ed25519KeyAlgorithm:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return rsa.GenerateKey(rand.Reader, RsaKeyBits())
+	}
+}
+
+// encodePrivateKey PEM encodes RSA keys in PKCS #1 and ECDSA keys in SEC 1 form, as cert-manager does,
+// and Ed25519 keys in PKCS #8 form, which is the only one defined for them.
+func encodePrivateKey(privateKey crypto.Signer) ([]byte, error) {
+	var block *pem.Block
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+
+	privateKeyPem := new(bytes.Buffer)
+	if err := pem.Encode(privateKeyPem, block); err != nil {
+		return nil, err
+	}
+	return privateKeyPem.Bytes(), nil
+}
+
+// ParsePrivateKey parses a PEM encoded RSA, ECDSA or Ed25519 private key in PKCS #1, SEC 1 or PKCS #8 form.
+func ParsePrivateKey(privateKey []byte) (crypto.Signer, error) {
+	decoded, err := DecodeCertificate(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	switch decoded.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(decoded.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(decoded.Bytes)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(decoded.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+	return signer, nil
+}
+
+// VerifyKeyPair checks that the PEM encoded private key belongs to the PEM encoded certificate.
+func VerifyKeyPair(certificate, privateKey []byte) error {
+	decodedCertificate, err := DecodeCertificate(certificate)
+	if err != nil {
+		return fmt.Errorf("certificate: %w", err)
+	}
+	parsedCertificate, err := x509.ParseCertificate(decodedCertificate.Bytes)
+	if err != nil {
+		return fmt.Errorf("certificate: %w", err)
+	}
+	parsedPrivateKey, err := ParsePrivateKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("private key: %w", err)
+	}
+	publicKey, ok := parsedCertificate.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(parsedPrivateKey.Public()) {
+		return fmt.Errorf("private key does not match the certificate")
+	}
+	return nil
+}
+
+// CertificateKeyAlgorithm returns the key algorithm of the certificate in the form accepted by SetKeyAlgorithm.
+func CertificateKeyAlgorithm(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return RsaKeyAlgorithm
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return EcdsaP256KeyAlgorithm
+		case elliptic.P384():
+			return EcdsaP384KeyAlgorithm
+		}
+	case ed25519.PublicKey:
+		return Ed25519KeyAlgorithm
+	}
+	return cert.PublicKeyAlgorithm.String()
 }
 
 func VerifyIfLeafIsSignedByGivenCA(caCertificate, leafCertificate []byte) (bool, error) {
@@ -189,6 +319,11 @@ func VerifyIfLeafIsSignedByGivenCA(caCertificate, leafCertificate []byte) (bool,
 	return true, nil
 }
 
+// WebhookDnsNames returns the DNS names of the sap-btp-operator webhook Service covered by the webhook certificate.
+func WebhookDnsNames() []string {
+	return getDns()
+}
+
 func getDns() []string {
 	return []string{"sap-btp-operator-webhook-service.kyma-system.svc", "sap-btp-operator-webhook-service.kyma-system"}
 }
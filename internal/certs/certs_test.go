@@ -3,12 +3,103 @@ package certs
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func TestGenerateCertificates(t *testing.T) {
+	originalBits, originalAlgorithm := RsaKeyBits(), KeyAlgorithm()
+	t.Cleanup(func() {
+		SetRsaKeyBits(originalBits)
+		require.NoError(t, SetKeyAlgorithm(originalAlgorithm))
+	})
+	SetRsaKeyBits(1024)
+
+	for _, tc := range []struct {
+		algorithm          string
+		publicKeyAlgorithm x509.PublicKeyAlgorithm
+	}{
+		{algorithm: RsaKeyAlgorithm, publicKeyAlgorithm: x509.RSA},
+		{algorithm: EcdsaP256KeyAlgorithm, publicKeyAlgorithm: x509.ECDSA},
+		{algorithm: EcdsaP384KeyAlgorithm, publicKeyAlgorithm: x509.ECDSA},
+		{algorithm: Ed25519KeyAlgorithm, publicKeyAlgorithm: x509.Ed25519},
+	} {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			require.NoError(t, SetKeyAlgorithm(tc.algorithm))
+			expiration := time.Now().UTC().Add(time.Hour)
+
+			caCert, caKey, err := GenerateSelfSignedCertificate(expiration)
+			require.NoError(t, err)
+			leafCert, leafKey, err := GenerateSignedCertificate(expiration, caCert, caKey)
+			require.NoError(t, err)
+
+			assert.NoError(t, VerifyKeyPair(caCert, caKey))
+			assert.NoError(t, VerifyKeyPair(leafCert, leafKey))
+			assert.Error(t, VerifyKeyPair(leafCert, caKey))
+
+			ok, err := VerifyIfLeafIsSignedByGivenCA(caCert, leafCert)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			decoded, err := DecodeCertificate(leafCert)
+			require.NoError(t, err)
+			parsed, err := x509.ParseCertificate(decoded.Bytes)
+			require.NoError(t, err)
+			assert.Equal(t, tc.publicKeyAlgorithm, parsed.PublicKeyAlgorithm)
+			assert.Equal(t, tc.algorithm, CertificateKeyAlgorithm(parsed))
+		})
+	}
+}
+
+func TestGenerateSignedCertificateWithCaOfOtherAlgorithm(t *testing.T) {
+	originalAlgorithm := KeyAlgorithm()
+	t.Cleanup(func() {
+		require.NoError(t, SetKeyAlgorithm(originalAlgorithm))
+	})
+	expiration := time.Now().UTC().Add(time.Hour)
+
+	require.NoError(t, SetKeyAlgorithm(Ed25519KeyAlgorithm))
+	caCert, caKey, err := GenerateSelfSignedCertificate(expiration)
+	require.NoError(t, err)
+
+	require.NoError(t, SetKeyAlgorithm(EcdsaP384KeyAlgorithm))
+	leafCert, _, err := GenerateSignedCertificate(expiration, caCert, caKey)
+	require.NoError(t, err)
+
+	ok, err := VerifyIfLeafIsSignedByGivenCA(caCert, leafCert)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestSetKeyAlgorithm(t *testing.T) {
+	originalAlgorithm := KeyAlgorithm()
+	t.Cleanup(func() {
+		require.NoError(t, SetKeyAlgorithm(originalAlgorithm))
+	})
+
+	assert.Error(t, SetKeyAlgorithm("dsa"))
+	assert.Equal(t, originalAlgorithm, KeyAlgorithm())
+	assert.NoError(t, SetKeyAlgorithm(EcdsaP256KeyAlgorithm))
+	assert.Equal(t, EcdsaP256KeyAlgorithm, KeyAlgorithm())
+}
+
+func TestParsePrivateKeyPKCS8(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	parsed, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	require.NoError(t, err)
+	assert.True(t, key.Equal(parsed))
+}
+
 func BenchmarkGenerateKey(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		_, err := rsa.GenerateKey(rand.Reader, RsaKeyBits())
@@ -46,6 +46,29 @@ const (
 
 const (
 	ReadyType = "Ready"
+
+	// CertificatesValidType reports whether the certificates regenerated by BTP Manager are outside ExpirationBoundary.
+	// It is set independently of the Ready condition and does not change the state.
+	CertificatesValidType = "CertificatesValid"
+	// WebhooksReachableType reports whether the sap-btp-operator webhooks answer a dry-run AdmissionReview over TLS verified with the published caBundle.
+	WebhooksReachableType = "WebhooksReachable"
+	// CaBundleTrustedType reports the result of the last CA bundle probe run, that is, whether the token URL is trusted over TLS.
+	CaBundleTrustedType = "CaBundleTrusted"
+	// OperandExportedType reports whether the ServiceInstances and ServiceBindings were exported before the deprovisioning.
+	// A failed export doesn't block the deletion.
+	OperandExportedType = "OperandExported"
+)
+
+const (
+	CertificatesValid              Reason = "CertificatesValid"
+	CertificateRegenerationFailing Reason = "CertificateRegenerationFailing"
+	WebhooksReachable              Reason = "WebhooksReachable"
+	WebhookUnreachable             Reason = "WebhookUnreachable"
+	CaBundleTrusted                Reason = "CaBundleTrusted"
+	CaBundleNotTrusted             Reason = "CaBundleNotTrusted"
+	CaBundleProbeFailed            Reason = "CaBundleProbeFailed"
+	OperandExported                Reason = "OperandExported"
+	OperandExportFailed            Reason = "OperandExportFailed"
 )
 
 type Metadata struct {
@@ -78,7 +78,7 @@ func NewDetector(k8sClient client.Client, apiServerClient client.Client) *DriftD
 var _ Detector = (*DriftDetector)(nil)
 
 func (d *DriftDetector) InitializeFromSecret(s *corev1.Secret) {
-	credentialsNamespace := config.ChartNamespace
+	credentialsNamespace := config.Current().ChartNamespace
 	if s != nil {
 		if v, ok := s.Data[credentialsNamespaceSecretKey]; ok && len(v) > 0 {
 			credentialsNamespace = string(v)
@@ -130,7 +130,7 @@ func (d *DriftDetector) CheckCredentialsNamespaceDrift(ctx context.Context, requ
 	if defaultCredentialsSecret != nil {
 		d.credentialsNamespaceFromSapBtpServiceOperatorSecret = defaultCredentialsSecret.Namespace
 		if d.credentialsNamespaceFromSapBtpManagerSecret != d.credentialsNamespaceFromSapBtpServiceOperatorSecret {
-			logger.Info(fmt.Sprintf("credentials namespaces between %s secret and %s secret don't match", config.SecretName, SapBtpServiceOperatorSecretName))
+			logger.Info(fmt.Sprintf("credentials namespaces between %s secret and %s secret don't match", config.Current().SecretName, SapBtpServiceOperatorSecretName))
 			if err := d.annotateSecret(ctx, requiredSecret, previousCredentialsNamespaceAnnotationKey, d.credentialsNamespaceFromSapBtpServiceOperatorSecret); err != nil {
 				return conditions.NewErrorWithReason(conditions.AnnotatingSecretFailed, err.Error())
 			}
@@ -153,7 +153,7 @@ func (d *DriftDetector) CheckClusterIdConfigMapDrift(ctx context.Context, requir
 		d.clusterIdFromSapBtpServiceOperatorConfigMap = sapBtpOperatorConfigMap.Data[strings.ToUpper(clusterIdSecretKey)]
 		d.clusterIdFromSapBtpServiceOperatorClusterIdSecret = d.clusterIdFromSapBtpServiceOperatorConfigMap
 		if d.clusterIdFromSapBtpManagerSecret != d.clusterIdFromSapBtpServiceOperatorConfigMap {
-			logger.Info(fmt.Sprintf("cluster IDs between %s secret and %s configmap don't match", config.SecretName, SapBtpServiceOperatorConfigMapName))
+			logger.Info(fmt.Sprintf("cluster IDs between %s secret and %s configmap don't match", config.Current().SecretName, SapBtpServiceOperatorConfigMapName))
 			if err := d.annotateSecret(ctx, requiredSecret, previousClusterIdAnnotationKey, d.clusterIdFromSapBtpServiceOperatorConfigMap); err != nil {
 				return conditions.NewErrorWithReason(conditions.AnnotatingSecretFailed, err.Error())
 			}
@@ -282,7 +282,7 @@ func (d *DriftDetector) GetDefaultCredentialsSecret(ctx context.Context) (*corev
 
 func (d *DriftDetector) GetSapBtpServiceOperatorConfigMap(ctx context.Context) (*corev1.ConfigMap, error) {
 	cm := &corev1.ConfigMap{}
-	if err := d.client.Get(ctx, client.ObjectKey{Namespace: config.ChartNamespace, Name: SapBtpServiceOperatorConfigMapName}, cm); err != nil {
+	if err := d.client.Get(ctx, client.ObjectKey{Namespace: config.Current().ChartNamespace, Name: SapBtpServiceOperatorConfigMapName}, cm); err != nil {
 		if k8serrors.IsNotFound(err) {
 			return nil, nil
 		}
@@ -325,7 +325,7 @@ func (d *DriftDetector) getSapBtpServiceOperatorPod(ctx context.Context) (*corev
 		return nil, nil
 	}
 	for i, p := range pods.Items {
-		if strings.HasPrefix(p.Name, operandName) && p.Namespace == config.ChartNamespace {
+		if strings.HasPrefix(p.Name, operandName) && p.Namespace == config.Current().ChartNamespace {
 			pod = &pods.Items[i]
 			break
 		}
@@ -0,0 +1,102 @@
+package deprovisioning
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const confirmationTokenLength = 16
+
+// confirmForceDelete reports whether the force delete of the existing ServiceInstances and ServiceBindings is confirmed
+// with the ForceDeleteConfirmationAnnotation. When it isn't, the confirmation token is published in the CR status and the deletion is blocked.
+func (h *handler) confirmForceDelete(ctx context.Context, cr *v1alpha1.BtpOperator) (bool, error) {
+	if !config.Current().ForceDeleteConfirmationRequired {
+		return true, nil
+	}
+	instances, err := h.listOperandResources(ctx, instanceGvk)
+	if err != nil {
+		return false, err
+	}
+	bindings, err := h.listOperandResources(ctx, bindingGvk)
+	if err != nil {
+		return false, err
+	}
+	if len(instances) == 0 && len(bindings) == 0 {
+		return true, nil
+	}
+
+	token := confirmationToken(cr, instances, bindings)
+	if isConfirmed(cr.GetAnnotations()[v1alpha1.ForceDeleteConfirmationAnnotation], token, len(instances), len(bindings)) {
+		return true, nil
+	}
+
+	if cr.Status.ForceDeleteConfirmationToken != token {
+		if err := h.updateStatus(ctx, cr, func(status *v1alpha1.Status) {
+			status.ForceDeleteConfirmationToken = token
+		}); err != nil {
+			return false, err
+		}
+	}
+	msg := fmt.Sprintf("Force delete of %d instance(s) and %d binding(s) must be confirmed: set the %s annotation to %q or to the status.forceDeleteConfirmationToken value",
+		len(instances), len(bindings), v1alpha1.ForceDeleteConfirmationAnnotation, confirmationCounts(len(instances), len(bindings)))
+	log.FromContext(ctx).Info(msg)
+	return false, h.setNotCleaned(ctx, cr, msg)
+}
+
+// confirmationToken identifies the CR and the ServiceInstances and ServiceBindings to be deleted, so that a confirmation
+// copied from another cluster, or given before the resources changed, doesn't match.
+func confirmationToken(cr *v1alpha1.BtpOperator, instances, bindings []unstructured.Unstructured) string {
+	resources := make([]string, 0, len(instances)+len(bindings))
+	for _, item := range append(instances, bindings...) {
+		resources = append(resources, fmt.Sprintf("%s/%s/%s/%s", item.GetKind(), item.GetNamespace(), item.GetName(), item.GetUID()))
+	}
+	sort.Strings(resources)
+
+	hash := sha256.New()
+	hash.Write([]byte(cr.GetUID()))
+	for _, resource := range resources {
+		hash.Write([]byte("\n" + resource))
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:confirmationTokenLength]
+}
+
+func confirmationCounts(instances, bindings int) string {
+	return fmt.Sprintf("instances=%d,bindings=%d", instances, bindings)
+}
+
+// isConfirmed reports whether the confirmation is the token or the exact counts, in any order and with optional spaces.
+func isConfirmed(confirmation, token string, instances, bindings int) bool {
+	confirmation = strings.TrimSpace(confirmation)
+	if confirmation == "" {
+		return false
+	}
+	if confirmation == token {
+		return true
+	}
+
+	counts := make(map[string]int)
+	for _, pair := range strings.Split(confirmation, ",") {
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			return false
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return false
+		}
+		counts[strings.TrimSpace(key)] = count
+	}
+	instancesCount, hasInstances := counts["instances"]
+	bindingsCount, hasBindings := counts["bindings"]
+	return len(counts) == 2 && hasInstances && hasBindings && instancesCount == instances && bindingsCount == bindings
+}
@@ -0,0 +1,171 @@
+package deprovisioning
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestIsConfirmed(t *testing.T) {
+	for confirmation, want := range map[string]bool{
+		"":                           false,
+		"instances=2,bindings=1":     true,
+		" bindings=1, instances=2":   true,
+		"instances=2,bindings=0":     false,
+		"instances=2":                false,
+		"instances=2,bindings=1,x=0": false,
+		"0123456789abcdef":           true,
+		"0123456789abcde0":           false,
+	} {
+		if got := isConfirmed(confirmation, "0123456789abcdef", 2, 1); got != want {
+			t.Errorf("%q: expected %t, got %t", confirmation, want, got)
+		}
+	}
+}
+
+func TestHandleDeprovisioning_ForceDeleteWithoutConfirmation(t *testing.T) {
+	cr := forceDeletedCr()
+	instance := operandResource(instanceGvk, "ns-a", "instance-1")
+	h := newTestHandler(t, cr, instance, &testNamespaces().Items[0])
+	updater := h.statusUpdater.(*fakeStatusUpdater)
+	ctx := context.Background()
+
+	if err := h.handleDeprovisioning(ctx, cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(updater.reasons) != 1 || updater.reasons[0] != conditions.ServiceInstancesAndBindingsNotCleaned {
+		t.Fatalf("expected the deletion to be blocked, got %v", updater.reasons)
+	}
+	if !strings.Contains(updater.messages[0], `"instances=1,bindings=0"`) {
+		t.Fatalf("expected the counts in the message, got %q", updater.messages[0])
+	}
+	latest := persistedCr(t, h)
+	if len(latest.Status.ForceDeleteConfirmationToken) != confirmationTokenLength || latest.Status.Deprovisioning != nil {
+		t.Fatalf("expected only the published token, got %+v", latest.Status)
+	}
+	if err := h.client.Get(ctx, client.ObjectKeyFromObject(instance), operandResource(instanceGvk, "", "")); err != nil {
+		t.Fatalf("expected the instance to exist, got %v", err)
+	}
+}
+
+func TestHandleDeprovisioning_ForceDeleteConfirmedWithPublishedToken(t *testing.T) {
+	cr := forceDeletedCr()
+	instance := operandResource(instanceGvk, "ns-a", "instance-1")
+	h := newTestHandler(t, cr, instance, &testNamespaces().Items[0])
+	ctx := context.Background()
+	if err := h.handleDeprovisioning(ctx, cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cr = persistedCr(t, h)
+	cr.Annotations = map[string]string{v1alpha1.ForceDeleteConfirmationAnnotation: cr.Status.ForceDeleteConfirmationToken}
+	if err := h.handleDeprovisioning(ctx, cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if status := persistedCr(t, h).Status.Deprovisioning; status == nil || status.Phase != v1alpha1.DeprovisioningPhaseCompleted {
+		t.Fatalf("expected the completed deprovisioning, got %+v", status)
+	}
+	if err := h.client.Get(ctx, client.ObjectKeyFromObject(instance), operandResource(instanceGvk, "", "")); !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the instance to be deleted, got %v", err)
+	}
+}
+
+func TestHandleDeprovisioning_ConfirmationOutdatedByNewResources(t *testing.T) {
+	cr := forceDeletedCr()
+	h := newTestHandler(t, cr, operandResource(instanceGvk, "ns-a", "instance-1"), &testNamespaces().Items[0])
+	ctx := context.Background()
+	if err := h.handleDeprovisioning(ctx, cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cr = persistedCr(t, h)
+	token := cr.Status.ForceDeleteConfirmationToken
+
+	if err := h.client.Create(ctx, operandResource(instanceGvk, "ns-a", "instance-2")); err != nil {
+		t.Fatal(err)
+	}
+	cr.Annotations = map[string]string{v1alpha1.ForceDeleteConfirmationAnnotation: token}
+	if err := h.handleDeprovisioning(ctx, cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	latest := persistedCr(t, h)
+	if latest.Status.Deprovisioning != nil || latest.Status.ForceDeleteConfirmationToken == token {
+		t.Fatalf("expected a new token and no deprovisioning, got %+v", latest.Status)
+	}
+}
+
+func TestHandleDeprovisioning_ConfirmationNotRequired(t *testing.T) {
+	origRequired := config.ForceDeleteConfirmationRequired
+	t.Cleanup(func() { config.ForceDeleteConfirmationRequired = origRequired })
+	config.ForceDeleteConfirmationRequired = false
+
+	cr := forceDeletedCr()
+	h := newTestHandler(t, cr, operandResource(instanceGvk, "ns-a", "instance-1"), &testNamespaces().Items[0])
+
+	if err := h.handleDeprovisioning(context.Background(), cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if status := persistedCr(t, h).Status.Deprovisioning; status == nil || status.Phase != v1alpha1.DeprovisioningPhaseCompleted {
+		t.Fatalf("expected the completed deprovisioning, got %+v", status)
+	}
+}
+
+func TestHandleDeprovisioning_ExportFailureDoesNotBlock(t *testing.T) {
+	origRequired := config.ForceDeleteConfirmationRequired
+	t.Cleanup(func() { config.ForceDeleteConfirmationRequired = origRequired })
+	config.ForceDeleteConfirmationRequired = false
+
+	cr := forceDeletedCr()
+	h := newTestHandler(t, cr, operandResource(instanceGvk, "ns-a", "instance-1"), &testNamespaces().Items[0])
+	exporter := h.operandExporter.(*fakeOperandExporter)
+	exporter.err = errors.New("secret too large")
+
+	if err := h.handleDeprovisioning(context.Background(), cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	latest := persistedCr(t, h)
+	if status := latest.Status.Deprovisioning; status == nil || status.Phase != v1alpha1.DeprovisioningPhaseCompleted {
+		t.Fatalf("expected the completed deprovisioning, got %+v", status)
+	}
+	var exported *metav1.Condition
+	for _, c := range latest.Status.Conditions {
+		if c.Type == conditions.OperandExportedType {
+			exported = c
+		}
+	}
+	if exported == nil || exported.Status != metav1.ConditionFalse || exported.Reason != string(conditions.OperandExportFailed) ||
+		!strings.Contains(exported.Message, "secret too large") {
+		t.Fatalf("expected the failed export in the OperandExported condition, got %+v", exported)
+	}
+	if exporter.exports != 1 {
+		t.Fatalf("expected a single export attempt, got %d", exporter.exports)
+	}
+}
+
+func forceDeletedCr() *v1alpha1.BtpOperator {
+	cr := deprovisionedCr(nil)
+	cr.Labels = map[string]string{forceDeleteLabelKey: "true"}
+	cr.UID = "btp-operator-uid"
+	return cr
+}
+
+func persistedCr(t *testing.T, h *handler) *v1alpha1.BtpOperator {
+	t.Helper()
+	cr := &v1alpha1.BtpOperator{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: config.BtpOperatorCrName, Namespace: config.KymaSystemNamespaceName}, cr); err != nil {
+		t.Fatal(err)
+	}
+	return cr
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/kyma-project/btp-manager/api/v1alpha1"
 	"github.com/kyma-project/btp-manager/controllers/config"
@@ -13,6 +12,7 @@ import (
 	"github.com/kyma-project/btp-manager/internal/credentials/drift"
 	"github.com/kyma-project/btp-manager/internal/k8s/networkpolicy"
 	"github.com/kyma-project/btp-manager/internal/manager/moduleresource"
+	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -75,9 +75,15 @@ type InstanceBindingService interface {
 	DisableSISBController()
 }
 
+// OperandExporter exports ServiceInstances and ServiceBindings before they are deleted.
+type OperandExporter interface {
+	Export(ctx context.Context, cr *v1alpha1.BtpOperator) error
+}
+
 // Handler runs the deprovisioning flow.
 type Handler interface {
 	Deprovision(ctx context.Context, cr *v1alpha1.BtpOperator) error
+	WritePreview(ctx context.Context, cr *v1alpha1.BtpOperator) error
 }
 
 type handler struct {
@@ -89,6 +95,8 @@ type handler struct {
 	driftDetector          drift.Detector
 	moduleResourceManager  moduleresource.ResourceManager
 	networkPolicyManager   networkpolicy.NetworkPolicyManager
+	operandExporter        OperandExporter
+	metrics                DeprovisioningMetrics
 }
 
 func NewHandler(
@@ -100,6 +108,8 @@ func NewHandler(
 	driftDetector drift.Detector,
 	moduleResourceManager moduleresource.ResourceManager,
 	networkPolicyManager networkpolicy.NetworkPolicyManager,
+	operandExporter OperandExporter,
+	metrics DeprovisioningMetrics,
 ) Handler {
 	return &handler{
 		client:                 c,
@@ -110,6 +120,8 @@ func NewHandler(
 		driftDetector:          driftDetector,
 		moduleResourceManager:  moduleResourceManager,
 		networkPolicyManager:   networkPolicyManager,
+		operandExporter:        operandExporter,
+		metrics:                metrics,
 	}
 }
 
@@ -117,10 +129,11 @@ var _ Handler = (*handler)(nil)
 
 func (h *handler) Deprovision(ctx context.Context, cr *v1alpha1.BtpOperator) error {
 	logger := log.FromContext(ctx)
+	cfg := config.Current()
 
-	requiredSecret, err := h.getSecretByNameAndNamespace(ctx, config.SecretName, config.ChartNamespace)
+	requiredSecret, err := h.getSecretByNameAndNamespace(ctx, cfg.SecretName, cfg.ChartNamespace)
 	if err != nil {
-		logger.Error(err, fmt.Sprintf("while getting %s secret in %s namespace", config.SecretName, config.ChartNamespace))
+		logger.Error(err, fmt.Sprintf("while getting %s secret in %s namespace", cfg.SecretName, cfg.ChartNamespace))
 		return fmt.Errorf("failed to get the required secret: %w", err)
 	}
 
@@ -175,16 +188,16 @@ func (h *handler) handleDeprovisioning(ctx context.Context, cr *v1alpha1.BtpOper
 	}
 
 	if !IsForceDelete(cr) {
-		numberOfBindings, err := h.numberOfResources(ctx, bindingGvk)
-		if err != nil {
-			return err
-		}
-		numberOfInstances, err := h.numberOfResources(ctx, instanceGvk)
+		remaining, err := h.remainingResources(ctx)
 		if err != nil {
 			return err
 		}
+		numberOfInstances, numberOfBindings := countByKind(remaining)
 
 		if numberOfBindings > 0 || numberOfInstances > 0 {
+			if err := h.reportRemainingResources(ctx, cr, remaining); err != nil {
+				logger.Error(err, "while reporting the remaining resources")
+			}
 			logger.Info(fmt.Sprintf("Existing resources (%d instances and %d bindings) block BTP Operator deletion.", numberOfInstances, numberOfBindings))
 			msg := fmt.Sprintf("All service instances and bindings must be removed: %d instance(s) and %d binding(s)", numberOfInstances, numberOfBindings)
 			logger.Info(msg)
@@ -199,6 +212,23 @@ func (h *handler) handleDeprovisioning(ctx context.Context, cr *v1alpha1.BtpOper
 			}
 			return nil
 		}
+	} else if cr.Status.Deprovisioning == nil || cr.Status.Deprovisioning.Phase == v1alpha1.DeprovisioningPhaseHardDeleting {
+		blocked, err := h.blockRetainedResources(ctx, cr)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return nil
+		}
+		if cr.Status.Deprovisioning == nil {
+			confirmed, err := h.confirmForceDelete(ctx, cr)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return nil
+			}
+		}
 	}
 	if cr.IsReasonStringEqual(string(conditions.ServiceInstancesAndBindingsNotCleaned)) {
 		if updateStatusErr := h.statusUpdater.UpdateBtpOperatorStatus(ctx, cr,
@@ -208,123 +238,33 @@ func (h *handler) handleDeprovisioning(ctx context.Context, cr *v1alpha1.BtpOper
 		}
 	}
 
-	hardDeleteSucceededCh := make(chan bool, 1)
-	hardDeleteTimeoutReachedCh := make(chan bool, 1)
-	defer close(hardDeleteTimeoutReachedCh)
-
-	go h.handleHardDelete(ctx, namespaces, hardDeleteSucceededCh, hardDeleteTimeoutReachedCh)
-
-	select {
-	case hardDeleteSucceeded := <-hardDeleteSucceededCh:
-		if hardDeleteSucceeded {
-			logger.Info("Service Instances and Service Bindings hard delete succeeded. Removing module resources")
-			if err := h.deleteBtpOperatorResources(ctx); err != nil {
-				logger.Error(err, "failed to remove module resources")
-				if updateStatusErr := h.statusUpdater.UpdateBtpOperatorStatus(ctx, cr, v1alpha1.StateError, conditions.ResourceRemovalFailed, "Unable to remove installed resources"); updateStatusErr != nil {
-					logger.Error(updateStatusErr, "failed to update status")
-					return updateStatusErr
-				}
-				return err
-			}
-		} else {
-			logger.Info("Service Instances and Service Bindings hard delete failed")
-			if err := h.statusUpdater.UpdateBtpOperatorStatus(ctx, cr, v1alpha1.StateDeleting, conditions.SoftDeleting, "Being soft deleted"); err != nil {
-				logger.Error(err, "failed to update status")
-				return err
-			}
-			if err := h.handleSoftDelete(ctx, namespaces); err != nil {
-				logger.Error(err, "failed to soft delete")
-				return err
-			}
-		}
-	case <-time.After(config.HardDeleteTimeout):
-		logger.Info("hard delete timeout reached", "duration", config.HardDeleteTimeout)
-		hardDeleteTimeoutReachedCh <- true
-		if err := h.statusUpdater.UpdateBtpOperatorStatus(ctx, cr, v1alpha1.StateDeleting, conditions.SoftDeleting, "Being soft deleted"); err != nil {
-			logger.Error(err, "failed to update status")
-			return err
-		}
-		if err := h.handleSoftDelete(ctx, namespaces); err != nil {
-			logger.Error(err, "failed to soft delete")
-			return err
-		}
+	if cr.Status.Deprovisioning == nil {
+		h.exportOperands(ctx, cr)
 	}
 
-	return nil
+	return h.runPhases(ctx, cr, namespaces)
 }
 
-func (h *handler) handleHardDelete(ctx context.Context, namespaces *corev1.NamespaceList, hardDeleteSucceededCh, hardDeleteTimeoutReachedCh chan bool) {
+// exportOperands exports the ServiceInstances and ServiceBindings before the first deprovisioning phase.
+// The export is a best-effort backup, so a failure doesn't block the deletion. It is reported in the OperandExported condition instead.
+func (h *handler) exportOperands(ctx context.Context, cr *v1alpha1.BtpOperator) {
 	logger := log.FromContext(ctx)
-	logger.Info("Deprovisioning BTP Operator - hard delete")
-	defer close(hardDeleteSucceededCh)
-
-	errs := make([]error, 0)
-
-	sbCrdExists, err := h.crdExists(ctx, bindingGvk)
-	if err != nil {
-		logger.Error(err, "while checking CRD existence", "GVK", bindingGvk.String())
-		errs = append(errs, err)
-	}
-	if sbCrdExists {
-		if err := h.hardDelete(ctx, bindingGvk, namespaces); err != nil {
-			logger.Error(err, "while deleting Service Bindings")
-			if !isDeadlineExceeded(err) {
-				errs = append(errs, err)
-			}
-		}
-	}
-
-	siCrdExists, err := h.crdExists(ctx, instanceGvk)
-	if err != nil {
-		logger.Error(err, "while checking CRD existence", "GVK", instanceGvk.String())
-		errs = append(errs, err)
-	}
-	if siCrdExists {
-		if err := h.hardDelete(ctx, instanceGvk, namespaces); err != nil {
-			logger.Error(err, "while deleting Service Instances")
-			if !isDeadlineExceeded(err) {
-				errs = append(errs, err)
-			}
-		}
-	}
-
-	if len(errs) > 0 {
-		hardDeleteSucceededCh <- false
-		return
-	}
-
-	var sbResourcesLeft, siResourcesLeft bool
-	for {
-		select {
-		case <-hardDeleteTimeoutReachedCh:
-			return
-		default:
-		}
-
-		if sbCrdExists {
-			sbResourcesLeft, err = h.resourcesExist(ctx, namespaces, bindingGvk)
-			if err != nil {
-				logger.Error(err, "ServiceBinding leftover resources check failed")
-				hardDeleteSucceededCh <- false
-				return
-			}
-		}
-
-		if siCrdExists {
-			siResourcesLeft, err = h.resourcesExist(ctx, namespaces, instanceGvk)
-			if err != nil {
-				logger.Error(err, "ServiceInstance leftover resources check failed")
-				hardDeleteSucceededCh <- false
-				return
-			}
-		}
-
-		if !sbResourcesLeft && !siResourcesLeft {
-			hardDeleteSucceededCh <- true
-			return
-		}
-
-		time.Sleep(config.HardDeleteCheckInterval)
+	condition := metav1.Condition{
+		Type:    conditions.OperandExportedType,
+		Status:  metav1.ConditionTrue,
+		Reason:  string(conditions.OperandExported),
+		Message: "Service instances and bindings exported",
+	}
+	if err := h.operandExporter.Export(ctx, cr); err != nil {
+		logger.Error(err, "while exporting service instances and bindings, deprovisioning continues without the export")
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = string(conditions.OperandExportFailed)
+		condition.Message = fmt.Sprintf("Service instances and bindings were not exported and can't be restored: %s", err)
+	}
+	if err := h.updateStatus(ctx, cr, func(status *v1alpha1.Status) {
+		conditions.SetStatusCondition(&status.Conditions, condition)
+	}); err != nil {
+		logger.Error(err, "while setting the OperandExported condition")
 	}
 }
 
@@ -341,46 +281,6 @@ func (h *handler) crdExists(ctx context.Context, gvk schema.GroupVersionKind) (b
 	return true, nil
 }
 
-func (h *handler) hardDelete(ctx context.Context, gvk schema.GroupVersionKind, namespaces *corev1.NamespaceList) error {
-	object := &unstructured.Unstructured{}
-	object.SetGroupVersionKind(gvk)
-	deleteCtx, cancel := context.WithTimeout(ctx, config.DeleteRequestTimeout)
-	defer cancel()
-
-	for _, namespace := range namespaces.Items {
-		if err := h.client.DeleteAllOf(deleteCtx, object, client.InNamespace(namespace.Name)); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func (h *handler) resourcesExist(ctx context.Context, namespaces *corev1.NamespaceList, gvk schema.GroupVersionKind) (bool, error) {
-	anyLeft := func(namespace string, gvk schema.GroupVersionKind) (bool, error) {
-		list := &unstructured.UnstructuredList{}
-		list.SetGroupVersionKind(gvk)
-		if err := h.client.List(ctx, list, client.InNamespace(namespace)); err != nil {
-			if !k8serrors.IsNotFound(err) {
-				return false, err
-			}
-		}
-		return len(list.Items) > 0, nil
-	}
-
-	for _, namespace := range namespaces.Items {
-		resourcesExist, err := anyLeft(namespace.Name, gvk)
-		if err != nil {
-			return false, err
-		}
-		if resourcesExist {
-			return true, nil
-		}
-	}
-
-	return false, nil
-}
-
 func (h *handler) numberOfResources(ctx context.Context, gvk schema.GroupVersionKind) (int, error) {
 	exists, err := h.crdExists(ctx, gvk)
 	if err != nil {
@@ -403,23 +303,10 @@ func (h *handler) deleteBtpOperatorResources(ctx context.Context) error {
 	logger := log.FromContext(ctx)
 
 	logger.Info("getting module resources to delete")
-	resourcesToDeleteFromApply, err := h.moduleResourceManager.CreateUnstructuredObjectsFromManifestsDir(h.moduleResourceManager.GetResourcesToApplyPath())
+	resourcesToDelete, err := h.moduleResourceTypes(ctx)
 	if err != nil {
-		logger.Error(err, "while getting objects to delete from manifests")
-		return fmt.Errorf("failed to create deletable objects from manifests: %w", err)
-	}
-	logger.Info(fmt.Sprintf("got %d module resources to delete from \"apply\" dir", len(resourcesToDeleteFromApply)))
-
-	resourcesToDeleteFromDelete, err := h.moduleResourceManager.CreateUnstructuredObjectsFromManifestsDir(h.moduleResourceManager.GetResourcesToDeletePath())
-	if err != nil {
-		logger.Error(err, "while getting objects to delete from manifests")
-		return fmt.Errorf("failed to create deletable objects from manifests: %w", err)
+		return err
 	}
-	logger.Info(fmt.Sprintf("got %d module resources to delete from \"delete\" dir", len(resourcesToDeleteFromDelete)))
-
-	resourcesToDelete := make([]*unstructured.Unstructured, 0)
-	resourcesToDelete = append(resourcesToDelete, resourcesToDeleteFromApply...)
-	resourcesToDelete = append(resourcesToDelete, resourcesToDeleteFromDelete...)
 
 	if err = h.deleteAllOfResourcesTypes(ctx, resourcesToDelete...); err != nil {
 		logger.Error(err, "while deleting module resources")
@@ -439,16 +326,42 @@ func (h *handler) deleteBtpOperatorResources(ctx context.Context) error {
 	return nil
 }
 
+// moduleResourceTypes returns objects of every module resource type, with the cert-manager types first.
+func (h *handler) moduleResourceTypes(ctx context.Context) ([]*unstructured.Unstructured, error) {
+	logger := log.FromContext(ctx)
+
+	resourcesFromApply, err := h.moduleResourceManager.CreateUnstructuredObjectsFromManifestsDir(h.moduleResourceManager.GetResourcesToApplyPath())
+	if err != nil {
+		logger.Error(err, "while getting objects to delete from manifests")
+		return nil, fmt.Errorf("failed to create deletable objects from manifests: %w", err)
+	}
+	logger.Info(fmt.Sprintf("got %d module resources to delete from \"apply\" dir", len(resourcesFromApply)))
+
+	resourcesFromDelete, err := h.moduleResourceManager.CreateUnstructuredObjectsFromManifestsDir(h.moduleResourceManager.GetResourcesToDeletePath())
+	if err != nil {
+		logger.Error(err, "while getting objects to delete from manifests")
+		return nil, fmt.Errorf("failed to create deletable objects from manifests: %w", err)
+	}
+	logger.Info(fmt.Sprintf("got %d module resources to delete from \"delete\" dir", len(resourcesFromDelete)))
+
+	resourceTypes := make([]*unstructured.Unstructured, 0)
+	resourceTypes = append(resourceTypes, certificate.CertManagerResourceTypes()...)
+	resourceTypes = append(resourceTypes, resourcesFromApply...)
+	resourceTypes = append(resourceTypes, resourcesFromDelete...)
+	return resourceTypes, nil
+}
+
 func (h *handler) deleteAllOfResourcesTypes(ctx context.Context, resourcesToDelete ...*unstructured.Unstructured) error {
 	logger := log.FromContext(ctx)
+	cfg := config.Current()
 	deletedGvks := make(map[string]struct{}, 0)
 	for _, u := range resourcesToDelete {
 		if _, exists := deletedGvks[u.GroupVersionKind().String()]; exists {
 			continue
 		}
 		logger.Info(fmt.Sprintf("deleting all of %s/%s module resources in %s namespace",
-			u.GroupVersionKind().GroupVersion(), u.GetKind(), config.ChartNamespace))
-		if err := h.client.DeleteAllOf(ctx, u, client.InNamespace(config.ChartNamespace), managedByLabelFilter); err != nil {
+			u.GroupVersionKind().GroupVersion(), u.GetKind(), cfg.ChartNamespace))
+		if err := h.client.DeleteAllOf(ctx, u, client.InNamespace(cfg.ChartNamespace), managedByLabelFilter); err != nil {
 			if !(k8serrors.IsNotFound(err) || k8serrors.IsMethodNotSupported(err) || meta.IsNoMatchError(err)) {
 				return err
 			}
@@ -515,8 +428,9 @@ func (h *handler) handleSoftDelete(ctx context.Context, namespaces *corev1.Names
 }
 
 func (h *handler) preSoftDeleteCleanup(ctx context.Context) error {
+	cfg := config.Current()
 	toDelete := []client.Object{
-		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: config.DeploymentName, Namespace: config.ChartNamespace}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: cfg.DeploymentName, Namespace: cfg.ChartNamespace}},
 		&admissionregistrationv1.MutatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: mutatingWebhookName}},
 		&admissionregistrationv1.ValidatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: validatingWebhookName}},
 	}
@@ -547,8 +461,10 @@ func (h *handler) softDelete(ctx context.Context, gvk schema.GroupVersionKind) e
 				return err
 			}
 		}
+		// patch instead of update, the Delete above changes the resource version of resources not deleted before
+		original := item.DeepCopy()
 		item.SetFinalizers([]string{})
-		if err := h.client.Update(ctx, &item); err != nil {
+		if err := h.client.Patch(ctx, &item, client.MergeFrom(original)); err != nil {
 			return err
 		}
 
@@ -0,0 +1,96 @@
+package deprovisioning
+
+import (
+	"context"
+	"time"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// hardDelete deletes the resources of the given kind in the namespaces that have any, config.HardDeleteConcurrency namespaces in parallel,
+// with at most config.HardDeleteQPS delete requests per second. Namespaces with resources with the orphan or retain deletion policy
+// are deleted resource by resource, skipping them; other namespaces with a single DeleteAllOf request.
+func (h *handler) hardDelete(ctx context.Context, gvk schema.GroupVersionKind, namespaces *corev1.NamespaceList) error {
+	logger := log.FromContext(ctx)
+	cfg := config.Current()
+	deleteCtx, cancel := context.WithTimeout(ctx, cfg.DeleteRequestTimeout)
+	defer cancel()
+
+	items, err := h.listOperandResources(deleteCtx, gvk)
+	if err != nil {
+		return err
+	}
+	deletableByNamespace := make(map[string][]unstructured.Unstructured)
+	protectedNamespaces := make(map[string]struct{})
+	for _, item := range items {
+		if deletionPolicyOf(&item) == DeletionPolicyDelete {
+			deletableByNamespace[item.GetNamespace()] = append(deletableByNamespace[item.GetNamespace()], item)
+		} else {
+			protectedNamespaces[item.GetNamespace()] = struct{}{}
+		}
+	}
+
+	limiter := hardDeleteRateLimiter()
+	group, groupCtx := errgroup.WithContext(deleteCtx)
+	group.SetLimit(max(cfg.HardDeleteConcurrency, 1))
+	for _, namespace := range namespaces.Items {
+		deletable := deletableByNamespace[namespace.Name]
+		if len(deletable) == 0 {
+			continue
+		}
+		_, protected := protectedNamespaces[namespace.Name]
+		group.Go(func() error {
+			start := time.Now()
+			if err := h.hardDeleteNamespace(groupCtx, limiter, gvk, namespace.Name, deletable, protected); err != nil {
+				return err
+			}
+			h.metrics.ObserveNamespaceHardDelete(gvk.Kind, time.Since(start))
+			h.metrics.AddHardDeletedResources(gvk.Kind, len(deletable))
+			logger.Info("hard delete requested", "kind", gvk.Kind, "namespace", namespace.Name, "resources", len(deletable), "duration", time.Since(start))
+			return nil
+		})
+	}
+
+	return group.Wait()
+}
+
+func (h *handler) hardDeleteNamespace(ctx context.Context, limiter *rate.Limiter, gvk schema.GroupVersionKind, namespace string, deletable []unstructured.Unstructured, protected bool) error {
+	if !protected {
+		object := &unstructured.Unstructured{}
+		object.SetGroupVersionKind(gvk)
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		err := h.client.DeleteAllOf(ctx, object, client.InNamespace(namespace))
+		h.metrics.ObserveHardDeleteRequest(gvk.Kind, err)
+		return err
+	}
+
+	for i := range deletable {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		err := client.IgnoreNotFound(h.client.Delete(ctx, &deletable[i]))
+		h.metrics.ObserveHardDeleteRequest(gvk.Kind, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hardDeleteRateLimiter allows config.HardDeleteQPS requests per second, or any number of requests when it isn't positive.
+func hardDeleteRateLimiter() *rate.Limiter {
+	cfg := config.Current()
+	if cfg.HardDeleteQPS <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(cfg.HardDeleteQPS), cfg.HardDeleteQPS)
+}
@@ -0,0 +1,153 @@
+package deprovisioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestHardDelete_DeletesNamespacesInParallel(t *testing.T) {
+	setHardDeleteLimits(t, 4, 0)
+	objs, namespaces := namespacesWithInstances(30)
+	objs = append(objs, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "empty"}})
+	namespaces.Items = append(namespaces.Items, corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "empty"}})
+	h := newTestHandler(t, objs...)
+	metrics := &fakeDeprovisioningMetrics{}
+	h.metrics = metrics
+	ctx := context.Background()
+
+	if err := h.hardDelete(ctx, instanceGvk, namespaces); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if remaining, err := h.deletableResourcesByNamespace(ctx, instanceGvk); err != nil || len(remaining) != 0 {
+		t.Fatalf("expected all instances to be deleted, got %v, %v", remaining, err)
+	}
+	if metrics.requests != 30 || metrics.deleted != 30 || metrics.namespaces != 30 {
+		t.Fatalf("expected 30 requests for 30 namespaces, got %+v", metrics)
+	}
+}
+
+func TestHardDelete_RateLimitsRequests(t *testing.T) {
+	setHardDeleteLimits(t, 10, 10)
+	objs, namespaces := namespacesWithInstances(15)
+	h := newTestHandler(t, objs...)
+
+	start := time.Now()
+	if err := h.hardDelete(context.Background(), instanceGvk, namespaces); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the burst of 10 requests is sent at once, the remaining 5 at 10 requests per second
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected the requests to be rate limited, took %s", elapsed)
+	}
+}
+
+func TestHardDelete_ReturnsRequestError(t *testing.T) {
+	setHardDeleteLimits(t, 4, 0)
+	objs, namespaces := namespacesWithInstances(5)
+	h := newTestHandler(t, objs...)
+	metrics := &fakeDeprovisioningMetrics{}
+	h.metrics = metrics
+	h.client = &failingDeleteAllOfClient{Client: h.client, namespace: "ns-3"}
+
+	err := h.hardDelete(context.Background(), instanceGvk, namespaces)
+
+	if err == nil || err.Error() != "delete failed" {
+		t.Fatalf("expected the delete error, got %v", err)
+	}
+	if metrics.failedRequests != 1 {
+		t.Fatalf("expected 1 failed request, got %+v", metrics)
+	}
+}
+
+func setHardDeleteLimits(t *testing.T, concurrency, qps int) {
+	origConcurrency, origQPS := config.HardDeleteConcurrency, config.HardDeleteQPS
+	t.Cleanup(func() { config.HardDeleteConcurrency, config.HardDeleteQPS = origConcurrency, origQPS })
+	config.HardDeleteConcurrency, config.HardDeleteQPS = concurrency, qps
+}
+
+func namespacesWithInstances(n int) ([]client.Object, *corev1.NamespaceList) {
+	objs := make([]client.Object, 0, 2*n)
+	namespaces := &corev1.NamespaceList{}
+	for i := 0; i < n; i++ {
+		namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("ns-%d", i)}}
+		namespaces.Items = append(namespaces.Items, namespace)
+		objs = append(objs, &namespace, operandResource(instanceGvk, namespace.Name, "instance"))
+	}
+	return objs, namespaces
+}
+
+// fakeDeprovisioningMetrics is a test double for DeprovisioningMetrics recording the observations.
+type fakeDeprovisioningMetrics struct {
+	mu             sync.Mutex
+	requests       int
+	failedRequests int
+	deleted        int
+	namespaces     int
+	remaining      map[string]int
+	phases         []string
+	fallbacks      []string
+}
+
+func (f *fakeDeprovisioningMetrics) ObserveHardDeleteRequest(_ string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err != nil {
+		f.failedRequests++
+		return
+	}
+	f.requests++
+}
+
+func (f *fakeDeprovisioningMetrics) AddHardDeletedResources(_ string, count int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted += count
+}
+
+func (f *fakeDeprovisioningMetrics) ObserveNamespaceHardDelete(string, time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.namespaces++
+}
+
+func (f *fakeDeprovisioningMetrics) ResetRemainingResources() {
+	f.remaining = map[string]int{}
+}
+
+func (f *fakeDeprovisioningMetrics) SetRemainingResources(kind, state string, count int) {
+	f.remaining[kind+"/"+state] = count
+}
+
+func (f *fakeDeprovisioningMetrics) ObservePhaseDuration(phase string, _ time.Duration) {
+	f.phases = append(f.phases, phase)
+}
+
+func (f *fakeDeprovisioningMetrics) IncrementSoftDeleteFallbacks(reason string) {
+	f.fallbacks = append(f.fallbacks, reason)
+}
+
+// failingDeleteAllOfClient fails DeleteAllOf requests in the namespace.
+type failingDeleteAllOfClient struct {
+	client.Client
+	namespace string
+}
+
+func (c *failingDeleteAllOfClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	deleteOpts := &client.DeleteAllOfOptions{}
+	deleteOpts.ApplyOptions(opts)
+	if deleteOpts.Namespace == c.namespace {
+		return errors.New("delete failed")
+	}
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
@@ -0,0 +1,263 @@
+package deprovisioning
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// runPhases runs the deprovisioning phases persisted in the CR status, starting from the current one.
+// The hard delete timeout is counted from the persisted phase start time, so a restarted manager continues with the time left.
+func (h *handler) runPhases(ctx context.Context, cr *v1alpha1.BtpOperator, namespaces *corev1.NamespaceList) error {
+	logger := log.FromContext(ctx)
+
+	if cr.Status.Deprovisioning == nil || cr.Status.Deprovisioning.Phase == "" {
+		if err := h.enterPhase(ctx, cr, v1alpha1.DeprovisioningPhaseHardDeleting); err != nil {
+			return err
+		}
+	} else {
+		logger.Info("resuming deprovisioning", "phase", cr.Status.Deprovisioning.Phase, "phaseStartTime", cr.Status.Deprovisioning.PhaseStartTime)
+	}
+
+	for {
+		switch phase := cr.Status.Deprovisioning.Phase; phase {
+		case v1alpha1.DeprovisioningPhaseHardDeleting:
+			next := h.runHardDeletePhase(ctx, cr, namespaces)
+			if next == v1alpha1.DeprovisioningPhaseSoftDeleting {
+				// The soft delete removes all resources left from the cluster. Resources annotated with the retain deletion policy
+				// after the hard delete started block the deletion in the HardDeleting phase instead, which resumes once they're gone.
+				blocked, err := h.blockRetainedResources(ctx, cr)
+				if err != nil {
+					return err
+				}
+				if blocked {
+					return nil
+				}
+			}
+			if err := h.reportRemainingResources(ctx, cr, nil); err != nil {
+				logger.Error(err, "while clearing the remaining resources")
+			}
+			if next == v1alpha1.DeprovisioningPhaseSoftDeleting {
+				if err := h.statusUpdater.UpdateBtpOperatorStatus(ctx, cr, v1alpha1.StateDeleting, conditions.SoftDeleting, "Being soft deleted"); err != nil {
+					logger.Error(err, "failed to update status")
+					return err
+				}
+			}
+			if err := h.enterPhase(ctx, cr, next); err != nil {
+				return err
+			}
+		case v1alpha1.DeprovisioningPhaseSoftDeleting:
+			if err := h.handleSoftDelete(ctx, namespaces); err != nil {
+				logger.Error(err, "failed to soft delete")
+				return err
+			}
+			if err := h.enterPhase(ctx, cr, v1alpha1.DeprovisioningPhaseCompleted); err != nil {
+				return err
+			}
+		case v1alpha1.DeprovisioningPhaseDeletingModuleResources:
+			logger.Info("Removing module resources")
+			if err := h.deleteBtpOperatorResources(ctx); err != nil {
+				logger.Error(err, "failed to remove module resources")
+				if updateStatusErr := h.statusUpdater.UpdateBtpOperatorStatus(ctx, cr, v1alpha1.StateError, conditions.ResourceRemovalFailed, "Unable to remove installed resources"); updateStatusErr != nil {
+					logger.Error(updateStatusErr, "failed to update status")
+					return updateStatusErr
+				}
+				return err
+			}
+			if err := h.enterPhase(ctx, cr, v1alpha1.DeprovisioningPhaseCompleted); err != nil {
+				return err
+			}
+		case v1alpha1.DeprovisioningPhaseCompleted:
+			return nil
+		default:
+			return fmt.Errorf("unknown deprovisioning phase %q", phase)
+		}
+	}
+}
+
+// runHardDeletePhase deletes ServiceBindings and ServiceInstances in all namespaces and waits until they are gone.
+// It returns the next phase: DeletingModuleResources on success, SoftDeleting on error or when the hard delete timeout is reached.
+func (h *handler) runHardDeletePhase(ctx context.Context, cr *v1alpha1.BtpOperator, namespaces *corev1.NamespaceList) v1alpha1.DeprovisioningPhase {
+	logger := log.FromContext(ctx)
+	cfg := config.Current()
+	logger.Info("Deprovisioning BTP Operator - hard delete")
+
+	deadline := cr.Status.Deprovisioning.PhaseStartTime.Add(cfg.HardDeleteTimeout)
+	phaseCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	errs := make([]error, 0)
+	for _, gvk := range []schema.GroupVersionKind{bindingGvk, instanceGvk} {
+		crdExists, err := h.crdExists(phaseCtx, gvk)
+		if err != nil {
+			logger.Error(err, "while checking CRD existence", "GVK", gvk.String())
+			errs = append(errs, err)
+			continue
+		}
+		if !crdExists {
+			continue
+		}
+		if err := h.hardDelete(phaseCtx, gvk, namespaces); err != nil {
+			logger.Error(err, fmt.Sprintf("while deleting %ss", gvk.Kind))
+			if !isDeadlineExceeded(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		logger.Info("Service Instances and Service Bindings hard delete failed")
+		h.metrics.IncrementSoftDeleteFallbacks(softDeleteFallbackError)
+		return v1alpha1.DeprovisioningPhaseSoftDeleting
+	}
+
+	for {
+		bindings, bindingsErr := h.deletableResourcesByNamespace(phaseCtx, bindingGvk)
+		if bindingsErr != nil && !isDeadlineExceeded(bindingsErr) {
+			logger.Error(bindingsErr, "ServiceBinding leftover resources check failed")
+			h.metrics.IncrementSoftDeleteFallbacks(softDeleteFallbackError)
+			return v1alpha1.DeprovisioningPhaseSoftDeleting
+		}
+		instances, instancesErr := h.deletableResourcesByNamespace(phaseCtx, instanceGvk)
+		if instancesErr != nil && !isDeadlineExceeded(instancesErr) {
+			logger.Error(instancesErr, "ServiceInstance leftover resources check failed")
+			h.metrics.IncrementSoftDeleteFallbacks(softDeleteFallbackError)
+			return v1alpha1.DeprovisioningPhaseSoftDeleting
+		}
+		if bindingsErr == nil && instancesErr == nil {
+			numberOfBindings, numberOfInstances := sum(bindings), sum(instances)
+			logHardDeleteProgress(ctx, instances, bindings)
+			if remaining, err := h.remainingResources(phaseCtx); err == nil {
+				if err := h.reportRemainingResources(ctx, cr, remaining); err != nil {
+					logger.Error(err, "while reporting the remaining resources")
+				}
+			}
+			if err := h.setRemainingResources(ctx, cr, numberOfInstances, numberOfBindings); err != nil {
+				logger.Error(err, "while updating the remaining resources in status")
+			}
+			if numberOfInstances == 0 && numberOfBindings == 0 {
+				logger.Info("Service Instances and Service Bindings hard delete succeeded")
+				return h.phaseAfterHardDelete(ctx)
+			}
+		}
+
+		select {
+		case <-phaseCtx.Done():
+			logger.Info("hard delete timeout reached", "duration", cfg.HardDeleteTimeout)
+			h.metrics.IncrementSoftDeleteFallbacks(softDeleteFallbackTimeout)
+			return v1alpha1.DeprovisioningPhaseSoftDeleting
+		case <-time.After(cfg.HardDeleteCheckInterval):
+		}
+	}
+}
+
+// logHardDeleteProgress logs the namespaces with resources left, with the number of ServiceInstances and ServiceBindings in each.
+func logHardDeleteProgress(ctx context.Context, instances, bindings map[string]int) {
+	pending := make(map[string]string)
+	for namespace, count := range instances {
+		pending[namespace] = fmt.Sprintf("%d instance(s), %d binding(s)", count, bindings[namespace])
+	}
+	for namespace, count := range bindings {
+		if _, exists := instances[namespace]; !exists {
+			pending[namespace] = fmt.Sprintf("0 instance(s), %d binding(s)", count)
+		}
+	}
+	if len(pending) > 0 {
+		log.FromContext(ctx).Info("waiting for the hard delete", "pendingNamespaces", len(pending), "namespaces", pending)
+	}
+}
+
+func sum(counts map[string]int) int {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
+
+// phaseAfterHardDelete returns SoftDeleting when resources with the orphan or retain deletion policy are left, so that their finalizers
+// are removed after the SAP BTP service operator is deleted and the SAP BTP resources are kept.
+// Retained resources are left only when the annotation was set after the hard delete started. runPhases blocks the deletion on them.
+func (h *handler) phaseAfterHardDelete(ctx context.Context) v1alpha1.DeprovisioningPhase {
+	logger := log.FromContext(ctx)
+	for _, policy := range []DeletionPolicy{DeletionPolicyOrphan, DeletionPolicyRetain} {
+		protected, err := h.resourcesWithPolicy(ctx, policy)
+		if err != nil {
+			logger.Error(err, "while listing resources with the deletion policy", "policy", policy)
+			return v1alpha1.DeprovisioningPhaseSoftDeleting
+		}
+		if len(protected) > 0 {
+			logger.Info("orphaning resources with the deletion policy", "policy", policy, "resources", protected)
+			return v1alpha1.DeprovisioningPhaseSoftDeleting
+		}
+	}
+	return v1alpha1.DeprovisioningPhaseDeletingModuleResources
+}
+
+// enterPhase persists the phase and its start time in the CR status.
+func (h *handler) enterPhase(ctx context.Context, cr *v1alpha1.BtpOperator, phase v1alpha1.DeprovisioningPhase) error {
+	log.FromContext(ctx).Info("entering deprovisioning phase", "phase", phase)
+	now := metav1.Now()
+	h.observePhaseEnd(cr, now.Time)
+	return h.updateDeprovisioningStatus(ctx, cr, func(status *v1alpha1.DeprovisioningStatus) {
+		if status.StartTime.IsZero() {
+			status.StartTime = now
+		}
+		status.Phase = phase
+		status.PhaseStartTime = now
+		if phase == v1alpha1.DeprovisioningPhaseCompleted {
+			status.RemainingServiceInstances, status.RemainingServiceBindings = 0, 0
+		}
+	})
+}
+
+func (h *handler) setRemainingResources(ctx context.Context, cr *v1alpha1.BtpOperator, instances, bindings int) error {
+	if cr.Status.Deprovisioning.RemainingServiceInstances == instances && cr.Status.Deprovisioning.RemainingServiceBindings == bindings {
+		return nil
+	}
+	return h.updateDeprovisioningStatus(ctx, cr, func(status *v1alpha1.DeprovisioningStatus) {
+		status.RemainingServiceInstances, status.RemainingServiceBindings = instances, bindings
+	})
+}
+
+// updateDeprovisioningStatus applies the change to the deprovisioning status of the latest CR.
+func (h *handler) updateDeprovisioningStatus(ctx context.Context, cr *v1alpha1.BtpOperator, change func(status *v1alpha1.DeprovisioningStatus)) error {
+	return h.updateStatus(ctx, cr, func(status *v1alpha1.Status) {
+		if status.Deprovisioning == nil {
+			status.Deprovisioning = &v1alpha1.DeprovisioningStatus{}
+		}
+		change(status.Deprovisioning)
+	})
+}
+
+// updateStatus applies the change to the status of the latest CR and retries on conflicts until config.StatusUpdateTimeout.
+func (h *handler) updateStatus(ctx context.Context, cr *v1alpha1.BtpOperator, change func(status *v1alpha1.Status)) error {
+	cfg := config.Current()
+	timeout := time.Now().Add(cfg.StatusUpdateTimeout)
+	var err error
+	for now := time.Now(); now.Before(timeout); now = time.Now() {
+		latest := &v1alpha1.BtpOperator{}
+		if err = h.client.Get(ctx, client.ObjectKeyFromObject(cr), latest); err != nil {
+			return fmt.Errorf("while getting BtpOperator CR: %w", err)
+		}
+		change(&latest.Status)
+		if err = h.client.Status().Update(ctx, latest); err == nil {
+			latest.DeepCopyInto(cr)
+			return nil
+		}
+		if !k8serrors.IsConflict(err) {
+			break
+		}
+		time.Sleep(cfg.StatusUpdateCheckInterval)
+	}
+	return fmt.Errorf("while updating BtpOperator status: %w", err)
+}
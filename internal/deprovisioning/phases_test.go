@@ -0,0 +1,122 @@
+package deprovisioning
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRunPhases_HardDeleteFromStart(t *testing.T) {
+	cr := deprovisionedCr(nil)
+	h := newTestHandler(t, cr, operandResource(instanceGvk, "ns-a", "instance-1"))
+	ctx := context.Background()
+
+	if err := h.runPhases(ctx, cr, testNamespaces()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status := persistedDeprovisioningStatus(t, h)
+	if status.Phase != v1alpha1.DeprovisioningPhaseCompleted || status.StartTime.IsZero() {
+		t.Fatalf("expected the completed deprovisioning, got %+v", status)
+	}
+	if reasons := h.statusUpdater.(*fakeStatusUpdater).reasons; len(reasons) != 0 {
+		t.Fatalf("expected no soft delete, got %v", reasons)
+	}
+	if err := h.client.Get(ctx, client.ObjectKey{Name: "instance-1", Namespace: "ns-a"}, operandResource(instanceGvk, "", "")); !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the instance to be deleted, got %v", err)
+	}
+}
+
+func TestRunPhases_ResumesHardDeleteWithTheTimeLeft(t *testing.T) {
+	origTimeout := config.HardDeleteTimeout
+	t.Cleanup(func() { config.HardDeleteTimeout = origTimeout })
+	config.HardDeleteTimeout = time.Minute
+
+	startTime := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	cr := deprovisionedCr(&v1alpha1.DeprovisioningStatus{
+		Phase: v1alpha1.DeprovisioningPhaseHardDeleting, StartTime: startTime, PhaseStartTime: startTime, RemainingServiceInstances: 1,
+	})
+	instance := operandResource(instanceGvk, "ns-a", "instance-1")
+	instance.SetFinalizers([]string{"services.cloud.sap.com/sap-btp-finalizer"})
+	h := newTestHandler(t, cr, instance)
+	ctx := context.Background()
+
+	if err := h.runPhases(ctx, cr, testNamespaces()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if reasons := h.statusUpdater.(*fakeStatusUpdater).reasons; len(reasons) != 1 || reasons[0] != conditions.SoftDeleting {
+		t.Fatalf("expected the soft delete after the persisted timeout, got %v", reasons)
+	}
+	status := persistedDeprovisioningStatus(t, h)
+	if status.Phase != v1alpha1.DeprovisioningPhaseCompleted || !status.StartTime.Equal(&startTime) {
+		t.Fatalf("expected the completed deprovisioning started at %s, got %+v", startTime, status)
+	}
+	if err := h.client.Get(ctx, client.ObjectKeyFromObject(instance), operandResource(instanceGvk, "", "")); !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the instance to be soft deleted, got %v", err)
+	}
+}
+
+func TestRunPhases_ResumesAfterServiceInstancesAndBindings(t *testing.T) {
+	cr := deprovisionedCr(&v1alpha1.DeprovisioningStatus{Phase: v1alpha1.DeprovisioningPhaseDeletingModuleResources, PhaseStartTime: metav1.Now()})
+	h := newTestHandler(t, cr,
+		operandResource(instanceGvk, "ns-a", "instance-1"),
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "sap-btp-operator-config", Namespace: testNamespace, Labels: map[string]string{managedByLabelKey: operatorName}}},
+	)
+	ctx := context.Background()
+
+	if err := h.runPhases(ctx, cr, testNamespaces()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := h.client.Get(ctx, client.ObjectKey{Name: "instance-1", Namespace: "ns-a"}, operandResource(instanceGvk, "", "")); err != nil {
+		t.Fatalf("expected the instance not to be touched, got %v", err)
+	}
+	err := h.client.Get(ctx, client.ObjectKey{Name: "sap-btp-operator-config", Namespace: testNamespace}, &corev1.ConfigMap{})
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the module resources to be deleted, got %v", err)
+	}
+	if status := persistedDeprovisioningStatus(t, h); status.Phase != v1alpha1.DeprovisioningPhaseCompleted {
+		t.Fatalf("expected the completed deprovisioning, got %+v", status)
+	}
+}
+
+func TestRunPhases_UnknownPhase(t *testing.T) {
+	cr := deprovisionedCr(&v1alpha1.DeprovisioningStatus{Phase: "Unknown"})
+	h := newTestHandler(t, cr)
+
+	if err := h.runPhases(context.Background(), cr, testNamespaces()); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func deprovisionedCr(status *v1alpha1.DeprovisioningStatus) *v1alpha1.BtpOperator {
+	return &v1alpha1.BtpOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: config.BtpOperatorCrName, Namespace: config.KymaSystemNamespaceName},
+		Status:     v1alpha1.Status{State: v1alpha1.StateDeleting, Deprovisioning: status},
+	}
+}
+
+func persistedDeprovisioningStatus(t *testing.T, h *handler) *v1alpha1.DeprovisioningStatus {
+	t.Helper()
+	cr := &v1alpha1.BtpOperator{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: config.BtpOperatorCrName, Namespace: config.KymaSystemNamespaceName}, cr); err != nil {
+		t.Fatal(err)
+	}
+	if cr.Status.Deprovisioning == nil {
+		t.Fatal("expected the deprovisioning status")
+	}
+	return cr.Status.Deprovisioning
+}
+
+func testNamespaces() *corev1.NamespaceList {
+	return &corev1.NamespaceList{Items: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}}}}
+}
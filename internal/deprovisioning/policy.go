@@ -0,0 +1,104 @@
+package deprovisioning
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DeletionPolicyAnnotation sets how a ServiceInstance or ServiceBinding is handled when the BtpOperator CR is force deleted.
+const DeletionPolicyAnnotation = operatorLabelPrefix + "deletion-policy"
+
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the resource together with the SAP BTP resource. This is the default.
+	DeletionPolicyDelete DeletionPolicy = "delete"
+	// DeletionPolicyOrphan removes the resource from the cluster and keeps the SAP BTP resource.
+	DeletionPolicyOrphan DeletionPolicy = "orphan"
+	// DeletionPolicyRetain blocks the deletion of the BtpOperator CR as long as the resource exists.
+	DeletionPolicyRetain DeletionPolicy = "retain"
+)
+
+// deletionPolicyOf returns the deletion policy of the resource. An unknown value is treated as retain, so a mistyped policy never destroys a resource.
+func deletionPolicyOf(u *unstructured.Unstructured) DeletionPolicy {
+	value, exists := u.GetAnnotations()[DeletionPolicyAnnotation]
+	if !exists || value == "" {
+		return DeletionPolicyDelete
+	}
+	switch policy := DeletionPolicy(strings.ToLower(value)); policy {
+	case DeletionPolicyDelete, DeletionPolicyOrphan, DeletionPolicyRetain:
+		return policy
+	default:
+		return DeletionPolicyRetain
+	}
+}
+
+// resourcesWithPolicy lists ServiceBindings and ServiceInstances in all namespaces with the given deletion policy, as "Kind namespace/name".
+func (h *handler) resourcesWithPolicy(ctx context.Context, policy DeletionPolicy) ([]string, error) {
+	names := make([]string, 0)
+	for _, gvk := range []schema.GroupVersionKind{bindingGvk, instanceGvk} {
+		items, err := h.listOperandResources(ctx, gvk)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			if deletionPolicyOf(&items[i]) == policy {
+				names = append(names, fmt.Sprintf("%s %s/%s", gvk.Kind, items[i].GetNamespace(), items[i].GetName()))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// blockRetainedResources sets the Warning state when force delete would destroy ServiceInstances or ServiceBindings with the retain deletion policy.
+// It reports whether the deletion is blocked.
+func (h *handler) blockRetainedResources(ctx context.Context, cr *v1alpha1.BtpOperator) (bool, error) {
+	retained, err := h.resourcesWithPolicy(ctx, DeletionPolicyRetain)
+	if err != nil {
+		return false, err
+	}
+	if len(retained) == 0 {
+		return false, nil
+	}
+
+	msg := fmt.Sprintf("Service instances and bindings with the %s annotation set to %q must be removed, or the annotation changed: %s",
+		DeletionPolicyAnnotation, DeletionPolicyRetain, strings.Join(retained, ", "))
+	log.FromContext(ctx).Info(msg)
+	return true, h.setNotCleaned(ctx, cr, msg)
+}
+
+func (h *handler) setNotCleaned(ctx context.Context, cr *v1alpha1.BtpOperator, msg string) error {
+	if remaining, err := h.remainingResources(ctx); err != nil {
+		log.FromContext(ctx).Error(err, "while listing the remaining resources")
+	} else if err := h.reportRemainingResources(ctx, cr, remaining); err != nil {
+		log.FromContext(ctx).Error(err, "while reporting the remaining resources")
+	}
+	if cr.IsMsgForGivenReasonEqual(string(conditions.ServiceInstancesAndBindingsNotCleaned), msg) && cr.Status.State == v1alpha1.StateWarning {
+		return nil
+	}
+	return h.statusUpdater.UpdateBtpOperatorStatus(ctx, cr, v1alpha1.StateWarning, conditions.ServiceInstancesAndBindingsNotCleaned, msg)
+}
+
+// deletableResourcesByNamespace counts the resources of the given kind with the delete deletion policy in each namespace.
+func (h *handler) deletableResourcesByNamespace(ctx context.Context, gvk schema.GroupVersionKind) (map[string]int, error) {
+	items, err := h.listOperandResources(ctx, gvk)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for i := range items {
+		if deletionPolicyOf(&items[i]) == DeletionPolicyDelete {
+			counts[items[i].GetNamespace()]++
+		}
+	}
+	return counts, nil
+}
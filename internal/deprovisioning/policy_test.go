@@ -0,0 +1,149 @@
+package deprovisioning
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDeletionPolicyOf(t *testing.T) {
+	for value, want := range map[string]DeletionPolicy{
+		"":        DeletionPolicyDelete,
+		"delete":  DeletionPolicyDelete,
+		"Orphan":  DeletionPolicyOrphan,
+		"retain":  DeletionPolicyRetain,
+		"destroy": DeletionPolicyRetain,
+	} {
+		u := operandResource(instanceGvk, "ns-a", "instance")
+		if value != "" {
+			u.SetAnnotations(map[string]string{DeletionPolicyAnnotation: value})
+		}
+		if got := deletionPolicyOf(u); got != want {
+			t.Errorf("%q: expected %s, got %s", value, want, got)
+		}
+	}
+}
+
+func TestHardDelete_SkipsProtectedResources(t *testing.T) {
+	orphaned := withDeletionPolicy(operandResource(instanceGvk, "ns-a", "orphaned"), DeletionPolicyOrphan)
+	orphaned.SetFinalizers([]string{"services.cloud.sap.com/sap-btp-finalizer"})
+	h := newTestHandler(t, orphaned, operandResource(instanceGvk, "ns-a", "deleted"))
+	ctx := context.Background()
+
+	if err := h.hardDelete(ctx, instanceGvk, testNamespaces()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := h.client.Get(ctx, client.ObjectKey{Name: "deleted", Namespace: "ns-a"}, operandResource(instanceGvk, "", "")); !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the instance to be deleted, got %v", err)
+	}
+	got := operandResource(instanceGvk, "", "")
+	if err := h.client.Get(ctx, client.ObjectKeyFromObject(orphaned), got); err != nil || got.GetDeletionTimestamp() != nil {
+		t.Fatalf("expected the orphaned instance not to be deleted, got %v", err)
+	}
+}
+
+func TestRunPhases_OrphansResourcesAfterHardDelete(t *testing.T) {
+	cr := deprovisionedCr(nil)
+	orphaned := withDeletionPolicy(operandResource(instanceGvk, "ns-a", "orphaned"), DeletionPolicyOrphan)
+	orphaned.SetFinalizers([]string{"services.cloud.sap.com/sap-btp-finalizer"})
+	h := newTestHandler(t, cr, orphaned)
+	ctx := context.Background()
+
+	if err := h.runPhases(ctx, cr, testNamespaces()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if reasons := h.statusUpdater.(*fakeStatusUpdater).reasons; len(reasons) != 1 || reasons[0] != conditions.SoftDeleting {
+		t.Fatalf("expected the orphaned instance to be soft deleted, got %v", reasons)
+	}
+	if err := h.client.Get(ctx, client.ObjectKeyFromObject(orphaned), operandResource(instanceGvk, "", "")); !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the orphaned instance to be removed from the cluster, got %v", err)
+	}
+}
+
+func TestRunPhases_BlocksOnResourcesRetainedAfterHardDeleteStarted(t *testing.T) {
+	cr := deprovisionedCr(nil)
+	retained := withDeletionPolicy(operandResource(instanceGvk, "ns-a", "production-db"), DeletionPolicyRetain)
+	retained.SetFinalizers([]string{"services.cloud.sap.com/sap-btp-finalizer"})
+	h := newTestHandler(t, cr, retained)
+	updater := h.statusUpdater.(*fakeStatusUpdater)
+	ctx := context.Background()
+
+	if err := h.runPhases(ctx, cr, testNamespaces()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(updater.reasons) != 1 || updater.reasons[0] != conditions.ServiceInstancesAndBindingsNotCleaned {
+		t.Fatalf("expected the deletion to be blocked, got %v", updater.reasons)
+	}
+	if status := persistedDeprovisioningStatus(t, h); status.Phase != v1alpha1.DeprovisioningPhaseHardDeleting {
+		t.Fatalf("expected the deprovisioning to stay in the HardDeleting phase, got %s", status.Phase)
+	}
+	got := operandResource(instanceGvk, "", "")
+	if err := h.client.Get(ctx, client.ObjectKeyFromObject(retained), got); err != nil || len(got.GetFinalizers()) == 0 {
+		t.Fatalf("expected the retained instance to keep its finalizers, got %v", err)
+	}
+}
+
+func TestHandleDeprovisioning_RetainedResourcesBlockForceDelete(t *testing.T) {
+	cr := deprovisionedCr(nil)
+	cr.Labels = map[string]string{forceDeleteLabelKey: "true"}
+	retained := withDeletionPolicy(operandResource(instanceGvk, "ns-a", "production-db"), DeletionPolicyRetain)
+	h := newTestHandler(t, cr, retained)
+	updater := h.statusUpdater.(*fakeStatusUpdater)
+	ctx := context.Background()
+
+	if err := h.handleDeprovisioning(ctx, cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(updater.reasons) != 1 || updater.reasons[0] != conditions.ServiceInstancesAndBindingsNotCleaned {
+		t.Fatalf("expected the deletion to be blocked, got %v", updater.reasons)
+	}
+	if !strings.Contains(updater.messages[0], "ServiceInstance ns-a/production-db") {
+		t.Fatalf("expected the retained instance in the message, got %q", updater.messages[0])
+	}
+	if err := h.client.Get(ctx, client.ObjectKeyFromObject(retained), operandResource(instanceGvk, "", "")); err != nil {
+		t.Fatalf("expected the retained instance to exist, got %v", err)
+	}
+	latest := &v1alpha1.BtpOperator{}
+	if err := h.client.Get(ctx, client.ObjectKeyFromObject(cr), latest); err != nil || latest.Status.Deprovisioning != nil {
+		t.Fatalf("expected the deprovisioning not to start, got %+v", latest.Status.Deprovisioning)
+	}
+}
+
+func withDeletionPolicy(u *unstructured.Unstructured, policy DeletionPolicy) *unstructured.Unstructured {
+	u.SetAnnotations(map[string]string{DeletionPolicyAnnotation: string(policy)})
+	return u
+}
+
+func TestPreview_BlockedByRetainedResources(t *testing.T) {
+	h := newTestHandler(t,
+		withDeletionPolicy(operandResource(instanceGvk, "ns-a", "instance-1"), "keep"),
+		withDeletionPolicy(operandResource(bindingGvk, "ns-a", "binding-1"), DeletionPolicyOrphan),
+	)
+	cr := deprovisionedCr(nil)
+	cr.Labels = map[string]string{forceDeleteLabelKey: "true"}
+
+	preview, err := h.Preview(context.Background(), cr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if preview.Outcome != PreviewOutcomeBlocked {
+		t.Fatalf("expected %s, got %s", PreviewOutcomeBlocked, preview.Outcome)
+	}
+	if len(preview.Retained) != 1 || preview.Retained[0] != "ServiceInstance ns-a/instance-1" {
+		t.Fatalf("expected the retained instance, got %v", preview.Retained)
+	}
+	if len(preview.Orphaned) != 1 || preview.Orphaned[0] != "ServiceBinding ns-a/binding-1" {
+		t.Fatalf("expected the orphaned binding, got %v", preview.Orphaned)
+	}
+}
@@ -0,0 +1,290 @@
+package deprovisioning
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/credentials/drift"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// PreviewConfigMapName is the name of the ConfigMap in the ChartNamespace holding the deprovisioning preview.
+	PreviewConfigMapName = "btp-manager-deprovisioning-preview"
+
+	PreviewRequestKey = "request"
+	PreviewSummaryKey = "summary"
+	PreviewReportKey  = "preview.yaml"
+)
+
+// PreviewOutcome describes what deleting the BtpOperator CR would do with the existing service instances and bindings.
+type PreviewOutcome string
+
+const (
+	// PreviewOutcomeBlocked means the existing service instances and bindings block the deletion.
+	PreviewOutcomeBlocked PreviewOutcome = "Blocked"
+	// PreviewOutcomeHardDelete means the service instances and bindings are deleted together with the module.
+	PreviewOutcomeHardDelete PreviewOutcome = "HardDelete"
+	// PreviewOutcomeModuleResourcesOnly means there are no service instances and bindings, only the module resources are deleted.
+	PreviewOutcomeModuleResourcesOnly PreviewOutcome = "ModuleResourcesOnly"
+)
+
+// Preview is the report of what deleting the BtpOperator CR would remove from the cluster.
+type Preview struct {
+	GeneratedAt      time.Time            `yaml:"generatedAt"`
+	ForceDelete      bool                 `yaml:"forceDelete"`
+	Outcome          PreviewOutcome       `yaml:"outcome"`
+	Message          string               `yaml:"message"`
+	ServiceInstances int                  `yaml:"serviceInstances"`
+	ServiceBindings  int                  `yaml:"serviceBindings"`
+	Namespaces       []NamespacePreview   `yaml:"namespaces,omitempty"`
+	Retained         []string             `yaml:"retained,omitempty"`
+	Orphaned         []string             `yaml:"orphaned,omitempty"`
+	Confirmation     *PreviewConfirmation `yaml:"confirmation,omitempty"`
+	ModuleResources  []ResourceReference  `yaml:"moduleResources,omitempty"`
+}
+
+// PreviewConfirmation lists the values of the ForceDeleteConfirmationAnnotation that confirm the force delete.
+type PreviewConfirmation struct {
+	Counts string `yaml:"counts"`
+	Token  string `yaml:"token"`
+}
+
+// NamespacePreview lists the service instances and bindings in a namespace.
+type NamespacePreview struct {
+	Namespace        string   `yaml:"namespace"`
+	ServiceInstances []string `yaml:"serviceInstances,omitempty"`
+	ServiceBindings  []string `yaml:"serviceBindings,omitempty"`
+}
+
+// ResourceReference identifies a module resource to be deleted.
+type ResourceReference struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Namespace  string `yaml:"namespace,omitempty"`
+	Name       string `yaml:"name"`
+}
+
+// WritePreview writes the deprovisioning preview to the PreviewConfigMapName ConfigMap when the CR requests it
+// with the DeprovisioningPreviewAnnotation. The preview is regenerated only when the annotation value changes.
+func (h *handler) WritePreview(ctx context.Context, cr *v1alpha1.BtpOperator) error {
+	logger := log.FromContext(ctx)
+	cfg := config.Current()
+
+	request := cr.GetAnnotations()[v1alpha1.DeprovisioningPreviewAnnotation]
+	if request == "" {
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := h.client.Get(ctx, client.ObjectKey{Name: PreviewConfigMapName, Namespace: cfg.ChartNamespace}, cm)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("while getting %s ConfigMap: %w", PreviewConfigMapName, err)
+	}
+	exists := err == nil
+	if exists && cm.Data[PreviewRequestKey] == request {
+		return nil
+	}
+
+	logger.Info("generating deprovisioning preview", "request", request)
+	preview, err := h.Preview(ctx, cr)
+	if err != nil {
+		return err
+	}
+	report, err := yaml.Marshal(preview)
+	if err != nil {
+		return fmt.Errorf("while marshalling deprovisioning preview: %w", err)
+	}
+
+	cm.Name, cm.Namespace = PreviewConfigMapName, cfg.ChartNamespace
+	if cm.Labels == nil {
+		cm.Labels = map[string]string{}
+	}
+	cm.Labels[managedByLabelKey] = operatorName
+	cm.Data = map[string]string{
+		PreviewRequestKey: request,
+		PreviewSummaryKey: preview.Message,
+		PreviewReportKey:  string(report),
+	}
+	if exists {
+		err = h.client.Update(ctx, cm)
+	} else {
+		err = h.client.Create(ctx, cm)
+	}
+	if err != nil {
+		return fmt.Errorf("while writing %s ConfigMap: %w", PreviewConfigMapName, err)
+	}
+	return nil
+}
+
+// Preview reports what deleting the BtpOperator CR would remove, without changing anything in the cluster.
+func (h *handler) Preview(ctx context.Context, cr *v1alpha1.BtpOperator) (*Preview, error) {
+	cfg := config.Current()
+	preview := &Preview{GeneratedAt: time.Now().UTC(), ForceDelete: IsForceDelete(cr)}
+
+	namespaces := map[string]*NamespacePreview{}
+	namespaceFor := func(name string) *NamespacePreview {
+		if _, exists := namespaces[name]; !exists {
+			namespaces[name] = &NamespacePreview{Namespace: name}
+		}
+		return namespaces[name]
+	}
+	instances, err := h.listOperandResources(ctx, instanceGvk)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range instances {
+		ns := namespaceFor(item.GetNamespace())
+		ns.ServiceInstances = append(ns.ServiceInstances, item.GetName())
+	}
+	bindings, err := h.listOperandResources(ctx, bindingGvk)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range bindings {
+		ns := namespaceFor(item.GetNamespace())
+		ns.ServiceBindings = append(ns.ServiceBindings, item.GetName())
+	}
+	preview.ServiceInstances, preview.ServiceBindings = len(instances), len(bindings)
+	for _, ns := range namespaces {
+		sort.Strings(ns.ServiceInstances)
+		sort.Strings(ns.ServiceBindings)
+		preview.Namespaces = append(preview.Namespaces, *ns)
+	}
+	sort.Slice(preview.Namespaces, func(i, j int) bool { return preview.Namespaces[i].Namespace < preview.Namespaces[j].Namespace })
+
+	if preview.Retained, err = h.resourcesWithPolicy(ctx, DeletionPolicyRetain); err != nil {
+		return nil, err
+	}
+	if preview.Orphaned, err = h.resourcesWithPolicy(ctx, DeletionPolicyOrphan); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case preview.ServiceInstances == 0 && preview.ServiceBindings == 0:
+		preview.Outcome = PreviewOutcomeModuleResourcesOnly
+		preview.Message = "No service instances and bindings exist. Only the module resources are deleted"
+	case !preview.ForceDelete:
+		preview.Outcome = PreviewOutcomeBlocked
+		preview.Message = fmt.Sprintf("Deletion is blocked by %d instance(s) and %d binding(s). Remove them or set the %s label to \"true\"",
+			preview.ServiceInstances, preview.ServiceBindings, forceDeleteLabelKey)
+	case len(preview.Retained) > 0:
+		preview.Outcome = PreviewOutcomeBlocked
+		preview.Message = fmt.Sprintf("Deletion is blocked by %d resource(s) with the %s annotation set to %q",
+			len(preview.Retained), DeletionPolicyAnnotation, DeletionPolicyRetain)
+	default:
+		preview.Outcome = PreviewOutcomeHardDelete
+		preview.Message = fmt.Sprintf("%d instance(s) and %d binding(s) are hard deleted. If the hard delete fails or does not finish within %s, their finalizers are removed (soft delete)",
+			preview.ServiceInstances, preview.ServiceBindings, cfg.HardDeleteTimeout)
+		if len(preview.Orphaned) > 0 {
+			preview.Message += fmt.Sprintf(". %d resource(s) with the %s annotation set to %q are removed from the cluster instead, and their SAP BTP resources are kept",
+				len(preview.Orphaned), DeletionPolicyAnnotation, DeletionPolicyOrphan)
+		}
+		if cfg.ForceDeleteConfirmationRequired {
+			preview.Confirmation = &PreviewConfirmation{
+				Counts: confirmationCounts(preview.ServiceInstances, preview.ServiceBindings),
+				Token:  confirmationToken(cr, instances, bindings),
+			}
+			preview.Message += fmt.Sprintf(". The deletion must be confirmed with the %s annotation", v1alpha1.ForceDeleteConfirmationAnnotation)
+		}
+	}
+
+	preview.ModuleResources, err = h.listModuleResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+func (h *handler) listOperandResources(ctx context.Context, gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	exists, err := h.crdExists(ctx, gvk)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	list := GvkToList(gvk)
+	if err := h.client.List(ctx, list, client.InNamespace(corev1.NamespaceAll)); err != nil {
+		return nil, fmt.Errorf("while listing %s resources: %w", gvk.Kind, err)
+	}
+	return list.Items, nil
+}
+
+// listModuleResources lists the resources deleteBtpOperatorResources removes. Objects of types outside the manager cache
+// are read with the API server client.
+func (h *handler) listModuleResources(ctx context.Context) ([]ResourceReference, error) {
+	cfg := config.Current()
+	resourceTypes, err := h.moduleResourceTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	networkPolicy := &unstructured.Unstructured{}
+	networkPolicy.SetGroupVersionKind(networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy"))
+	resourceTypes = append(resourceTypes, networkPolicy)
+
+	references := make([]ResourceReference, 0)
+	listedGvks := make(map[string]struct{})
+	for _, u := range resourceTypes {
+		gvk := u.GroupVersionKind()
+		if _, exists := listedGvks[gvk.String()]; exists {
+			continue
+		}
+		listedGvks[gvk.String()] = struct{}{}
+
+		list := GvkToList(gvk)
+		if err := h.apiServerClient.List(ctx, list, client.InNamespace(cfg.ChartNamespace), managedByLabelFilter); err != nil {
+			if k8serrors.IsNotFound(err) || k8serrors.IsMethodNotSupported(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("while listing %s module resources: %w", gvk.Kind, err)
+		}
+		for _, item := range list.Items {
+			if gvk.Kind == "ConfigMap" && item.GetName() == PreviewConfigMapName {
+				continue
+			}
+			references = append(references, ResourceReference{APIVersion: gvk.GroupVersion().String(), Kind: gvk.Kind, Namespace: item.GetNamespace(), Name: item.GetName()})
+		}
+	}
+
+	requiredSecret, err := h.getSecretByNameAndNamespace(ctx, cfg.SecretName, cfg.ChartNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if requiredSecret != nil {
+		h.driftDetector.InitializeFromSecret(requiredSecret)
+	}
+	if namespace := h.driftDetector.CredentialsNamespaceFromManager(); namespace != "" {
+		secret, err := h.getSecretByNameAndNamespace(ctx, drift.SapBtpServiceOperatorClusterIdSecretName, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if secret != nil {
+			references = append(references, ResourceReference{APIVersion: "v1", Kind: "Secret", Namespace: namespace, Name: secret.Name})
+		}
+	}
+
+	sort.SliceStable(references, func(i, j int) bool {
+		a, b := references[i], references[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return references, nil
+}
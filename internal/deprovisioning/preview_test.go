@@ -0,0 +1,250 @@
+package deprovisioning
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	"github.com/kyma-project/btp-manager/internal/credentials/drift"
+	"github.com/kyma-project/btp-manager/internal/manager/moduleresource"
+	"github.com/kyma-project/btp-manager/internal/metrics"
+	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "kyma-system"
+
+func TestPreview_BlockedWithoutForceDelete(t *testing.T) {
+	h := newTestHandler(t,
+		operandResource(instanceGvk, "ns-b", "instance-2"),
+		operandResource(instanceGvk, "ns-a", "instance-1"),
+		operandResource(bindingGvk, "ns-a", "binding-1"),
+	)
+
+	preview, err := h.Preview(context.Background(), &v1alpha1.BtpOperator{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if preview.Outcome != PreviewOutcomeBlocked {
+		t.Fatalf("expected %s, got %s", PreviewOutcomeBlocked, preview.Outcome)
+	}
+	if preview.ServiceInstances != 2 || preview.ServiceBindings != 1 {
+		t.Fatalf("expected 2 instances and 1 binding, got %d and %d", preview.ServiceInstances, preview.ServiceBindings)
+	}
+	if len(preview.Namespaces) != 2 || preview.Namespaces[0].Namespace != "ns-a" {
+		t.Fatalf("expected namespaces ns-a and ns-b, got %+v", preview.Namespaces)
+	}
+	if got := preview.Namespaces[0]; len(got.ServiceInstances) != 1 || got.ServiceBindings[0] != "binding-1" {
+		t.Fatalf("unexpected ns-a preview: %+v", got)
+	}
+}
+
+func TestPreview_HardDeleteWithForceDelete(t *testing.T) {
+	h := newTestHandler(t, operandResource(instanceGvk, "ns-a", "instance-1"))
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{forceDeleteLabelKey: "true"}}}
+
+	preview, err := h.Preview(context.Background(), cr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if preview.Outcome != PreviewOutcomeHardDelete || !preview.ForceDelete {
+		t.Fatalf("expected force hard delete, got %s", preview.Outcome)
+	}
+}
+
+func TestPreview_ListsModuleResources(t *testing.T) {
+	h := newTestHandler(t,
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "sap-btp-operator-config", Namespace: testNamespace, Labels: map[string]string{managedByLabelKey: operatorName}}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: testNamespace}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: PreviewConfigMapName, Namespace: testNamespace, Labels: map[string]string{managedByLabelKey: operatorName}}},
+		&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: testNamespace, Labels: map[string]string{managedByLabelKey: operatorName}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: drift.SapBtpServiceOperatorClusterIdSecretName, Namespace: "credentials"}},
+	)
+
+	preview, err := h.Preview(context.Background(), &v1alpha1.BtpOperator{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if preview.Outcome != PreviewOutcomeModuleResourcesOnly {
+		t.Fatalf("expected %s, got %s", PreviewOutcomeModuleResourcesOnly, preview.Outcome)
+	}
+	want := []ResourceReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: testNamespace, Name: "sap-btp-operator-config"},
+		{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy", Namespace: testNamespace, Name: "policy"},
+		{APIVersion: "v1", Kind: "Secret", Namespace: "credentials", Name: drift.SapBtpServiceOperatorClusterIdSecretName},
+	}
+	if len(preview.ModuleResources) != len(want) {
+		t.Fatalf("expected %v, got %v", want, preview.ModuleResources)
+	}
+	for i := range want {
+		if preview.ModuleResources[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, preview.ModuleResources)
+		}
+	}
+}
+
+func TestWritePreview_RegeneratesOnlyForNewRequest(t *testing.T) {
+	h := newTestHandler(t)
+	ctx := context.Background()
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1alpha1.DeprovisioningPreviewAnnotation: "1"}}}
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Name: PreviewConfigMapName, Namespace: testNamespace}
+
+	if err := h.WritePreview(ctx, cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.client.Get(ctx, key, cm); err != nil {
+		t.Fatalf("expected the preview ConfigMap: %v", err)
+	}
+	if cm.Data[PreviewRequestKey] != "1" || !strings.Contains(cm.Data[PreviewReportKey], "outcome: ModuleResourcesOnly") {
+		t.Fatalf("unexpected preview: %v", cm.Data)
+	}
+
+	cm.Data[PreviewSummaryKey] = "unchanged"
+	if err := h.client.Update(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.WritePreview(ctx, cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.client.Get(ctx, key, cm); err != nil || cm.Data[PreviewSummaryKey] != "unchanged" {
+		t.Fatalf("expected the preview not to be regenerated for the same request, got %v", cm.Data)
+	}
+
+	cr.Annotations[v1alpha1.DeprovisioningPreviewAnnotation] = "2"
+	if err := h.WritePreview(ctx, cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.client.Get(ctx, key, cm); err != nil || cm.Data[PreviewRequestKey] != "2" || cm.Data[PreviewSummaryKey] == "unchanged" {
+		t.Fatalf("expected the preview to be regenerated, got %v", cm.Data)
+	}
+}
+
+func TestWritePreview_WithoutAnnotation(t *testing.T) {
+	h := newTestHandler(t)
+	ctx := context.Background()
+
+	if err := h.WritePreview(ctx, &v1alpha1.BtpOperator{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := h.client.Get(ctx, client.ObjectKey{Name: PreviewConfigMapName, Namespace: testNamespace}, &corev1.ConfigMap{})
+	if client.IgnoreNotFound(err) != nil || err == nil {
+		t.Fatalf("expected no preview ConfigMap, got %v", err)
+	}
+}
+
+func newTestHandler(t *testing.T, objs ...client.Object) *handler {
+	t.Helper()
+	origChartNs := config.ChartNamespace
+	config.ChartNamespace = testNamespace
+	t.Cleanup(func() { config.ChartNamespace = origChartNs })
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	gvks := []schema.GroupVersionKind{instanceGvk, bindingGvk}
+	for _, u := range certificate.CertManagerResourceTypes() {
+		gvks = append(gvks, u.GroupVersionKind())
+	}
+	for _, gvk := range gvks {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(GvkToList(gvk).GroupVersionKind(), &unstructured.UnstructuredList{})
+	}
+	objs = append(objs,
+		&apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "serviceinstances.services.cloud.sap.com"}},
+		&apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "servicebindings.services.cloud.sap.com"}},
+	)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(&v1alpha1.BtpOperator{}).Build()
+
+	return &handler{
+		client:                k8sClient,
+		apiServerClient:       k8sClient,
+		statusUpdater:         &fakeStatusUpdater{},
+		driftDetector:         &fakeDriftDetector{credentialsNamespace: "credentials"},
+		moduleResourceManager: &fakeModuleResourceManager{},
+		operandExporter:       &fakeOperandExporter{},
+		metrics:               metrics.NewDeprovisioningMetrics(prometheus.NewRegistry()),
+	}
+}
+
+func operandResource(gvk schema.GroupVersionKind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+// fakeDriftDetector is a minimal test double for drift.Detector.
+type fakeDriftDetector struct {
+	drift.Detector
+	credentialsNamespace string
+}
+
+func (f *fakeDriftDetector) InitializeFromSecret(*corev1.Secret) {}
+
+func (f *fakeDriftDetector) CredentialsNamespaceFromManager() string {
+	return f.credentialsNamespace
+}
+
+func (f *fakeDriftDetector) DeleteClusterIdSecret(context.Context) error { return nil }
+
+// fakeStatusUpdater is a minimal test double for StatusUpdater recording the reasons and messages.
+type fakeStatusUpdater struct {
+	reasons  []conditions.Reason
+	messages []string
+}
+
+func (f *fakeStatusUpdater) UpdateBtpOperatorStatus(_ context.Context, _ *v1alpha1.BtpOperator, _ v1alpha1.State, reason conditions.Reason, msg string) error {
+	f.reasons = append(f.reasons, reason)
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+// fakeOperandExporter is a minimal test double for OperandExporter counting the exports.
+type fakeOperandExporter struct {
+	exports int
+	err     error
+}
+
+func (f *fakeOperandExporter) Export(context.Context, *v1alpha1.BtpOperator) error {
+	f.exports++
+	return f.err
+}
+
+// fakeModuleResourceManager is a minimal test double for moduleresource.ResourceManager returning a ConfigMap as the only module resource type.
+type fakeModuleResourceManager struct {
+	moduleresource.ResourceManager
+}
+
+func (f *fakeModuleResourceManager) CreateUnstructuredObjectsFromManifestsDir(string) ([]*unstructured.Unstructured, error) {
+	cm := &unstructured.Unstructured{}
+	cm.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	return []*unstructured.Unstructured{cm}, nil
+}
+
+func (f *fakeModuleResourceManager) GetResourcesToApplyPath() string  { return "apply" }
+func (f *fakeModuleResourceManager) GetResourcesToDeletePath() string { return "delete" }
@@ -0,0 +1,146 @@
+package deprovisioning
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxRemainingResourcesInStatus limits the breakdown in the CR status, so that clusters with many namespaces don't bloat it.
+// The metrics aggregate the namespaces, so their cardinality doesn't grow with the number of namespaces either.
+const maxRemainingResourcesInStatus = 50
+
+// Resource states in the remaining resources breakdown.
+const (
+	resourceStateDeleting = "Deleting"
+	resourceStateFailed   = "Failed"
+	resourceStateReady    = "Ready"
+	resourceStateNotReady = "NotReady"
+)
+
+// Reasons of the fallbacks from the hard delete to the soft delete.
+const (
+	softDeleteFallbackError   = "error"
+	softDeleteFallbackTimeout = "timeout"
+)
+
+// DeprovisioningMetrics records the deprovisioning progress and the hard delete throughput.
+type DeprovisioningMetrics interface {
+	ObserveHardDeleteRequest(kind string, err error)
+	AddHardDeletedResources(kind string, count int)
+	ObserveNamespaceHardDelete(kind string, duration time.Duration)
+	ResetRemainingResources()
+	SetRemainingResources(kind, state string, count int)
+	ObservePhaseDuration(phase string, duration time.Duration)
+	IncrementSoftDeleteFallbacks(reason string)
+}
+
+// remainingResources breaks down the existing ServiceInstances and ServiceBindings by kind, namespace, and state, most numerous first.
+func (h *handler) remainingResources(ctx context.Context) ([]v1alpha1.RemainingResources, error) {
+	counts := make(map[v1alpha1.RemainingResources]int)
+	for _, gvk := range []schema.GroupVersionKind{instanceGvk, bindingGvk} {
+		items, err := h.listOperandResources(ctx, gvk)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			counts[v1alpha1.RemainingResources{Kind: gvk.Kind, Namespace: items[i].GetNamespace(), State: resourceState(&items[i])}]++
+		}
+	}
+
+	remaining := make([]v1alpha1.RemainingResources, 0, len(counts))
+	for key, count := range counts {
+		key.Count = count
+		remaining = append(remaining, key)
+	}
+	sort.Slice(remaining, func(i, j int) bool {
+		a, b := remaining[i], remaining[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.State < b.State
+	})
+	return remaining, nil
+}
+
+func countByKind(remaining []v1alpha1.RemainingResources) (instances, bindings int) {
+	for _, r := range remaining {
+		switch r.Kind {
+		case instanceGvk.Kind:
+			instances += r.Count
+		case bindingGvk.Kind:
+			bindings += r.Count
+		}
+	}
+	return instances, bindings
+}
+
+// resourceState returns Deleting for resources marked for deletion, otherwise the state from the conditions set by the SAP BTP service operator.
+func resourceState(u *unstructured.Unstructured) string {
+	if u.GetDeletionTimestamp() != nil {
+		return resourceStateDeleting
+	}
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	ready := false
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["status"] != "True" {
+			continue
+		}
+		switch condition["type"] {
+		case "Failed":
+			return resourceStateFailed
+		case "Ready":
+			ready = true
+		}
+	}
+	if ready {
+		return resourceStateReady
+	}
+	return resourceStateNotReady
+}
+
+// reportRemainingResources publishes the remaining resources breakdown in the CR status, and its totals per kind and state in the metrics.
+func (h *handler) reportRemainingResources(ctx context.Context, cr *v1alpha1.BtpOperator, remaining []v1alpha1.RemainingResources) error {
+	totals := make(map[v1alpha1.RemainingResources]int)
+	for _, r := range remaining {
+		totals[v1alpha1.RemainingResources{Kind: r.Kind, State: r.State}] += r.Count
+	}
+	h.metrics.ResetRemainingResources()
+	for key, count := range totals {
+		h.metrics.SetRemainingResources(key.Kind, key.State, count)
+	}
+
+	if len(remaining) > maxRemainingResourcesInStatus {
+		remaining = remaining[:maxRemainingResourcesInStatus]
+	}
+	if len(remaining) == 0 {
+		remaining = nil
+	}
+	if equality.Semantic.DeepEqual(cr.Status.RemainingResources, remaining) {
+		return nil
+	}
+	return h.updateStatus(ctx, cr, func(status *v1alpha1.Status) {
+		status.RemainingResources = remaining
+	})
+}
+
+// observePhaseEnd records the duration of the current deprovisioning phase.
+func (h *handler) observePhaseEnd(cr *v1alpha1.BtpOperator, now time.Time) {
+	status := cr.Status.Deprovisioning
+	if status == nil || status.Phase == "" || status.Phase == v1alpha1.DeprovisioningPhaseCompleted {
+		return
+	}
+	h.metrics.ObservePhaseDuration(string(status.Phase), now.Sub(status.PhaseStartTime.Time))
+}
@@ -0,0 +1,121 @@
+package deprovisioning
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestResourceState(t *testing.T) {
+	deleting := operandResource(instanceGvk, "ns-a", "deleting")
+	deleting.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	for want, u := range map[string]*unstructured.Unstructured{
+		resourceStateDeleting: deleting,
+		resourceStateFailed:   withConditions(operandResource(instanceGvk, "ns-a", "failed"), "Ready", "False", "Failed", "True"),
+		resourceStateReady:    withConditions(operandResource(instanceGvk, "ns-a", "ready"), "Succeeded", "True", "Ready", "True"),
+		resourceStateNotReady: withConditions(operandResource(instanceGvk, "ns-a", "not-ready"), "Ready", "False"),
+	} {
+		if got := resourceState(u); got != want {
+			t.Errorf("%s: expected %s, got %s", u.GetName(), want, got)
+		}
+	}
+}
+
+func TestHandleDeprovisioning_ReportsRemainingResources(t *testing.T) {
+	cr := deprovisionedCr(nil)
+	h := newTestHandler(t, cr,
+		withConditions(operandResource(instanceGvk, "ns-a", "instance-1"), "Ready", "True"),
+		withConditions(operandResource(instanceGvk, "ns-a", "instance-2"), "Ready", "True"),
+		withConditions(operandResource(instanceGvk, "ns-b", "instance-3"), "Ready", "True"),
+		operandResource(bindingGvk, "ns-b", "binding-1"),
+	)
+	metrics := &fakeDeprovisioningMetrics{}
+	h.metrics = metrics
+
+	if err := h.handleDeprovisioning(context.Background(), cr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []v1alpha1.RemainingResources{
+		{Kind: "ServiceInstance", Namespace: "ns-a", State: resourceStateReady, Count: 2},
+		{Kind: "ServiceBinding", Namespace: "ns-b", State: resourceStateNotReady, Count: 1},
+		{Kind: "ServiceInstance", Namespace: "ns-b", State: resourceStateReady, Count: 1},
+	}
+	if got := persistedCr(t, h).Status.RemainingResources; !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	wantMetrics := map[string]int{"ServiceInstance/Ready": 3, "ServiceBinding/NotReady": 1}
+	if !reflect.DeepEqual(wantMetrics, metrics.remaining) {
+		t.Fatalf("expected %v, got %v", wantMetrics, metrics.remaining)
+	}
+}
+
+func TestReportRemainingResources_LimitsStatus(t *testing.T) {
+	cr := deprovisionedCr(nil)
+	h := newTestHandler(t, cr)
+	metrics := &fakeDeprovisioningMetrics{}
+	h.metrics = metrics
+	remaining := make([]v1alpha1.RemainingResources, 0)
+	for i := 0; i < maxRemainingResourcesInStatus+10; i++ {
+		remaining = append(remaining, v1alpha1.RemainingResources{Kind: "ServiceInstance", Namespace: fmt.Sprintf("ns-%d", i), State: resourceStateReady, Count: 1})
+	}
+
+	if err := h.reportRemainingResources(context.Background(), cr, remaining); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := len(persistedCr(t, h).Status.RemainingResources); got != maxRemainingResourcesInStatus {
+		t.Fatalf("expected %d resources in status, got %d", maxRemainingResourcesInStatus, got)
+	}
+	if want := map[string]int{"ServiceInstance/Ready": maxRemainingResourcesInStatus + 10}; !reflect.DeepEqual(want, metrics.remaining) {
+		t.Fatalf("expected the totals of all namespaces in metrics %v, got %v", want, metrics.remaining)
+	}
+}
+
+func TestRunPhases_RecordsPhaseDurationsAndFallbacks(t *testing.T) {
+	origTimeout := config.HardDeleteTimeout
+	t.Cleanup(func() { config.HardDeleteTimeout = origTimeout })
+	config.HardDeleteTimeout = time.Minute
+
+	startTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	cr := deprovisionedCr(&v1alpha1.DeprovisioningStatus{Phase: v1alpha1.DeprovisioningPhaseHardDeleting, StartTime: startTime, PhaseStartTime: startTime})
+	cr.Status.RemainingResources = []v1alpha1.RemainingResources{{Kind: "ServiceInstance", Namespace: "ns-a", State: resourceStateDeleting, Count: 1}}
+	instance := operandResource(instanceGvk, "ns-a", "instance-1")
+	instance.SetFinalizers([]string{"services.cloud.sap.com/sap-btp-finalizer"})
+	h := newTestHandler(t, cr, instance)
+	metrics := &fakeDeprovisioningMetrics{}
+	h.metrics = metrics
+
+	if err := h.runPhases(context.Background(), cr, testNamespaces()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(metrics.fallbacks, []string{softDeleteFallbackTimeout}) {
+		t.Fatalf("expected a fallback after the timeout, got %v", metrics.fallbacks)
+	}
+	wantPhases := []string{"HardDeleting", "SoftDeleting"}
+	if !reflect.DeepEqual(metrics.phases, wantPhases) {
+		t.Fatalf("expected durations of %v, got %v", wantPhases, metrics.phases)
+	}
+	if got := persistedCr(t, h).Status.RemainingResources; got != nil {
+		t.Fatalf("expected the remaining resources to be cleared, got %+v", got)
+	}
+}
+
+func withConditions(u *unstructured.Unstructured, typesAndStatuses ...string) *unstructured.Unstructured {
+	conditions := make([]interface{}, 0)
+	for i := 0; i+1 < len(typesAndStatuses); i += 2 {
+		conditions = append(conditions, map[string]interface{}{"type": typesAndStatuses[i], "status": typesAndStatuses[i+1]})
+	}
+	if err := unstructured.SetNestedSlice(u.Object, conditions, "status", "conditions"); err != nil {
+		panic(err)
+	}
+	return u
+}
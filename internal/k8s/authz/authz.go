@@ -0,0 +1,68 @@
+package authz
+
+import (
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const bearerPrefix = "Bearer "
+
+// WithAuthorization serves a request with next only if the API server authenticates its bearer token with a TokenReview,
+// and a SubjectAccessReview allows the user the request verb on the request path as a non-resource URL,
+// for example, get on /debug/config.
+func WithAuthorization(c client.Client, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		logger := log.FromContext(ctx).WithValues("path", req.URL.Path)
+
+		token, found := strings.CutPrefix(req.Header.Get("Authorization"), bearerPrefix)
+		if !found || token == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+		if err := c.Create(ctx, review); err != nil {
+			logger.Error(err, "while reviewing the token")
+			http.Error(w, "Authentication failed", http.StatusInternalServerError)
+			return
+		}
+		if !review.Status.Authenticated {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		user := review.Status.User
+		extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+		for k, v := range user.Extra {
+			extra[k] = authorizationv1.ExtraValue(v)
+		}
+		access := &authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: req.URL.Path,
+				Verb: strings.ToLower(req.Method),
+			},
+		}}
+		if err := c.Create(ctx, access); err != nil {
+			logger.Error(err, "while reviewing the access", "user", user.Username)
+			http.Error(w, "Authorization failed", http.StatusInternalServerError)
+			return
+		}
+		if !access.Status.Allowed {
+			logger.Info("access denied", "user", user.Username, "reason", access.Status.Reason)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,87 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const validToken = "valid-token"
+
+// newReviewer returns a client whose TokenReviews authenticate validToken as user "reader",
+// and whose SubjectAccessReviews allow the user only if allowed is true. The last SubjectAccessReview is stored in access.
+func newReviewer(allowed bool, access *authorizationv1.SubjectAccessReview) client.Client {
+	return fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			switch review := obj.(type) {
+			case *authenticationv1.TokenReview:
+				if review.Spec.Token == validToken {
+					review.Status.Authenticated = true
+					review.Status.User = authenticationv1.UserInfo{Username: "reader", Groups: []string{"system:authenticated"}}
+				}
+			case *authorizationv1.SubjectAccessReview:
+				review.Status.Allowed = allowed
+				*access = *review
+			}
+			return nil
+		},
+	}).Build()
+}
+
+func serve(c client.Client, token string) *httptest.ResponseRecorder {
+	handler := WithAuthorization(c, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("served"))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/debug/config", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWithAuthorization(t *testing.T) {
+	t.Run("should reject a request without a token", func(t *testing.T) {
+		access := &authorizationv1.SubjectAccessReview{}
+		rec := serve(newReviewer(true, access), "")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should reject a token the API server does not authenticate", func(t *testing.T) {
+		access := &authorizationv1.SubjectAccessReview{}
+		rec := serve(newReviewer(true, access), "other-token")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("should reject a user without access to the path", func(t *testing.T) {
+		access := &authorizationv1.SubjectAccessReview{}
+		rec := serve(newReviewer(false, access), validToken)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "served")
+	})
+
+	t.Run("should serve a user with access to the path", func(t *testing.T) {
+		access := &authorizationv1.SubjectAccessReview{}
+		rec := serve(newReviewer(true, access), validToken)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "served", rec.Body.String())
+		assert.Equal(t, "reader", access.Spec.User)
+		require.NotNil(t, access.Spec.NonResourceAttributes)
+		assert.Equal(t, authorizationv1.NonResourceAttributes{Path: "/debug/config", Verb: "get"}, *access.Spec.NonResourceAttributes)
+	})
+}
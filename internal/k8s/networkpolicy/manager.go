@@ -3,25 +3,34 @@ package networkpolicy
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
 
 	"github.com/kyma-project/btp-manager/controllers/config"
 	"github.com/kyma-project/btp-manager/internal/manifest"
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 const (
 	operatorName = "btp-manager"
+	moduleName   = "btp-operator"
 
-	managedByLabelKey = "app.kubernetes.io/managed-by"
+	managedByLabelKey         = "app.kubernetes.io/managed-by"
+	kymaProjectModuleLabelKey = "kyma-project.io/module"
 
 	oldWebhookNetworkPolicyName = "kyma-project.io--btp-operator-allow-to-webhook"
+
+	ProxyNetworkPolicyName = "kyma-project.io--btp-operator-allow-to-proxy"
 )
 
 var managedLabelsFilter = client.MatchingLabels{managedByLabelKey: operatorName}
@@ -29,6 +38,7 @@ var managedLabelsFilter = client.MatchingLabels{managedByLabelKey: operatorName}
 type NetworkPolicyManager interface {
 	CleanupNetworkPolicies(ctx context.Context) error
 	DeleteOldWebhookNetworkPolicy(ctx context.Context) error
+	DeleteStaleProxyNetworkPolicy(ctx context.Context) error
 	LoadNetworkPolicies() ([]*unstructured.Unstructured, error)
 }
 
@@ -47,7 +57,7 @@ func NewManager(k8sClient client.Client, manifestHandler *manifest.Handler) *Man
 var _ NetworkPolicyManager = (*Manager)(nil)
 
 func (m *Manager) getNetworkPoliciesPath() string {
-	return fmt.Sprintf("%s%cnetwork-policies", config.ManagerResourcesPath, os.PathSeparator)
+	return fmt.Sprintf("%s%cnetwork-policies", config.Current().ManagerResourcesPath, os.PathSeparator)
 }
 
 func (m *Manager) LoadNetworkPolicies() ([]*unstructured.Unstructured, error) {
@@ -55,13 +65,99 @@ func (m *Manager) LoadNetworkPolicies() ([]*unstructured.Unstructured, error) {
 	if err != nil {
 		return nil, err
 	}
-	return m.manifestHandler.ObjectsToUnstructured(objects)
+	policies, err := m.manifestHandler.ObjectsToUnstructured(objects)
+	if err != nil {
+		return nil, err
+	}
+	if !config.ProxyConfigured() {
+		return policies, nil
+	}
+	proxyPolicy, err := proxyNetworkPolicy()
+	if err != nil {
+		return nil, err
+	}
+	return append(policies, proxyPolicy), nil
+}
+
+// proxyNetworkPolicy allows egress from the module Pods to the configured HTTP and HTTPS proxies.
+// Proxies given by IP address are restricted to that address. A NetworkPolicy can't select a host name,
+// so for proxies given by host name the rule allows egress to any address on the proxy port.
+func proxyNetworkPolicy() (*unstructured.Unstructured, error) {
+	cfg := config.Current()
+	egress := make([]networkingv1.NetworkPolicyEgressRule, 0, 2)
+	seen := make(map[string]struct{})
+	for _, rawProxyURL := range []string{cfg.HttpProxy, cfg.HttpsProxy} {
+		if rawProxyURL == "" {
+			continue
+		}
+		host, port, err := proxyHostAndPort(rawProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		key := net.JoinHostPort(host, strconv.Itoa(port))
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		tcp := corev1.ProtocolTCP
+		portNumber := intstr.FromInt32(int32(port))
+		rule := networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &portNumber}},
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			prefixLen := 32
+			if ip.To4() == nil {
+				prefixLen = 128
+			}
+			rule.To = []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: fmt.Sprintf("%s/%d", ip.String(), prefixLen)}}}
+		}
+		egress = append(egress, rule)
+	}
+
+	policy := &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{Kind: "NetworkPolicy", APIVersion: networkingv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ProxyNetworkPolicyName,
+			Namespace: cfg.ChartNamespace,
+			Labels:    map[string]string{kymaProjectModuleLabelKey: moduleName},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{kymaProjectModuleLabelKey: moduleName}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress:      egress,
+		},
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert proxy network policy to unstructured: %w", err)
+	}
+	return &unstructured.Unstructured{Object: obj}, nil
+}
+
+func proxyHostAndPort(rawProxyURL string) (string, int, error) {
+	proxyURL, err := config.ParseProxyURL(rawProxyURL)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid proxy URL %q: %w", rawProxyURL, err)
+	}
+	host := proxyURL.Hostname()
+	if proxyURL.Port() == "" {
+		if proxyURL.Scheme == "https" {
+			return host, 443, nil
+		}
+		return host, 80, nil
+	}
+	port, err := strconv.Atoi(proxyURL.Port())
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid proxy URL %q: invalid port", rawProxyURL)
+	}
+	return host, port, nil
 }
 
 func (m *Manager) CleanupNetworkPolicies(ctx context.Context) error {
 	logger := log.FromContext(ctx)
 	logger.Info("deleting all managed network policies")
-	if err := m.client.DeleteAllOf(ctx, &networkingv1.NetworkPolicy{}, client.InNamespace(config.ChartNamespace), managedLabelsFilter); err != nil {
+	if err := m.client.DeleteAllOf(ctx, &networkingv1.NetworkPolicy{}, client.InNamespace(config.Current().ChartNamespace), managedLabelsFilter); err != nil {
 		if !(k8serrors.IsNotFound(err) || k8serrors.IsMethodNotSupported(err) || meta.IsNoMatchError(err)) {
 			return fmt.Errorf("failed to delete network policies: %w", err)
 		}
@@ -74,7 +170,7 @@ func (m *Manager) DeleteOldWebhookNetworkPolicy(ctx context.Context) error {
 	oldPolicy := &networkingv1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      oldWebhookNetworkPolicyName,
-			Namespace: config.ChartNamespace,
+			Namespace: config.Current().ChartNamespace,
 		},
 	}
 	if err := m.client.Delete(ctx, oldPolicy); err != nil {
@@ -86,3 +182,20 @@ func (m *Manager) DeleteOldWebhookNetworkPolicy(ctx context.Context) error {
 	}
 	return nil
 }
+
+// DeleteStaleProxyNetworkPolicy removes the proxy network policy once no proxy is configured anymore.
+func (m *Manager) DeleteStaleProxyNetworkPolicy(ctx context.Context) error {
+	if config.ProxyConfigured() {
+		return nil
+	}
+	proxyPolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ProxyNetworkPolicyName,
+			Namespace: config.Current().ChartNamespace,
+		},
+	}
+	if err := m.client.Delete(ctx, proxyPolicy); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete proxy network policy: %w", err)
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -50,6 +51,64 @@ var _ = Describe("Network Policy Manager", func() {
 		})
 	})
 
+	Describe("LoadNetworkPolicies with proxy configured", func() {
+		var originalHttpProxy, originalHttpsProxy string
+
+		BeforeEach(func() {
+			originalHttpProxy, originalHttpsProxy = config.HttpProxy, config.HttpsProxy
+		})
+
+		AfterEach(func() {
+			config.HttpProxy, config.HttpsProxy = originalHttpProxy, originalHttpsProxy
+		})
+
+		It("should add the proxy network policy with egress to every configured proxy", func() {
+			config.HttpProxy = "http://10.1.2.3:3128"
+			config.HttpsProxy = "https://proxy.corp.local:8443"
+
+			policies, err := mgr.LoadNetworkPolicies()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policies).To(HaveLen(3))
+			proxyPolicy := &networkingv1.NetworkPolicy{}
+			Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(findByName(policies, networkpolicy.ProxyNetworkPolicyName).Object, proxyPolicy)).To(Succeed())
+			Expect(proxyPolicy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeEgress))
+			Expect(proxyPolicy.Spec.Egress).To(HaveLen(2))
+			Expect(proxyPolicy.Spec.Egress[0].Ports[0].Port.IntValue()).To(Equal(3128))
+			Expect(proxyPolicy.Spec.Egress[0].To).To(ConsistOf(networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: "10.1.2.3/32"}}))
+			Expect(proxyPolicy.Spec.Egress[1].Ports[0].Port.IntValue()).To(Equal(8443))
+			Expect(proxyPolicy.Spec.Egress[1].To).To(BeEmpty())
+		})
+
+		It("should add a single egress rule when both proxies point to the same address", func() {
+			config.HttpProxy = "http://proxy.corp.local:3128"
+			config.HttpsProxy = "http://proxy.corp.local:3128"
+
+			policies, err := mgr.LoadNetworkPolicies()
+
+			Expect(err).NotTo(HaveOccurred())
+			egress, _, err := unstructured.NestedSlice(findByName(policies, networkpolicy.ProxyNetworkPolicyName).Object, "spec", "egress")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(egress).To(HaveLen(1))
+		})
+
+		It("should return an error when the proxy URL is invalid", func() {
+			config.HttpProxy = "http://proxy.corp.local:notaport"
+
+			_, err := mgr.LoadNetworkPolicies()
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when the proxy URL has no scheme", func() {
+			config.HttpProxy = "proxy.corp.local:3128"
+
+			_, err := mgr.LoadNetworkPolicies()
+
+			Expect(err).To(MatchError(ContainSubstring("must be an http or https URL with a host")))
+		})
+	})
+
 	Describe("CleanupNetworkPolicies", func() {
 		It("should delete all managed network policies from the cluster", func() {
 			policy1 := managedNetworkPolicy(policyName1)
@@ -117,8 +176,51 @@ var _ = Describe("Network Policy Manager", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+
+	Describe("DeleteStaleProxyNetworkPolicy", func() {
+		var originalHttpProxy string
+
+		BeforeEach(func() {
+			originalHttpProxy = config.HttpProxy
+			fakeClient = newFakeClient(managedNetworkPolicy(networkpolicy.ProxyNetworkPolicyName))
+			mgr = networkpolicy.NewManager(fakeClient, &manifest.Handler{Scheme: scheme})
+		})
+
+		AfterEach(func() {
+			config.HttpProxy = originalHttpProxy
+		})
+
+		It("should delete the proxy network policy when no proxy is configured", func() {
+			config.HttpProxy = ""
+
+			Expect(mgr.DeleteStaleProxyNetworkPolicy(ctx)).To(Succeed())
+
+			remaining := &networkingv1.NetworkPolicyList{}
+			Expect(fakeClient.List(ctx, remaining, client.InNamespace(kymaNamespace))).To(Succeed())
+			Expect(remaining.Items).To(BeEmpty())
+		})
+
+		It("should keep the proxy network policy when a proxy is configured", func() {
+			config.HttpProxy = "http://proxy.corp.local:3128"
+
+			Expect(mgr.DeleteStaleProxyNetworkPolicy(ctx)).To(Succeed())
+
+			remaining := &networkingv1.NetworkPolicyList{}
+			Expect(fakeClient.List(ctx, remaining, client.InNamespace(kymaNamespace))).To(Succeed())
+			Expect(remaining.Items).To(HaveLen(1))
+		})
+	})
 })
 
+func findByName(us []*unstructured.Unstructured, name string) *unstructured.Unstructured {
+	for _, u := range us {
+		if u.GetName() == name {
+			return u
+		}
+	}
+	return nil
+}
+
 func extractNames(us []*unstructured.Unstructured) []string {
 	names := make([]string, 0, len(us))
 	for _, u := range us {
@@ -44,11 +44,11 @@ func NewManager(secretReader Reader) Manager {
 }
 
 func (m *manager) GetCaServerCertSecret(ctx context.Context) (*corev1.Secret, error) {
-	return m.getOptionalSecretByNameAndNamespace(ctx, caServerCertSecretName, config.ChartNamespace)
+	return m.getOptionalSecretByNameAndNamespace(ctx, caServerCertSecretName, config.Current().ChartNamespace)
 }
 
 func (m *manager) GetWebhookServerCertSecret(ctx context.Context) (*corev1.Secret, error) {
-	return m.getOptionalSecretByNameAndNamespace(ctx, webhookServerCertSecretName, config.ChartNamespace)
+	return m.getOptionalSecretByNameAndNamespace(ctx, webhookServerCertSecretName, config.Current().ChartNamespace)
 }
 
 func (m *manager) getOptionalSecretByNameAndNamespace(ctx context.Context, name, namespace string) (*corev1.Secret, error) {
@@ -102,6 +102,7 @@ func (m *Manager) CreateUnstructuredObjectsFromManifestsDir(manifestsDir string)
 }
 
 func (m *Manager) PrepareModuleResources(ctx context.Context, resourcesToApply []*unstructured.Unstructured, s *corev1.Secret) error {
+	cfg := config.Current()
 	var configMap, secret, deployment *unstructured.Unstructured
 	for _, u := range resourcesToApply {
 		switch {
@@ -109,14 +110,14 @@ func (m *Manager) PrepareModuleResources(ctx context.Context, resourcesToApply [
 			configMap = u
 		case u.GetName() == SapBtpServiceOperatorName && u.GetKind() == "Secret":
 			secret = u
-		case u.GetName() == config.DeploymentName && u.GetKind() == DeploymentKind:
+		case u.GetName() == cfg.DeploymentName && u.GetKind() == DeploymentKind:
 			deployment = u
 		}
 	}
 	if configMap == nil || secret == nil || deployment == nil {
 		return fmt.Errorf("required module resources not found in manifests (configMap=%t, secret=%t, deployment=%t)", configMap != nil, secret != nil, deployment != nil)
 	}
-	chartVer, err := ymlutils.ExtractStringValueFromYamlForGivenKey(fmt.Sprintf("%s%cChart.yaml", config.ChartPath, os.PathSeparator), "version")
+	chartVer, err := ymlutils.ExtractStringValueFromYamlForGivenKey(fmt.Sprintf("%s%cChart.yaml", cfg.ChartPath, os.PathSeparator), "version")
 	if err != nil {
 		return fmt.Errorf("failed to get module chart version: %w", err)
 	}
@@ -135,6 +136,9 @@ func (m *Manager) PrepareModuleResources(ctx context.Context, resourcesToApply [
 	if err := m.SetDeploymentImages(deployment); err != nil {
 		return fmt.Errorf("failed to set container images in Deployment: %w", err)
 	}
+	if err := m.SetDeploymentProxyEnv(deployment); err != nil {
+		return fmt.Errorf("failed to set proxy environment variables in Deployment: %w", err)
+	}
 
 	return nil
 }
@@ -182,7 +186,7 @@ func (m *Manager) addLabelsInPodTemplate(u *unstructured.Unstructured) error {
 
 func (m *Manager) SetNamespace(us []*unstructured.Unstructured) {
 	for _, u := range us {
-		u.SetNamespace(config.ChartNamespace)
+		u.SetNamespace(config.Current().ChartNamespace)
 	}
 }
 
@@ -202,7 +206,7 @@ func (m *Manager) SetConfigMapValues(u *unstructured.Unstructured) error {
 		return fmt.Errorf("failed to set management namespace: %w", err)
 	}
 
-	if err := unstructured.SetNestedField(u.Object, config.EnableLimitedCache, "data", enableLimitedCacheConfigMapKey); err != nil {
+	if err := unstructured.SetNestedField(u.Object, config.Current().EnableLimitedCache, "data", enableLimitedCacheConfigMapKey); err != nil {
 		return fmt.Errorf("failed to set enable limited cache: %w", err)
 	}
 
@@ -232,7 +236,38 @@ func (m *Manager) SetDeploymentImages(u *unstructured.Unstructured) error {
 	return nil
 }
 
-func (m *Manager) setContainerImage(u *unstructured.Unstructured, containerName, image string) error {
+// SetDeploymentProxyEnv replaces the proxy environment variables of the sap-btp-service-operator container
+// with the ones from the configuration. Variables that are not configured are removed from the container.
+func (m *Manager) SetDeploymentProxyEnv(u *unstructured.Unstructured) error {
+	return m.updateContainer(u, sapBtpServiceOperatorContainerName, func(container map[string]interface{}) error {
+		env, _, err := unstructured.NestedSlice(container, "env")
+		if err != nil {
+			return fmt.Errorf("failed to get env of container %s: %w", sapBtpServiceOperatorContainerName, err)
+		}
+		updatedEnv := make([]interface{}, 0, len(env)+3)
+		for _, e := range env {
+			envVar, ok := e.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("cannot cast env field to map[string]interface{}: %v", e)
+			}
+			switch envVar["name"] {
+			case config.HttpProxyEnv, config.HttpsProxyEnv, config.NoProxyEnv:
+				continue
+			}
+			updatedEnv = append(updatedEnv, envVar)
+		}
+		for _, e := range config.ProxyEnvVars() {
+			updatedEnv = append(updatedEnv, map[string]interface{}{"name": e.Name, "value": e.Value})
+		}
+		if len(updatedEnv) == 0 {
+			unstructured.RemoveNestedField(container, "env")
+			return nil
+		}
+		return unstructured.SetNestedSlice(container, updatedEnv, "env")
+	})
+}
+
+func (m *Manager) updateContainer(u *unstructured.Unstructured, containerName string, update func(container map[string]interface{}) error) error {
 	containers, found, err := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
 	if err != nil {
 		return fmt.Errorf("failed to get containers from %s %s: %w", u.GetKind(), u.GetName(), err)
@@ -241,33 +276,36 @@ func (m *Manager) setContainerImage(u *unstructured.Unstructured, containerName,
 		return fmt.Errorf("containers not found in %s %s", u.GetKind(), u.GetName())
 	}
 
-	containerFound := false
 	for i, c := range containers {
 		container, ok := c.(map[string]interface{})
 		if !ok {
 			return fmt.Errorf("cannot cast container field to map[string]interface{}: %v", c)
 		}
 		if container["name"] == containerName {
-			container["image"] = image
+			if err := update(container); err != nil {
+				return err
+			}
 			containers[i] = container
-			containerFound = true
-			break
+			return unstructured.SetNestedSlice(u.Object, containers, "spec", "template", "spec", "containers")
 		}
 	}
 
-	if !containerFound {
-		return fmt.Errorf("container %s not found in %s %s", containerName, u.GetKind(), u.GetName())
-	}
+	return fmt.Errorf("container %s not found in %s %s", containerName, u.GetKind(), u.GetName())
+}
 
-	return unstructured.SetNestedSlice(u.Object, containers, "spec", "template", "spec", "containers")
+func (m *Manager) setContainerImage(u *unstructured.Unstructured, containerName, image string) error {
+	return m.updateContainer(u, containerName, func(container map[string]interface{}) error {
+		container["image"] = image
+		return nil
+	})
 }
 
 func (m *Manager) GetResourcesToApplyPath() string {
-	return fmt.Sprintf("%s%capply", config.ResourcesPath, os.PathSeparator)
+	return fmt.Sprintf("%s%capply", config.Current().ResourcesPath, os.PathSeparator)
 }
 
 func (m *Manager) GetResourcesToDeletePath() string {
-	return fmt.Sprintf("%s%cdelete", config.ResourcesPath, os.PathSeparator)
+	return fmt.Sprintf("%s%cdelete", config.Current().ResourcesPath, os.PathSeparator)
 }
 
 func (m *Manager) ApplyOrUpdateResources(ctx context.Context, us []*unstructured.Unstructured) error {
@@ -350,13 +388,14 @@ func (m *Manager) WaitForResourcesReadiness(ctx context.Context, us []*unstructu
 }
 
 func (m *Manager) waitForResource(ctx context.Context, u *unstructured.Unstructured) error {
+	cfg := config.Current()
 	now := time.Now()
 	for {
-		if time.Since(now) >= config.ReadyTimeout {
+		if time.Since(now) >= cfg.ReadyTimeout {
 			return fmt.Errorf("timeout waiting for %s %s to be ready", u.GetName(), u.GetKind())
 		}
 
-		ctxWithTimeout, cancel := context.WithTimeout(ctx, config.ReadyCheckInterval)
+		ctxWithTimeout, cancel := context.WithTimeout(ctx, cfg.ReadyCheckInterval)
 		current := &unstructured.Unstructured{}
 		current.SetGroupVersionKind(u.GroupVersionKind())
 		err := m.client.Get(ctxWithTimeout, client.ObjectKey{Name: u.GetName(), Namespace: u.GetNamespace()}, current)
@@ -372,7 +411,7 @@ func (m *Manager) waitForResource(ctx context.Context, u *unstructured.Unstructu
 		select {
 		case <-ctx.Done():
 			return fmt.Errorf("timeout waiting for %s %s to be ready", u.GetName(), u.GetKind())
-		case <-time.After(config.ReadyCheckInterval):
+		case <-time.After(cfg.ReadyCheckInterval):
 		}
 	}
 }
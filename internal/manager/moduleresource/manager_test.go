@@ -266,6 +266,79 @@ var _ = Describe("Module Resource Manager", func() {
 		})
 	})
 
+	Describe("set Deployment proxy environment variables", func() {
+		var originalHttpProxy, originalHttpsProxy, originalNoProxy string
+
+		BeforeEach(func() {
+			originalHttpProxy, originalHttpsProxy, originalNoProxy = config.HttpProxy, config.HttpsProxy, config.NoProxy
+		})
+
+		AfterEach(func() {
+			config.HttpProxy, config.HttpsProxy, config.NoProxy = originalHttpProxy, originalHttpsProxy, originalNoProxy
+		})
+
+		managerContainerEnv := func(deployment *unstructured.Unstructured) []interface{} {
+			containers, found, err := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			managerContainer, ok := containers[0].(map[string]interface{})
+			Expect(ok).To(BeTrue())
+			env, _, err := unstructured.NestedSlice(managerContainer, "env")
+			Expect(err).NotTo(HaveOccurred())
+			return env
+		}
+
+		It("should add configured proxy variables to the manager container", func() {
+			config.HttpProxy = "http://proxy.local:3128"
+			config.HttpsProxy = "http://proxy.local:3129"
+			config.NoProxy = "10.0.0.0/8,.svc"
+
+			objects, err := manager.CreateUnstructuredObjectsFromManifestsDir(moduleResourcesPathToApply)
+			Expect(err).NotTo(HaveOccurred())
+			deployment := findByKindAndName(objects, DeploymentKind, deploymentName)
+			Expect(deployment).NotTo(BeNil())
+
+			Expect(manager.SetDeploymentProxyEnv(deployment)).To(Succeed())
+
+			Expect(managerContainerEnv(deployment)).To(Equal([]interface{}{
+				map[string]interface{}{"name": "HTTP_PROXY", "value": "http://proxy.local:3128"},
+				map[string]interface{}{"name": "HTTPS_PROXY", "value": "http://proxy.local:3129"},
+				map[string]interface{}{"name": "NO_PROXY", "value": "10.0.0.0/8,.svc"},
+			}))
+		})
+
+		It("should remove proxy variables which are no longer configured and keep the other ones", func() {
+			config.HttpProxy = ""
+			config.HttpsProxy = "http://proxy.local:3129"
+			config.NoProxy = ""
+
+			deployment := unstructuredDeployment(false, false)
+			deployment.Object["spec"] = map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name": "manager",
+								"env": []interface{}{
+									map[string]interface{}{"name": "APP_VERSION", "value": "v0.0.1"},
+									map[string]interface{}{"name": "HTTP_PROXY", "value": "http://old-proxy.local:3128"},
+									map[string]interface{}{"name": "NO_PROXY", "value": ".old"},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			Expect(manager.SetDeploymentProxyEnv(deployment)).To(Succeed())
+
+			Expect(managerContainerEnv(deployment)).To(Equal([]interface{}{
+				map[string]interface{}{"name": "APP_VERSION", "value": "v0.0.1"},
+				map[string]interface{}{"name": "HTTPS_PROXY", "value": "http://proxy.local:3129"},
+			}))
+		})
+	})
+
 	Describe("module resources management", func() {
 		var ctx context.Context
 		var savedModuleResourcesPath string
@@ -1,6 +1,11 @@
 package metrics
 
 import (
+	"sync"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/internal/conditions"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -15,6 +20,8 @@ func buildMetricName(subsystem, name string) string {
 
 type WebhookMetrics struct {
 	certsRegenerationCounter prometheus.Counter
+	selfTestSuccessGauge     *prometheus.GaugeVec
+	selfTestFailuresCounter  *prometheus.CounterVec
 }
 
 func NewWebhookMetrics(r prometheus.Registerer) *WebhookMetrics {
@@ -22,8 +29,18 @@ func NewWebhookMetrics(r prometheus.Registerer) *WebhookMetrics {
 		Name: buildMetricName("", "certs_regenerations_total"),
 		Help: "Total number of certs regenerations",
 	})
+	selfTestSuccessGauge := promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+		Name: buildMetricName("", "webhook_self_test_success"),
+		Help: "Indicates if the last dry-run AdmissionReview sent to the webhook succeeded (1) or not (0)",
+	}, []string{"webhook"})
+	selfTestFailuresCounter := promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Name: buildMetricName("", "webhook_self_test_failures_total"),
+		Help: "Total number of failed dry-run AdmissionReviews sent to the webhook",
+	}, []string{"webhook"})
 	m := &WebhookMetrics{
 		certsRegenerationCounter: certRegenCounter,
+		selfTestSuccessGauge:     selfTestSuccessGauge,
+		selfTestFailuresCounter:  selfTestFailuresCounter,
 	}
 	return m
 }
@@ -32,18 +49,33 @@ func (m *WebhookMetrics) IncrementCertsRegenerationCounter() {
 	m.certsRegenerationCounter.Inc()
 }
 
+func (m *WebhookMetrics) SetWebhookSelfTestResult(webhook string, success bool) {
+	if success {
+		m.selfTestSuccessGauge.WithLabelValues(webhook).Set(1)
+		return
+	}
+	m.selfTestSuccessGauge.WithLabelValues(webhook).Set(0)
+	m.selfTestFailuresCounter.WithLabelValues(webhook).Inc()
+}
+
 type ConfigMetrics struct {
 	configMapAppliedGauge prometheus.Gauge
+	rejectedKeysGauge     prometheus.Gauge
 }
 
 func NewConfigMetrics(r prometheus.Registerer) *ConfigMetrics {
 	gauge := promauto.With(r).NewGauge(prometheus.GaugeOpts{
 		Name: buildMetricName("", "custom_config_applied"),
-		Help: "Indicates if the custom configuration ConfigMap is applied (1) or not (0)",
+		Help: "Indicates if the custom configuration ConfigMap is applied (1) or not (0), because it doesn't exist or every key in it was rejected",
+	})
+	rejectedKeys := promauto.With(r).NewGauge(prometheus.GaugeOpts{
+		Name: buildMetricName("", "custom_config_rejected_keys"),
+		Help: "Number of keys in the custom configuration ConfigMap rejected by the last configuration update",
 	})
 
 	m := &ConfigMetrics{
 		configMapAppliedGauge: gauge,
+		rejectedKeysGauge:     rejectedKeys,
 	}
 	return m
 }
@@ -55,3 +87,204 @@ func (m *ConfigMetrics) ConfigMapApplied() {
 func (m *ConfigMetrics) ConfigMapNotApplied() {
 	m.configMapAppliedGauge.Set(0)
 }
+
+func (m *ConfigMetrics) SetRejectedKeys(count int) {
+	m.rejectedKeysGauge.Set(float64(count))
+}
+
+// CertificateMetrics exposes the remaining lifetime of the certificates used by the module.
+// The lifetime is computed when the metric is collected, so it keeps decreasing between reconciliations.
+type CertificateMetrics struct {
+	desc *prometheus.Desc
+
+	mu          sync.Mutex
+	expirations map[string]time.Time
+}
+
+func NewCertificateMetrics(r prometheus.Registerer) *CertificateMetrics {
+	m := &CertificateMetrics{
+		desc: prometheus.NewDesc(
+			buildMetricName("", "certificate_remaining_lifetime_seconds"),
+			"Seconds until the certificate expires, negative for expired certificates",
+			[]string{"certificate"}, nil,
+		),
+		expirations: make(map[string]time.Time),
+	}
+	r.MustRegister(m)
+	return m
+}
+
+var _ prometheus.Collector = (*CertificateMetrics)(nil)
+
+func (m *CertificateMetrics) SetCertificateExpiration(certificate string, notAfter time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expirations[certificate] = notAfter
+}
+
+func (m *CertificateMetrics) DeleteCertificateExpiration(certificate string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.expirations, certificate)
+}
+
+func (m *CertificateMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.desc
+}
+
+func (m *CertificateMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for certificate, notAfter := range m.expirations {
+		ch <- prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, time.Until(notAfter).Seconds(), certificate)
+	}
+}
+
+// DeprovisioningMetrics exposes the progress of the deprovisioning and the throughput of the hard delete of ServiceInstances and ServiceBindings.
+type DeprovisioningMetrics struct {
+	hardDeleteRequestsCounter   *prometheus.CounterVec
+	hardDeletedResourcesCounter *prometheus.CounterVec
+	namespaceDurationHistogram  *prometheus.HistogramVec
+	remainingResourcesGauge     *prometheus.GaugeVec
+	phaseDurationHistogram      *prometheus.HistogramVec
+	softDeleteFallbacksCounter  *prometheus.CounterVec
+}
+
+func NewDeprovisioningMetrics(r prometheus.Registerer) *DeprovisioningMetrics {
+	requestsCounter := promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Name: buildMetricName("", "hard_delete_requests_total"),
+		Help: "Total number of delete requests sent during the hard delete",
+	}, []string{"kind", "result"})
+	resourcesCounter := promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Name: buildMetricName("", "hard_deleted_resources_total"),
+		Help: "Total number of resources requested to be deleted during the hard delete",
+	}, []string{"kind"})
+	durationHistogram := promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    buildMetricName("", "hard_delete_namespace_duration_seconds"),
+		Help:    "Time spent sending the delete requests for a namespace during the hard delete, including the rate limiter wait",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+	}, []string{"kind"})
+	remainingGauge := promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+		Name: buildMetricName("", "deprovisioning_remaining_resources"),
+		Help: "Number of service instances and bindings blocking or left by the deprovisioning, per state",
+	}, []string{"kind", "state"})
+	phaseHistogram := promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    buildMetricName("", "deprovisioning_phase_duration_seconds"),
+		Help:    "Duration of the deprovisioning phases",
+		Buckets: prometheus.ExponentialBuckets(1, 3, 10),
+	}, []string{"phase"})
+	fallbacksCounter := promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Name: buildMetricName("", "deprovisioning_soft_delete_fallbacks_total"),
+		Help: "Total number of fallbacks from the hard delete to the soft delete",
+	}, []string{"reason"})
+
+	return &DeprovisioningMetrics{
+		hardDeleteRequestsCounter:   requestsCounter,
+		hardDeletedResourcesCounter: resourcesCounter,
+		namespaceDurationHistogram:  durationHistogram,
+		remainingResourcesGauge:     remainingGauge,
+		phaseDurationHistogram:      phaseHistogram,
+		softDeleteFallbacksCounter:  fallbacksCounter,
+	}
+}
+
+func (m *DeprovisioningMetrics) ObserveHardDeleteRequest(kind string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.hardDeleteRequestsCounter.WithLabelValues(kind, result).Inc()
+}
+
+func (m *DeprovisioningMetrics) AddHardDeletedResources(kind string, count int) {
+	m.hardDeletedResourcesCounter.WithLabelValues(kind).Add(float64(count))
+}
+
+func (m *DeprovisioningMetrics) ObserveNamespaceHardDelete(kind string, duration time.Duration) {
+	m.namespaceDurationHistogram.WithLabelValues(kind).Observe(duration.Seconds())
+}
+
+func (m *DeprovisioningMetrics) ResetRemainingResources() {
+	m.remainingResourcesGauge.Reset()
+}
+
+func (m *DeprovisioningMetrics) SetRemainingResources(kind, state string, count int) {
+	m.remainingResourcesGauge.WithLabelValues(kind, state).Set(float64(count))
+}
+
+func (m *DeprovisioningMetrics) ObservePhaseDuration(phase string, duration time.Duration) {
+	m.phaseDurationHistogram.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+func (m *DeprovisioningMetrics) IncrementSoftDeleteFallbacks(reason string) {
+	m.softDeleteFallbacksCounter.WithLabelValues(reason).Inc()
+}
+
+// ReconcileMetrics exposes the state of the BtpOperator, the condition reasons it was set to, and the duration of the provisioning phases and drift checks.
+type ReconcileMetrics struct {
+	stateGauge                  *prometheus.GaugeVec
+	conditionReasonsCounter     *prometheus.CounterVec
+	phaseDurationHistogram      *prometheus.HistogramVec
+	driftCheckDurationHistogram *prometheus.HistogramVec
+}
+
+var btpOperatorStates = []v1alpha1.State{v1alpha1.StateProcessing, v1alpha1.StateReady, v1alpha1.StateWarning, v1alpha1.StateError, v1alpha1.StateDeleting}
+
+func NewReconcileMetrics(r prometheus.Registerer) *ReconcileMetrics {
+	stateGauge := promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+		Name: buildMetricName("", "btpoperator_state"),
+		Help: "Indicates the current state of the BtpOperator (1) among all states (0)",
+	}, []string{"state"})
+	reasonsCounter := promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Name: buildMetricName("", "btpoperator_condition_reasons_total"),
+		Help: "Total number of BtpOperator status updates setting the Ready condition to the reason",
+	}, []string{"reason"})
+	phaseHistogram := promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    buildMetricName("", "provisioning_phase_duration_seconds"),
+		Help:    "Duration of the phases of the module resources reconciliation, including the failed ones",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 9),
+	}, []string{"phase"})
+	driftHistogram := promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    buildMetricName("", "drift_check_duration_seconds"),
+		Help:    "Duration of the credentials drift checks",
+		Buckets: prometheus.ExponentialBuckets(0.005, 4, 7),
+	}, []string{"check"})
+
+	// The series exist from the start, so that the dashboards can tell a reason that never occurred from a missing metric.
+	for _, state := range btpOperatorStates {
+		stateGauge.WithLabelValues(string(state)).Set(0)
+	}
+	for reason := range conditions.Reasons {
+		reasonsCounter.WithLabelValues(string(reason))
+	}
+
+	return &ReconcileMetrics{
+		stateGauge:                  stateGauge,
+		conditionReasonsCounter:     reasonsCounter,
+		phaseDurationHistogram:      phaseHistogram,
+		driftCheckDurationHistogram: driftHistogram,
+	}
+}
+
+// SetState sets the gauge of state to 1 and of the other states to 0. An unknown state, such as the initial empty one, sets all of them to 0.
+func (m *ReconcileMetrics) SetState(state v1alpha1.State) {
+	for _, s := range btpOperatorStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		m.stateGauge.WithLabelValues(string(s)).Set(value)
+	}
+}
+
+func (m *ReconcileMetrics) IncrementConditionReason(reason conditions.Reason) {
+	m.conditionReasonsCounter.WithLabelValues(string(reason)).Inc()
+}
+
+func (m *ReconcileMetrics) ObserveProvisioningPhase(phase string, duration time.Duration) {
+	m.phaseDurationHistogram.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+func (m *ReconcileMetrics) ObserveDriftCheck(check string, duration time.Duration) {
+	m.driftCheckDurationHistogram.WithLabelValues(check).Observe(duration.Seconds())
+}
@@ -0,0 +1,235 @@
+package operandexport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// SecretName is the name of the Secret in the ChartNamespace holding the exported ServiceInstances and ServiceBindings.
+	// The Secret is not labeled as managed by btp-manager, so it survives the module resources cleanup.
+	SecretName = "btp-manager-operand-export"
+
+	ResourcesKey        = "resources.json.gz"
+	ExportedAtKey       = "exportedAt"
+	ServiceInstancesKey = "serviceInstances"
+	ServiceBindingsKey  = "serviceBindings"
+
+	// ExportedForAnnotation holds the UID of the BtpOperator CR whose deletion triggered the export.
+	ExportedForAnnotation = "operator.kyma-project.io/exported-for"
+	// RestoredAtAnnotation marks the export as restored.
+	RestoredAtAnnotation = "operator.kyma-project.io/restored-at"
+
+	lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+var (
+	instanceGvk = schema.GroupVersionKind{Group: "services.cloud.sap.com", Version: "v1", Kind: "ServiceInstance"}
+	bindingGvk  = schema.GroupVersionKind{Group: "services.cloud.sap.com", Version: "v1", Kind: "ServiceBinding"}
+)
+
+// Exporter exports ServiceInstances and ServiceBindings before deprovisioning and restores them after the next provisioning,
+// so that the SAP BTP service operator adopts the existing SAP BTP resources instead of orphaning them.
+type Exporter struct {
+	client    client.Client
+	apiReader client.Reader
+	now       func() time.Time
+}
+
+func NewExporter(k8sClient client.Client, apiReader client.Reader) *Exporter {
+	return &Exporter{
+		client:    k8sClient,
+		apiReader: apiReader,
+		now:       time.Now,
+	}
+}
+
+// Export writes the specs of all ServiceInstances and ServiceBindings to the SecretName Secret.
+// The export is written once per deletion of the CR, so that a retried deprovisioning does not overwrite it with partially deleted resources.
+// Nothing is written when there are no ServiceInstances and ServiceBindings.
+func (e *Exporter) Export(ctx context.Context, cr *v1alpha1.BtpOperator) error {
+	logger := log.FromContext(ctx)
+	cfg := config.Current()
+
+	secret := &corev1.Secret{}
+	err := e.apiReader.Get(ctx, client.ObjectKey{Name: SecretName, Namespace: cfg.ChartNamespace}, secret)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("while getting %s secret: %w", SecretName, err)
+	}
+	exists := err == nil
+	if exists && cr.GetUID() != "" && secret.Annotations[ExportedForAnnotation] == string(cr.GetUID()) {
+		logger.Info("service instances and bindings already exported", "secret", SecretName)
+		return nil
+	}
+
+	instances, err := e.list(ctx, instanceGvk)
+	if err != nil {
+		return err
+	}
+	bindings, err := e.list(ctx, bindingGvk)
+	if err != nil {
+		return err
+	}
+	if len(instances) == 0 && len(bindings) == 0 {
+		return nil
+	}
+
+	resources := make([]map[string]interface{}, 0, len(instances)+len(bindings))
+	for _, item := range append(instances, bindings...) {
+		resources = append(resources, exportable(item))
+	}
+	data, err := compress(resources)
+	if err != nil {
+		return err
+	}
+
+	secret.Name, secret.Namespace = SecretName, cfg.ChartNamespace
+	secret.Type = corev1.SecretTypeOpaque
+	secret.Annotations = map[string]string{ExportedForAnnotation: string(cr.GetUID())}
+	secret.Data = map[string][]byte{
+		ResourcesKey:        data,
+		ExportedAtKey:       []byte(e.now().UTC().Format(time.RFC3339)),
+		ServiceInstancesKey: []byte(strconv.Itoa(len(instances))),
+		ServiceBindingsKey:  []byte(strconv.Itoa(len(bindings))),
+	}
+	if exists {
+		err = e.client.Update(ctx, secret)
+	} else {
+		err = e.client.Create(ctx, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("while writing %s secret: %w", SecretName, err)
+	}
+	logger.Info("exported service instances and bindings", "secret", SecretName, "instances", len(instances), "bindings", len(bindings))
+	return nil
+}
+
+// Restore re-creates the exported ServiceInstances and ServiceBindings when config.RestoreServiceInstancesAndBindings is enabled.
+// Existing resources are left unchanged. The export is marked as restored once all resources exist.
+func (e *Exporter) Restore(ctx context.Context) error {
+	cfg := config.Current()
+	if !cfg.RestoreServiceInstancesAndBindings {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	secret := &corev1.Secret{}
+	if err := e.apiReader.Get(ctx, client.ObjectKey{Name: SecretName, Namespace: cfg.ChartNamespace}, secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("while getting %s secret: %w", SecretName, err)
+	}
+	if secret.Annotations[RestoredAtAnnotation] != "" {
+		return nil
+	}
+
+	resources, err := decompress(secret.Data[ResourcesKey])
+	if err != nil {
+		return err
+	}
+	created := 0
+	for _, resource := range resources {
+		u := &unstructured.Unstructured{Object: resource}
+		if err := e.client.Create(ctx, u); err != nil {
+			if k8serrors.IsAlreadyExists(err) {
+				continue
+			}
+			return fmt.Errorf("while restoring %s %s/%s: %w", u.GetKind(), u.GetNamespace(), u.GetName(), err)
+		}
+		created++
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[RestoredAtAnnotation] = e.now().UTC().Format(time.RFC3339)
+	if err := e.client.Update(ctx, secret); err != nil {
+		return fmt.Errorf("while marking %s secret as restored: %w", SecretName, err)
+	}
+	logger.Info("restored service instances and bindings", "secret", SecretName, "created", created, "exported", len(resources))
+	return nil
+}
+
+func (e *Exporter) list(ctx context.Context, gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := e.client.List(ctx, list, client.InNamespace(corev1.NamespaceAll)); err != nil {
+		if k8serrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("while listing %s resources: %w", gvk.Kind, err)
+	}
+	return list.Items, nil
+}
+
+// exportable returns the resource without its status, server-set metadata and the user info set by the SAP BTP service operator webhook.
+// Credentials are not exported: binding Secrets and Secrets referenced in parametersFrom are not part of the resources,
+// and the last applied configuration annotation is dropped.
+func exportable(item unstructured.Unstructured) map[string]interface{} {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(item.GetAPIVersion())
+	u.SetKind(item.GetKind())
+	u.SetName(item.GetName())
+	u.SetNamespace(item.GetNamespace())
+	u.SetLabels(item.GetLabels())
+	annotations := item.GetAnnotations()
+	delete(annotations, lastAppliedConfigAnnotation)
+	if len(annotations) > 0 {
+		u.SetAnnotations(annotations)
+	}
+	if spec, found, _ := unstructured.NestedMap(item.Object, "spec"); found {
+		delete(spec, "userInfo")
+		u.Object["spec"] = spec
+	}
+	return u.Object
+}
+
+func compress(resources []map[string]interface{}) ([]byte, error) {
+	raw, err := json.Marshal(resources)
+	if err != nil {
+		return nil, fmt.Errorf("while marshalling exported resources: %w", err)
+	}
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(raw); err != nil {
+		return nil, fmt.Errorf("while compressing exported resources: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("while compressing exported resources: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) ([]map[string]interface{}, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("while decompressing exported resources: %w", err)
+	}
+	defer r.Close()
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("while decompressing exported resources: %w", err)
+	}
+	resources := make([]map[string]interface{}, 0)
+	if err := json.Unmarshal(raw, &resources); err != nil {
+		return nil, fmt.Errorf("while unmarshalling exported resources: %w", err)
+	}
+	return resources, nil
+}
@@ -0,0 +1,159 @@
+package operandexport_test
+
+import (
+	"context"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/operandexport"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Operand Exporter", func() {
+	var (
+		ctx           context.Context
+		k8sClient     client.Client
+		exporter      *operandexport.Exporter
+		cr            *v1alpha1.BtpOperator
+		origNamespace string
+		origRestore   bool
+	)
+
+	secretKey := client.ObjectKey{Name: operandexport.SecretName, Namespace: kymaNamespace}
+
+	newExporter := func(objs ...client.Object) {
+		k8sClient = newFakeClient(objs...)
+		exporter = operandexport.NewExporter(k8sClient, k8sClient)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		origNamespace, origRestore = config.ChartNamespace, config.RestoreServiceInstancesAndBindings
+		config.ChartNamespace = kymaNamespace
+		cr = &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{UID: types.UID("cr-uid")}}
+	})
+
+	AfterEach(func() {
+		config.ChartNamespace, config.RestoreServiceInstancesAndBindings = origNamespace, origRestore
+	})
+
+	Describe("Export", func() {
+		It("exports the specs without status, credentials and webhook user info", func() {
+			instance := operand(instanceGvk, "ns-a", "instance-1")
+			instance.SetAnnotations(map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"})
+			Expect(unstructured.SetNestedField(instance.Object, "Ready", "status", "conditions")).To(Succeed())
+			Expect(unstructured.SetNestedField(instance.Object, "admin", "spec", "userInfo", "username")).To(Succeed())
+			newExporter(instance, operand(bindingGvk, "ns-a", "binding-1"))
+
+			Expect(exporter.Export(ctx, cr)).To(Succeed())
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, secretKey, secret)).To(Succeed())
+			Expect(secret.Labels).NotTo(HaveKey("app.kubernetes.io/managed-by"))
+			Expect(secret.Annotations[operandexport.ExportedForAnnotation]).To(Equal("cr-uid"))
+			Expect(string(secret.Data[operandexport.ServiceInstancesKey])).To(Equal("1"))
+			Expect(string(secret.Data[operandexport.ServiceBindingsKey])).To(Equal("1"))
+
+			config.RestoreServiceInstancesAndBindings = true
+			restoreClient := newFakeClient(secret)
+			Expect(operandexport.NewExporter(restoreClient, restoreClient).Restore(ctx)).To(Succeed())
+			restored := operand(instanceGvk, "", "")
+			Expect(restoreClient.Get(ctx, client.ObjectKey{Name: "instance-1", Namespace: "ns-a"}, restored)).To(Succeed())
+			Expect(restored.Object).NotTo(HaveKey("status"))
+			Expect(restored.GetAnnotations()).To(BeEmpty())
+			Expect(restored.Object["spec"]).To(Equal(map[string]interface{}{"serviceOfferingName": "offering"}))
+		})
+
+		It("does not overwrite the export for the same CR", func() {
+			newExporter(operand(instanceGvk, "ns-a", "instance-1"), operand(instanceGvk, "ns-a", "instance-2"))
+			Expect(exporter.Export(ctx, cr)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, operand(instanceGvk, "ns-a", "instance-2"))).To(Succeed())
+
+			Expect(exporter.Export(ctx, cr)).To(Succeed())
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, secretKey, secret)).To(Succeed())
+			Expect(string(secret.Data[operandexport.ServiceInstancesKey])).To(Equal("2"))
+		})
+
+		It("replaces the export of a previous CR", func() {
+			newExporter(operand(instanceGvk, "ns-a", "instance-1"))
+			Expect(exporter.Export(ctx, &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{UID: types.UID("previous")}})).To(Succeed())
+			Expect(k8sClient.Create(ctx, operand(instanceGvk, "ns-b", "instance-2"))).To(Succeed())
+
+			Expect(exporter.Export(ctx, cr)).To(Succeed())
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, secretKey, secret)).To(Succeed())
+			Expect(secret.Annotations[operandexport.ExportedForAnnotation]).To(Equal("cr-uid"))
+			Expect(string(secret.Data[operandexport.ServiceInstancesKey])).To(Equal("2"))
+		})
+
+		It("does not write an export without service instances and bindings", func() {
+			newExporter()
+
+			Expect(exporter.Export(ctx, cr)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, secretKey, &corev1.Secret{})).NotTo(Succeed())
+		})
+	})
+
+	Describe("Restore", func() {
+		exportFrom := func(objs ...client.Object) *corev1.Secret {
+			newExporter(objs...)
+			Expect(exporter.Export(ctx, cr)).To(Succeed())
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, secretKey, secret)).To(Succeed())
+			secret.ResourceVersion = ""
+			return secret
+		}
+
+		It("does nothing unless enabled", func() {
+			newExporter(exportFrom(operand(instanceGvk, "ns-a", "instance-1")))
+
+			Expect(exporter.Restore(ctx)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "instance-1", Namespace: "ns-a"}, operand(instanceGvk, "", ""))).NotTo(Succeed())
+		})
+
+		It("re-creates missing resources once and keeps existing ones", func() {
+			config.RestoreServiceInstancesAndBindings = true
+			secret := exportFrom(operand(instanceGvk, "ns-a", "instance-1"), operand(bindingGvk, "ns-a", "binding-1"))
+			existing := operand(instanceGvk, "ns-a", "instance-1")
+			existing.SetLabels(map[string]string{"kept": "true"})
+			newExporter(secret, existing)
+
+			Expect(exporter.Restore(ctx)).To(Succeed())
+
+			binding := operand(bindingGvk, "", "")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "binding-1", Namespace: "ns-a"}, binding)).To(Succeed())
+			instance := operand(instanceGvk, "", "")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "instance-1", Namespace: "ns-a"}, instance)).To(Succeed())
+			Expect(instance.GetLabels()).To(HaveKeyWithValue("kept", "true"))
+			Expect(k8sClient.Get(ctx, secretKey, secret)).To(Succeed())
+			Expect(secret.Annotations).To(HaveKey(operandexport.RestoredAtAnnotation))
+
+			Expect(k8sClient.Delete(ctx, binding)).To(Succeed())
+			Expect(exporter.Restore(ctx)).To(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "binding-1", Namespace: "ns-a"}, operand(bindingGvk, "", ""))).NotTo(Succeed())
+		})
+	})
+})
+
+func operand(gvk schema.GroupVersionKind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetGroupVersionKind(gvk)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	if name != "" {
+		u.Object["spec"] = map[string]interface{}{"serviceOfferingName": "offering"}
+	}
+	return u
+}
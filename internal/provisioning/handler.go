@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/kyma-project/btp-manager/api/v1alpha1"
 	"github.com/kyma-project/btp-manager/controllers/config"
@@ -12,6 +13,7 @@ import (
 	"github.com/kyma-project/btp-manager/internal/credentials/drift"
 	"github.com/kyma-project/btp-manager/internal/k8s/networkpolicy"
 	"github.com/kyma-project/btp-manager/internal/manager/moduleresource"
+	"github.com/kyma-project/btp-manager/internal/trustbundle"
 	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -32,6 +34,28 @@ type ProvisionResult struct {
 	ErrorReason   *conditions.ErrorWithReason
 }
 
+// Phases of the module resources reconciliation.
+const (
+	phaseRender           = "render"
+	phasePrepare          = "prepare"
+	phaseWebhooks         = "webhooks"
+	phaseApply            = "apply"
+	phaseWaitForReadiness = "wait_for_readiness"
+)
+
+// Credentials drift checks.
+const (
+	driftCheckCredentialsNamespace = "credentials_namespace"
+	driftCheckClusterIdConfigMap   = "cluster_id_configmap"
+	driftCheckClusterIdSecret      = "cluster_id_secret"
+)
+
+// ProvisioningMetrics records the duration of the module resources reconciliation phases and of the drift checks.
+type ProvisioningMetrics interface {
+	ObserveProvisioningPhase(phase string, duration time.Duration)
+	ObserveDriftCheck(check string, duration time.Duration)
+}
+
 // Handler runs the provisioning flow and exposes helpers used by the Ready state path.
 type Handler interface {
 	Provision(ctx context.Context, cr *v1alpha1.BtpOperator) ProvisionResult
@@ -46,7 +70,9 @@ type handler struct {
 	moduleResourceManager  moduleresource.ResourceManager
 	networkPolicyManager   networkpolicy.NetworkPolicyManager
 	certManager            certificate.CertificateManager
+	trustBundleManager     trustbundle.TrustBundleManager
 	instanceBindingService InstanceBindingService
+	metrics                ProvisioningMetrics
 }
 
 func NewHandler(
@@ -55,7 +81,9 @@ func NewHandler(
 	moduleResourceManager moduleresource.ResourceManager,
 	networkPolicyManager networkpolicy.NetworkPolicyManager,
 	certManager certificate.CertificateManager,
+	trustBundleManager trustbundle.TrustBundleManager,
 	instanceBindingService InstanceBindingService,
+	metrics ProvisioningMetrics,
 ) Handler {
 	return &handler{
 		client:                 c,
@@ -63,7 +91,9 @@ func NewHandler(
 		moduleResourceManager:  moduleResourceManager,
 		networkPolicyManager:   networkPolicyManager,
 		certManager:            certManager,
+		trustBundleManager:     trustBundleManager,
 		instanceBindingService: instanceBindingService,
+		metrics:                metrics,
 	}
 }
 
@@ -83,15 +113,15 @@ func (h *handler) Provision(ctx context.Context, cr *v1alpha1.BtpOperator) Provi
 
 	h.driftDetector.InitializeFromSecret(requiredSecret)
 
-	if errWithReason := h.driftDetector.CheckCredentialsNamespaceDrift(ctx, requiredSecret); errWithReason != nil {
+	if errWithReason := h.checkDrift(ctx, driftCheckCredentialsNamespace, h.driftDetector.CheckCredentialsNamespaceDrift, requiredSecret); errWithReason != nil {
 		return ProvisionResult{ErrorReason: errWithReason}
 	}
 
-	if errWithReason := h.driftDetector.CheckClusterIdConfigMapDrift(ctx, requiredSecret); errWithReason != nil {
+	if errWithReason := h.checkDrift(ctx, driftCheckClusterIdConfigMap, h.driftDetector.CheckClusterIdConfigMapDrift, requiredSecret); errWithReason != nil {
 		return ProvisionResult{ErrorReason: errWithReason}
 	}
 
-	if errWithReason := h.driftDetector.ResolveClusterIdSecretDrift(ctx, requiredSecret); errWithReason != nil {
+	if errWithReason := h.checkDrift(ctx, driftCheckClusterIdSecret, h.driftDetector.ResolveClusterIdSecretDrift, requiredSecret); errWithReason != nil {
 		return ProvisionResult{ErrorReason: errWithReason}
 	}
 
@@ -113,7 +143,13 @@ func (h *handler) Provision(ctx context.Context, cr *v1alpha1.BtpOperator) Provi
 	return ProvisionResult{}
 }
 
-var errSecretNotFound = fmt.Errorf("%s Secret in %s namespace not found", config.SecretName, config.ChartNamespace)
+func (h *handler) checkDrift(ctx context.Context, check string, fn func(context.Context, *corev1.Secret) *conditions.ErrorWithReason, secret *corev1.Secret) *conditions.ErrorWithReason {
+	start := time.Now()
+	defer func() { h.metrics.ObserveDriftCheck(check, time.Since(start)) }()
+	return fn(ctx, secret)
+}
+
+var errSecretNotFound = fmt.Errorf("%s Secret in %s namespace not found", config.Current().SecretName, config.Current().ChartNamespace)
 
 func (h *handler) GetAndVerifyRequiredSecret(ctx context.Context) (*corev1.Secret, *conditions.ErrorWithReason) {
 	logger := log.FromContext(ctx)
@@ -137,8 +173,9 @@ func (h *handler) GetAndVerifyRequiredSecret(ctx context.Context) (*corev1.Secre
 }
 
 func (h *handler) getRequiredSecret(ctx context.Context) (*corev1.Secret, error) {
+	cfg := config.Current()
 	secret := &corev1.Secret{}
-	objKey := client.ObjectKey{Namespace: config.ChartNamespace, Name: config.SecretName}
+	objKey := client.ObjectKey{Namespace: cfg.ChartNamespace, Name: cfg.SecretName}
 	if err := h.client.Get(ctx, objKey, secret); err != nil {
 		if k8serrors.IsNotFound(err) {
 			return nil, errSecretNotFound
@@ -157,6 +194,8 @@ func (h *handler) ReconcileReady(ctx context.Context, cr *v1alpha1.BtpOperator,
 
 func (h *handler) reconcileResources(ctx context.Context, cr *v1alpha1.BtpOperator, s *corev1.Secret) error {
 	logger := log.FromContext(ctx)
+	timer := h.startPhase(phaseRender)
+	defer timer.stop()
 
 	logger.Info("getting module resources to apply")
 	resourcesToApply, err := h.moduleResourceManager.CreateUnstructuredObjectsFromManifestsDir(h.moduleResourceManager.GetResourcesToApplyPath())
@@ -177,6 +216,10 @@ func (h *handler) reconcileResources(ctx context.Context, cr *v1alpha1.BtpOperat
 		if err := h.addNetworkPoliciesToResources(ctx, &resourcesToApply); err != nil {
 			return err
 		}
+		if err := h.deleteStaleProxyNetworkPolicy(ctx); err != nil {
+			logger.Error(err, "while deleting stale proxy network policy")
+			return fmt.Errorf("failed to delete stale proxy network policy: %w", err)
+		}
 	}
 
 	if err := h.deleteOldWebhookNetworkPolicy(ctx); err != nil {
@@ -184,11 +227,18 @@ func (h *handler) reconcileResources(ctx context.Context, cr *v1alpha1.BtpOperat
 		return fmt.Errorf("failed to delete old webhook network policy: %w", err)
 	}
 
+	timer.next(phasePrepare)
 	if err = h.moduleResourceManager.PrepareModuleResources(ctx, resourcesToApply, s); err != nil {
 		logger.Error(err, "while preparing objects to apply")
 		return fmt.Errorf("failed to prepare objects to apply: %w", err)
 	}
 
+	if err = h.prepareTrustBundle(ctx, resourcesToApply); err != nil {
+		logger.Error(err, "while mounting custom CA trust bundle")
+		return fmt.Errorf("failed to mount custom CA trust bundle: %w", err)
+	}
+
+	timer.next(phaseWebhooks)
 	webhookResources, nonWebhookResources := certificate.PartitionWebhooks(resourcesToApply)
 	preparedWebhooks, err := h.certManager.PrepareAdmissionWebhooks(ctx, webhookResources)
 	if err != nil {
@@ -197,6 +247,7 @@ func (h *handler) reconcileResources(ctx context.Context, cr *v1alpha1.BtpOperat
 	}
 	resourcesToApply = append(nonWebhookResources, preparedWebhooks...)
 
+	timer.next(phaseApply)
 	h.moduleResourceManager.DeleteCreationTimestamp(resourcesToApply...)
 
 	logger.Info(fmt.Sprintf("applying module resources for %d resources", len(resourcesToApply)))
@@ -205,6 +256,7 @@ func (h *handler) reconcileResources(ctx context.Context, cr *v1alpha1.BtpOperat
 		return fmt.Errorf("failed to apply module resources: %w", err)
 	}
 
+	timer.next(phaseWaitForReadiness)
 	logger.Info("waiting for module resources readiness")
 	if err = h.moduleResourceManager.WaitForResourcesReadiness(ctx, resourcesToApply); err != nil {
 		logger.Error(err, "while waiting for module resources readiness")
@@ -214,6 +266,27 @@ func (h *handler) reconcileResources(ctx context.Context, cr *v1alpha1.BtpOperat
 	return nil
 }
 
+// phaseTimer records the duration of each phase of the module resources reconciliation.
+// Stopping it records the current phase, so the phase a failed reconciliation stopped in is recorded as well.
+type phaseTimer struct {
+	metrics ProvisioningMetrics
+	phase   string
+	start   time.Time
+}
+
+func (h *handler) startPhase(phase string) *phaseTimer {
+	return &phaseTimer{metrics: h.metrics, phase: phase, start: time.Now()}
+}
+
+func (t *phaseTimer) next(phase string) {
+	t.stop()
+	t.phase, t.start = phase, time.Now()
+}
+
+func (t *phaseTimer) stop() {
+	t.metrics.ObserveProvisioningPhase(t.phase, time.Since(t.start))
+}
+
 func (h *handler) ReconcileResourcesWithoutStatusChange(ctx context.Context, cr *v1alpha1.BtpOperator) {
 	logger := log.FromContext(ctx)
 	secret, errWithReason := h.GetAndVerifyRequiredSecret(ctx)
@@ -258,6 +331,20 @@ func (h *handler) deleteOldWebhookNetworkPolicy(ctx context.Context) error {
 	return h.networkPolicyManager.DeleteOldWebhookNetworkPolicy(ctx)
 }
 
+func (h *handler) deleteStaleProxyNetworkPolicy(ctx context.Context) error {
+	if h.networkPolicyManager == nil {
+		return nil
+	}
+	return h.networkPolicyManager.DeleteStaleProxyNetworkPolicy(ctx)
+}
+
+func (h *handler) prepareTrustBundle(ctx context.Context, resourcesToApply []*unstructured.Unstructured) error {
+	if h.trustBundleManager == nil {
+		return nil
+	}
+	return h.trustBundleManager.PrepareDeployment(ctx, resourcesToApply)
+}
+
 func verifySecret(secret *corev1.Secret) error {
 	missingKeys := make([]string, 0)
 	missingValues := make([]string, 0)
@@ -1,9 +1,17 @@
 package provisioning
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/internal/manager/moduleresource"
+	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func TestVerifySecret_AllKeysPresent(t *testing.T) {
@@ -46,3 +54,81 @@ func TestVerifySecret_EmptyValue(t *testing.T) {
 		t.Fatal("expected error for empty value, got nil")
 	}
 }
+
+func TestReconcileResources_ObservesPhases(t *testing.T) {
+	t.Run("observes every phase up to the failed readiness wait", func(t *testing.T) {
+		metrics := &fakeProvisioningMetrics{}
+		resourceManager := &fakeResourceManager{readinessErr: errors.New("timeout")}
+		h := &handler{moduleResourceManager: resourceManager, certManager: &fakeCertManager{}, metrics: metrics}
+
+		if err := h.reconcileResources(context.Background(), &v1alpha1.BtpOperator{}, &corev1.Secret{}); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		expected := []string{phaseRender, phasePrepare, phaseWebhooks, phaseApply, phaseWaitForReadiness}
+		if !reflect.DeepEqual(metrics.phases, expected) {
+			t.Fatalf("expected phases %v, got %v", expected, metrics.phases)
+		}
+	})
+
+	t.Run("stops observing at the failed phase", func(t *testing.T) {
+		metrics := &fakeProvisioningMetrics{}
+		resourceManager := &fakeResourceManager{prepareErr: errors.New("prepare failed")}
+		h := &handler{moduleResourceManager: resourceManager, certManager: &fakeCertManager{}, metrics: metrics}
+
+		if err := h.reconcileResources(context.Background(), &v1alpha1.BtpOperator{}, &corev1.Secret{}); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		expected := []string{phaseRender, phasePrepare}
+		if !reflect.DeepEqual(metrics.phases, expected) {
+			t.Fatalf("expected phases %v, got %v", expected, metrics.phases)
+		}
+	})
+}
+
+type fakeProvisioningMetrics struct {
+	phases []string
+}
+
+func (m *fakeProvisioningMetrics) ObserveProvisioningPhase(phase string, _ time.Duration) {
+	m.phases = append(m.phases, phase)
+}
+
+func (m *fakeProvisioningMetrics) ObserveDriftCheck(string, time.Duration) {}
+
+type fakeResourceManager struct {
+	moduleresource.ResourceManager
+	prepareErr   error
+	readinessErr error
+}
+
+func (m *fakeResourceManager) CreateUnstructuredObjectsFromManifestsDir(string) ([]*unstructured.Unstructured, error) {
+	return []*unstructured.Unstructured{}, nil
+}
+
+func (m *fakeResourceManager) GetResourcesToApplyPath() string {
+	return ""
+}
+
+func (m *fakeResourceManager) PrepareModuleResources(context.Context, []*unstructured.Unstructured, *corev1.Secret) error {
+	return m.prepareErr
+}
+
+func (m *fakeResourceManager) DeleteCreationTimestamp(...*unstructured.Unstructured) {}
+
+func (m *fakeResourceManager) ApplyOrUpdateResources(context.Context, []*unstructured.Unstructured) error {
+	return nil
+}
+
+func (m *fakeResourceManager) WaitForResourcesReadiness(context.Context, []*unstructured.Unstructured) error {
+	return m.readinessErr
+}
+
+type fakeCertManager struct {
+	certificate.CertificateManager
+}
+
+func (m *fakeCertManager) PrepareAdmissionWebhooks(_ context.Context, webhookResources []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	return webhookResources, nil
+}
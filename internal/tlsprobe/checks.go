@@ -0,0 +1,157 @@
+package tlsprobe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Connectivity checks run by the probe.
+const (
+	CheckDNSTokenURL = "dns-token-url"
+	CheckTLSTokenURL = "tls-token-url"
+	CheckDNSSMURL    = "dns-sm-url"
+	CheckTLSSMURL    = "tls-sm-url"
+	CheckProxy       = "proxy"
+	CheckOAuthToken  = "oauth-token"
+)
+
+// tokenURLSuffix is appended to the token URL from the credentials, the same way the SAP BTP service operator requests tokens.
+const tokenURLSuffix = "/oauth/token"
+
+// Target is the SAP Service Manager the probe checks connectivity to, as read from the sap-btp-manager Secret.
+// SMURL and the client credentials are optional; the checks that need them are skipped if they are empty.
+type Target struct {
+	TokenURL     string
+	SMURL        string
+	ClientID     string
+	ClientSecret string
+}
+
+// check runs the function and returns its timed result.
+func check(name string, run func() error) v1alpha1.ProbeCheck {
+	start := time.Now()
+	err := run()
+	result := v1alpha1.ProbeCheck{Name: name, Success: err == nil, Duration: metav1.Duration{Duration: time.Since(start)}}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// hostPort returns the address to dial for the URL, with the default HTTPS port if none is set.
+func hostPort(u *url.URL) string {
+	if u.Port() == "" {
+		return net.JoinHostPort(u.Hostname(), "443")
+	}
+	return u.Host
+}
+
+// endpointChecks checks DNS resolution, or the proxy for a proxied host, and the TLS handshake for the URL.
+// DNS is not checked for a proxied host, because the proxy resolves it.
+// It returns the TLS result and the certificate chains of the URL next to the checks, see dialTLS.
+func endpointChecks(ctx context.Context, u *url.URL, dnsCheck, tlsCheck string, pool *x509.CertPool, proxy ProxyFunc, checkedProxies map[string]bool) ([]v1alpha1.ProbeCheck, string, [][]*x509.Certificate) {
+	checks := make([]v1alpha1.ProbeCheck, 0, 2)
+	addr := hostPort(u)
+
+	proxyURL, err := proxy(&url.URL{Scheme: "https", Host: addr})
+	switch {
+	case err != nil:
+		checks = append(checks, check(CheckProxy, func() error { return fmt.Errorf("resolve proxy: %w", err) }))
+	case proxyURL == nil:
+		checks = append(checks, check(dnsCheck, func() error { return lookupHost(ctx, u.Hostname()) }))
+	case !checkedProxies[proxyURL.Host]:
+		checkedProxies[proxyURL.Host] = true
+		checks = append(checks, check(CheckProxy, func() error { return dialProxy(ctx, proxyURL) }))
+	}
+
+	var tlsResult string
+	var chains [][]*x509.Certificate
+	tlsCheckResult := check(tlsCheck, func() error {
+		tlsResult, chains = dialTLS(addr, pool, proxy)
+		if tlsResult != TLSResultOK {
+			return fmt.Errorf("TLS handshake with %s: %s", addr, tlsResult)
+		}
+		return nil
+	})
+	return append(checks, tlsCheckResult), tlsResult, chains
+}
+
+func lookupHost(ctx context.Context, host string) error {
+	lookupCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(lookupCtx, host)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("no addresses found for %s", host)
+	}
+	return nil
+}
+
+func dialProxy(ctx context.Context, proxyURL *url.URL) error {
+	if err := checkProxyScheme(proxyURL); err != nil {
+		return err
+	}
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return fmt.Errorf("dial proxy: %w", err)
+	}
+	return conn.Close()
+}
+
+// requestToken runs the OAuth client credentials flow against the token URL and verifies that an access token is returned.
+func requestToken(ctx context.Context, target Target, pool *x509.CertPool, proxy ProxyFunc) error {
+	httpClient := &http.Client{
+		Timeout: dialTimeout,
+		Transport: &http.Transport{
+			Proxy:           func(r *http.Request) (*url.URL, error) { return proxy(r.URL) },
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}
+	defer httpClient.CloseIdleConnections()
+
+	form := url.Values{"grant_type": {"client_credentials"}, "client_id": {target.ClientID}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(target.TokenURL, "/")+tokenURLSuffix, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(target.ClientID), url.QueryEscape(target.ClientSecret))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request token: %s", resp.Status)
+	}
+	token := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return fmt.Errorf("decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("token response does not contain an access token")
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package tlsprobe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServiceManager starts a TLS server standing in for both the token URL and the SAP Service Manager URL.
+// It issues a token for the client credentials clientid:clientsecret.
+func newServiceManager(t *testing.T) (*httptest.Server, MountSignal) {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != tokenURLSuffix {
+			return
+		}
+		id, secret, ok := r.BasicAuth()
+		if r.Method != http.MethodPost || r.FormValue("grant_type") != "client_credentials" || !ok || id != "clientid" || secret != "clientsecret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"bearer"}`))
+	}))
+	t.Cleanup(srv.Close)
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	return srv, MountSignal{}.WithTrustBundle(serverCA)
+}
+
+// newSelfSignedServer starts a TLS server with its own self-signed certificate, which the httptest certificate does not chain to.
+func newSelfSignedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "self-signed"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func checkNames(checks []v1alpha1.ProbeCheck) []string {
+	names := make([]string, 0, len(checks))
+	for _, c := range checks {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestRun_AllChecksPass(t *testing.T) {
+	srv, mount := newServiceManager(t)
+	target := Target{TokenURL: srv.URL, SMURL: srv.URL, ClientID: "clientid", ClientSecret: "clientsecret"}
+
+	status := Run(context.Background(), target, mount, noProxy)
+
+	assert.Equal(t, v1alpha1.ProbeResultOK, status.Result)
+	assert.Equal(t, []string{CheckDNSTokenURL, CheckTLSTokenURL, CheckDNSSMURL, CheckTLSSMURL, CheckOAuthToken}, checkNames(status.Checks))
+	for _, c := range status.Checks {
+		assert.True(t, c.Success, "check %s failed: %s", c.Name, c.Error)
+		assert.Positive(t, c.Duration.Duration, "check %s is not timed", c.Name)
+	}
+}
+
+func TestRun_OAuthTokenRejected(t *testing.T) {
+	srv, mount := newServiceManager(t)
+	target := Target{TokenURL: srv.URL, ClientID: "clientid", ClientSecret: "wrong"}
+
+	status := Run(context.Background(), target, mount, noProxy)
+
+	assert.Equal(t, v1alpha1.ProbeResultOK, status.Result, "the result depends only on the token URL handshake")
+	require.Len(t, status.Checks, 3)
+	oauth := status.Checks[2]
+	assert.Equal(t, CheckOAuthToken, oauth.Name)
+	assert.False(t, oauth.Success)
+	assert.Contains(t, oauth.Error, "401")
+}
+
+func TestRun_SMURLNotTrusted(t *testing.T) {
+	srv, mount := newServiceManager(t)
+	other := newSelfSignedServer(t)
+
+	status := Run(context.Background(), Target{TokenURL: srv.URL, SMURL: other.URL}, mount, noProxy)
+
+	assert.Equal(t, v1alpha1.ProbeResultOK, status.Result)
+	require.Len(t, status.Checks, 4)
+	assert.True(t, status.Checks[2].Success)
+	assert.Equal(t, CheckTLSSMURL, status.Checks[3].Name)
+	assert.False(t, status.Checks[3].Success)
+	assert.Contains(t, status.Checks[3].Error, TLSResultX509)
+}
+
+func TestRun_ThroughProxy(t *testing.T) {
+	srv, mount := newServiceManager(t)
+	proxy, connects := newConnectProxy(t)
+	target := Target{TokenURL: srv.URL, SMURL: srv.URL, ClientID: "clientid", ClientSecret: "clientsecret"}
+
+	status := Run(context.Background(), target, mount, proxyTo(t, proxy.URL))
+
+	assert.Equal(t, v1alpha1.ProbeResultOK, status.Result)
+	assert.Equal(t, []string{CheckProxy, CheckTLSTokenURL, CheckTLSSMURL, CheckOAuthToken}, checkNames(status.Checks), "DNS is resolved by the proxy and the proxy is checked once")
+	for _, c := range status.Checks {
+		assert.True(t, c.Success, "check %s failed: %s", c.Name, c.Error)
+	}
+	assert.Equal(t, 3, *connects)
+}
+
+func TestRun_ProxyUnreachable(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	proxyURL := proxy.URL
+	proxy.Close()
+
+	status := Run(context.Background(), Target{TokenURL: "https://token.example.com"}, MountSignal{}, proxyTo(t, proxyURL))
+
+	assert.Equal(t, v1alpha1.ProbeResultError, status.Result)
+	require.Len(t, status.Checks, 2)
+	assert.Equal(t, CheckProxy, status.Checks[0].Name)
+	assert.False(t, status.Checks[0].Success)
+	assert.Contains(t, status.Checks[0].Error, "dial proxy")
+}
+
+func TestRun_HttpsProxyRejected(t *testing.T) {
+	status := Run(context.Background(), Target{TokenURL: "https://token.example.com"}, MountSignal{}, proxyTo(t, "https://proxy.local:3128"))
+
+	assert.Equal(t, v1alpha1.ProbeResultError, status.Result)
+	require.Len(t, status.Checks, 2)
+	assert.Equal(t, CheckProxy, status.Checks[0].Name)
+	assert.Contains(t, status.Checks[0].Error, "https proxy proxy.local:3128 is not supported")
+	assert.Contains(t, status.Checks[1].Error, TLSResultOther)
+}
+
+func TestRun_DNSFailure(t *testing.T) {
+	status := Run(context.Background(), Target{TokenURL: "https://token.invalid"}, MountSignal{}, noProxy)
+
+	require.Len(t, status.Checks, 2)
+	assert.Equal(t, CheckDNSTokenURL, status.Checks[0].Name)
+	assert.False(t, status.Checks[0].Success)
+	assert.Equal(t, TLSResultOther, status.TLSResult)
+}
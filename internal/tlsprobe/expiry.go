@@ -0,0 +1,67 @@
+package tlsprobe
+
+import (
+	"bytes"
+	"crypto/x509"
+	"sort"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// expiringCAs returns the certificates in the mounted CA bundle and the managed trust bundle that are expired at now
+// or expire within mount.ExpiryWarning, earliest first. The system CA bundle is not analyzed.
+func expiringCAs(mount MountSignal, chains [][]*x509.Certificate, now time.Time) []v1alpha1.ExpiringCA {
+	seen := map[string]struct{}{}
+	var expiring []*x509.Certificate
+	for _, data := range [][]byte{mount.Content, mount.TrustBundle} {
+		for _, cert := range parseCertificates(data) {
+			fp := fingerprint(cert)
+			if _, exists := seen[fp]; exists {
+				continue
+			}
+			seen[fp] = struct{}{}
+			if now.Add(mount.ExpiryWarning).After(cert.NotAfter) {
+				expiring = append(expiring, cert)
+			}
+		}
+	}
+	sort.SliceStable(expiring, func(i, j int) bool { return expiring[i].NotAfter.Before(expiring[j].NotAfter) })
+
+	result := make([]v1alpha1.ExpiringCA, 0, len(expiring))
+	for _, cert := range expiring {
+		result = append(result, v1alpha1.ExpiringCA{
+			Subject:         cert.Subject.String(),
+			NotAfter:        metav1.NewTime(cert.NotAfter),
+			Expired:         now.After(cert.NotAfter),
+			InTokenURLChain: chainsDependOn(chains, cert),
+		})
+	}
+	return result
+}
+
+// chainsDependOn reports whether every chain contains the CA or a certificate issued by it. Without chains, it reports false.
+func chainsDependOn(chains [][]*x509.Certificate, ca *x509.Certificate) bool {
+	if len(chains) == 0 {
+		return false
+	}
+	for _, chain := range chains {
+		if !chainDependsOn(chain, ca) {
+			return false
+		}
+	}
+	return true
+}
+
+func chainDependsOn(chain []*x509.Certificate, ca *x509.Certificate) bool {
+	for _, cert := range chain {
+		if bytes.Equal(cert.Raw, ca.Raw) {
+			return true
+		}
+		if bytes.Equal(cert.RawIssuer, ca.RawSubject) && cert.CheckSignatureFrom(ca) == nil {
+			return true
+		}
+	}
+	return false
+}
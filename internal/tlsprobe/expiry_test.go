@@ -0,0 +1,132 @@
+package tlsprobe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newTestCA creates a self-signed CA valid from notBefore to notAfter.
+func newTestCA(t *testing.T, name string, notBefore, notAfter time.Time) testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// newServerWithCA starts a TLS server for 127.0.0.1 with a certificate issued by the CA.
+func newServerWithCA(t *testing.T, ca testCA) *httptest.Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRun_ExpiringCAInTokenURLChain(t *testing.T) {
+	now := time.Now()
+	issuer := newTestCA(t, "Expiring Issuer CA", now.Add(-time.Hour), now.Add(24*time.Hour))
+	unrelated := newTestCA(t, "Expiring Unrelated CA", now.Add(-time.Hour), now.Add(12*time.Hour))
+	valid := newTestCA(t, "Valid CA", now.Add(-time.Hour), now.Add(365*24*time.Hour))
+	srv := newServerWithCA(t, issuer)
+	bundle := append(append(append([]byte{}, issuer.pem...), unrelated.pem...), valid.pem...)
+	mount := MountSignal{Present: true, Content: bundle, ExpiryWarning: 48 * time.Hour}
+
+	status := Run(context.Background(), Target{TokenURL: srv.URL}, mount, noProxy)
+
+	assert.Equal(t, v1alpha1.ProbeResultOK, status.Result)
+	require.Len(t, status.ExpiringCAs, 2)
+	assert.Equal(t, "CN=Expiring Unrelated CA", status.ExpiringCAs[0].Subject, "the earliest expiry comes first")
+	assert.False(t, status.ExpiringCAs[0].InTokenURLChain)
+	assert.Equal(t, "CN=Expiring Issuer CA", status.ExpiringCAs[1].Subject)
+	assert.True(t, status.ExpiringCAs[1].InTokenURLChain)
+	assert.False(t, status.ExpiringCAs[1].Expired)
+	assert.True(t, status.ExpiringCAs[1].NotAfter.Time.Equal(issuer.cert.NotAfter))
+}
+
+func TestRun_ExpiredCAInTokenURLChain(t *testing.T) {
+	now := time.Now()
+	issuer := newTestCA(t, "Expired Issuer CA", now.Add(-48*time.Hour), now.Add(-time.Hour))
+	srv := newServerWithCA(t, issuer)
+
+	status := Run(context.Background(), Target{TokenURL: srv.URL}, MountSignal{Present: true, Content: issuer.pem}, noProxy)
+
+	assert.Equal(t, v1alpha1.ProbeResultAlert, status.Result)
+	require.Len(t, status.ExpiringCAs, 1)
+	assert.True(t, status.ExpiringCAs[0].Expired)
+	assert.True(t, status.ExpiringCAs[0].InTokenURLChain, "the chain presented by the token URL is issued by the expired CA")
+}
+
+func TestExpiringCAs(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	soon := newTestCA(t, "Soon CA", now.Add(-time.Hour), now.Add(24*time.Hour))
+	later := newTestCA(t, "Later CA", now.Add(-time.Hour), now.Add(30*24*time.Hour))
+
+	t.Run("should report only expired CAs without a warning period", func(t *testing.T) {
+		assert.Empty(t, expiringCAs(MountSignal{Content: soon.pem}, nil, now))
+	})
+
+	t.Run("should analyze the managed trust bundle and report each CA once", func(t *testing.T) {
+		mount := MountSignal{Content: soon.pem, TrustBundle: append(append([]byte{}, soon.pem...), later.pem...), ExpiryWarning: 7 * 24 * time.Hour}
+
+		expiring := expiringCAs(mount, nil, now)
+
+		require.Len(t, expiring, 1)
+		assert.Equal(t, "CN=Soon CA", expiring[0].Subject)
+		assert.False(t, expiring[0].InTokenURLChain, "without a chain nothing depends on the CA")
+	})
+
+	t.Run("should require every verified chain to depend on the CA", func(t *testing.T) {
+		chains := [][]*x509.Certificate{{soon.cert}, {later.cert}}
+
+		assert.False(t, chainsDependOn(chains, soon.cert))
+		assert.True(t, chainsDependOn(chains[:1], soon.cert))
+	})
+}
@@ -0,0 +1,341 @@
+package tlsprobe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"golang.org/x/net/http/httpproxy"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Results of the TLS handshake with the target URL.
+const (
+	TLSResultOK    = "ok"
+	TLSResultX509  = "failed-x509"
+	TLSResultOther = "failed-other"
+)
+
+const dialTimeout = 10 * time.Second
+
+// MountSignal describes the CA bundle the probe verifies the target URL with.
+type MountSignal struct {
+	Present bool
+	Hash    string
+	Content []byte
+	// TrustBundle holds the CA trust bundle managed by BTP Manager. It extends the pool
+	// instead of replacing it, and it is not part of Hash, because BTP Manager rolls out
+	// sap-btp-operator itself when the managed bundle changes.
+	TrustBundle []byte
+	// ExpiryWarning is how long before their expiry the certificates in the bundle are reported as expiring.
+	ExpiryWarning time.Duration
+}
+
+// WithTrustBundle adds the managed CA trust bundle to the mount signal. The signal is returned unchanged if data is empty.
+func (m MountSignal) WithTrustBundle(data []byte) MountSignal {
+	if len(data) == 0 {
+		return m
+	}
+	m.Present = true
+	m.TrustBundle = data
+	return m
+}
+
+// CollectMount reads the CA bundle from caFile.
+// It considers the CA bundle injected only when caBundleMntDir is an explicit
+// mountpoint in mountInfoFile — distinguishing an injected volume from the CA
+// bundle that ships in the base image.
+func CollectMount(caFile, caBundleMntDir, mountInfoFile string) MountSignal {
+	if !IsMountPoint(caBundleMntDir, mountInfoFile) {
+		return MountSignal{}
+	}
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return MountSignal{}
+	}
+	return MountSignal{Present: true, Hash: HashCertBundle(data), Content: data}
+}
+
+// HashCertBundle parses all PEM certificates in data, computes a SHA-256 fingerprint
+// (sha256(cert.Raw)) for each, deduplicates, sorts, and returns a SHA-256 hash of the
+// joined sorted fingerprint list. Returns "" when no valid certificates are found.
+// The result is order-independent: the same set of certs in any order produces the same hash.
+func HashCertBundle(data []byte) string {
+	seen := map[string]struct{}{}
+	for _, cert := range parseCertificates(data) {
+		seen[fingerprint(cert)] = struct{}{}
+	}
+	if len(seen) == 0 {
+		return ""
+	}
+	fps := make([]string, 0, len(seen))
+	for fp := range seen {
+		fps = append(fps, fp)
+	}
+	sort.Strings(fps)
+	sum := sha256.Sum256([]byte(strings.Join(fps, ",")))
+	return fmt.Sprintf("%x", sum)
+}
+
+// parseCertificates returns the valid PEM certificates in data. Other PEM blocks and invalid certificates are skipped.
+func parseCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	rest := data
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	return certs
+}
+
+func fingerprint(cert *x509.Certificate) string {
+	return fmt.Sprintf("%x", sha256.Sum256(cert.Raw))
+}
+
+// IsMountPoint reports whether path appears as a mount destination in mountInfoFile.
+// Field 5 (0-indexed) of each /proc/self/mountinfo line is the mount point.
+func IsMountPoint(path, mountInfoFile string) bool {
+	data, err := os.ReadFile(mountInfoFile)
+	if err != nil {
+		return false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 5 && fields[4] == path {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildCertPool returns the mounted CA bundle, or the system CA bundle if none is mounted, extended with the managed trust bundle.
+func BuildCertPool(m MountSignal) *x509.CertPool {
+	var pool *x509.CertPool
+	if m.Present && len(m.Content) > 0 {
+		pool = x509.NewCertPool()
+		pool.AppendCertsFromPEM(m.Content)
+	} else if systemPool, err := x509.SystemCertPool(); err == nil {
+		pool = systemPool
+	} else {
+		pool = x509.NewCertPool()
+	}
+	if len(m.TrustBundle) > 0 {
+		pool.AppendCertsFromPEM(m.TrustBundle)
+	}
+	return pool
+}
+
+// ComputeSignal returns the probe result for the TLS result. An untrusted certificate is an alert only when a CA bundle is mounted.
+func ComputeSignal(mountPresent bool, tlsResult string) string {
+	switch tlsResult {
+	case TLSResultOK:
+		return v1alpha1.ProbeResultOK
+	case TLSResultX509:
+		if mountPresent {
+			return v1alpha1.ProbeResultAlert
+		}
+		return v1alpha1.ProbeResultError
+	default: // failed-other
+		return v1alpha1.ProbeResultError
+	}
+}
+
+// Run checks the connectivity to the target with the CA bundle of the mount signal and returns the probe result.
+// The result is computed from the TLS handshake with the token URL. All checks, including the handshake, are reported in Checks.
+func Run(ctx context.Context, target Target, mount MountSignal, proxy ProxyFunc) *v1alpha1.ProbeStatus {
+	status := &v1alpha1.ProbeStatus{
+		TargetURL:    target.TokenURL,
+		Result:       v1alpha1.ProbeResultError,
+		MountPresent: mount.Present,
+		Hash:         mount.Hash,
+		UpdatedAt:    metav1.Now(),
+	}
+
+	tokenURL, err := url.Parse(target.TokenURL)
+	if err != nil {
+		status.Error = fmt.Sprintf("parse token URL: %s", err)
+		return status
+	}
+
+	pool := BuildCertPool(mount)
+	checkedProxies := make(map[string]bool)
+	var chains [][]*x509.Certificate
+	status.Checks, status.TLSResult, chains = endpointChecks(ctx, tokenURL, CheckDNSTokenURL, CheckTLSTokenURL, pool, proxy, checkedProxies)
+	if status.TLSResult == TLSResultX509 && len(chains) > 0 {
+		status.FailingCertificateSubjects = certificateSubjects(chains[0])
+	}
+	status.Result = ComputeSignal(mount.Present, status.TLSResult)
+	status.ExpiringCAs = expiringCAs(mount, chains, time.Now())
+
+	if target.SMURL != "" {
+		smURL, err := url.Parse(target.SMURL)
+		if err != nil {
+			status.Checks = append(status.Checks, v1alpha1.ProbeCheck{Name: CheckTLSSMURL, Error: fmt.Sprintf("parse SM URL: %s", err)})
+		} else {
+			checks, _, _ := endpointChecks(ctx, smURL, CheckDNSSMURL, CheckTLSSMURL, pool, proxy, checkedProxies)
+			status.Checks = append(status.Checks, checks...)
+		}
+	}
+	if target.ClientID != "" && target.ClientSecret != "" {
+		status.Checks = append(status.Checks, check(CheckOAuthToken, func() error { return requestToken(ctx, target, pool, proxy) }))
+	}
+	return status
+}
+
+// ProxyFunc returns the proxy for a target URL, or nil to connect directly.
+type ProxyFunc func(*url.URL) (*url.URL, error)
+
+// EnvironmentProxy resolves the proxy for a target URL from HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func EnvironmentProxy() ProxyFunc {
+	return httpproxy.FromEnvironment().ProxyFunc()
+}
+
+// DialTLS returns the TLS result and, when the certificate is not trusted, the subjects of the chain presented by addr.
+func DialTLS(addr string, pool *x509.CertPool, proxy ProxyFunc) (string, []string) {
+	result, chains := dialTLS(addr, pool, proxy)
+	if result != TLSResultX509 || len(chains) == 0 {
+		return result, nil
+	}
+	return result, certificateSubjects(chains[0])
+}
+
+// dialTLS returns the TLS result with the verified chains of addr or, when the certificate is not trusted, the chain presented by addr.
+func dialTLS(addr string, pool *x509.CertPool, proxy ProxyFunc) (string, [][]*x509.Certificate) {
+	conn, err := dialTLSConn(addr, pool, proxy)
+	if err != nil {
+		var x509Err *tls.CertificateVerificationError
+		if errors.As(err, &x509Err) {
+			return TLSResultX509, [][]*x509.Certificate{x509Err.UnverifiedCertificates}
+		}
+		if isTLSCertError(err.Error()) {
+			return TLSResultX509, nil
+		}
+		return TLSResultOther, nil
+	}
+	defer conn.Close()
+	return TLSResultOK, conn.ConnectionState().VerifiedChains
+}
+
+func certificateSubjects(certs []*x509.Certificate) []string {
+	subjects := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		subjects = append(subjects, cert.Subject.String())
+	}
+	return subjects
+}
+
+// dialTLSConn opens a TLS connection to addr, tunnelling through the configured HTTP proxy if there is one.
+func dialTLSConn(addr string, pool *x509.CertPool, proxy ProxyFunc) (*tls.Conn, error) {
+	proxyURL, err := proxy(&url.URL{Scheme: "https", Host: addr})
+	if err != nil {
+		return nil, fmt.Errorf("resolve proxy: %w", err)
+	}
+	if proxyURL == nil {
+		return tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", addr, &tls.Config{RootCAs: pool})
+	}
+
+	rawConn, err := dialThroughProxy(proxyURL, addr)
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	conn := tls.Client(rawConn, &tls.Config{RootCAs: pool, ServerName: host})
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+	if err := conn.Handshake(); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// checkProxyScheme rejects the proxies the probe can't tunnel through. The probe speaks plain HTTP to the proxy,
+// so an https proxy is rejected explicitly instead of failing the CONNECT request with a TLS record.
+func checkProxyScheme(proxyURL *url.URL) error {
+	switch proxyURL.Scheme {
+	case "http":
+		return nil
+	case "https":
+		return fmt.Errorf("https proxy %s is not supported by the probe, use an http proxy", proxyURL.Host)
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+}
+
+// dialThroughProxy opens a tunnel to addr using the HTTP CONNECT method.
+func dialThroughProxy(proxyURL *url.URL, addr string) (net.Conn, error) {
+	if err := checkProxyScheme(proxyURL); err != nil {
+		return nil, err
+	}
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+	conn, err := net.DialTimeout("tcp", proxyAddr, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("dial proxy: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		req.SetBasicAuth(proxyURL.User.Username(), password)
+		req.Header.Set("Proxy-Authorization", req.Header.Get("Authorization"))
+		req.Header.Del("Authorization")
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("write CONNECT request: %w", err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("read CONNECT response: %w", err)
+	}
+	// The body of a successful CONNECT response is the tunnel itself, so it must not be drained.
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT failed: %s", resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func isTLSCertError(errStr string) bool {
+	return strings.Contains(errStr, "x509") || strings.Contains(errStr, "certificate")
+}
@@ -0,0 +1,323 @@
+package tlsprobe
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// certA and certB are real self-signed test certificates valid for 100 years (expire 2126).
+// x509.ParseCertificate does not validate expiry, so these are stable test fixtures.
+const certA = `-----BEGIN CERTIFICATE-----
+MIIBETCBt6ADAgECAgEBMAoGCCqGSM49BAMCMBExDzANBgNVBAMTBmNlcnQtQTAg
+Fw0yNjA3MjExNzE1MTVaGA8yMTI2MDYyNzE3MTUxNVowETEPMA0GA1UEAxMGY2Vy
+dC1BMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEEwls12ICy7qAI9LdVz9THuAw
+IwarpGQgnxNK/eJGcSB2HRXXLoDs5FZFxvEaLO8B2I7v3jLJCzLt6CQP/qWBWDAK
+BggqhkjOPQQDAgNJADBGAiEAyW1ebwVF0GOmqaKSbd4VPmo6hOl6wKhfZnCRplI6
+td4CIQCkEvs9atXyWaSeAdpCHPkVaqyx1r0M+q4DtPtm63K8ug==
+-----END CERTIFICATE-----
+`
+
+const certB = `-----BEGIN CERTIFICATE-----
+MIIBDzCBt6ADAgECAgEBMAoGCCqGSM49BAMCMBExDzANBgNVBAMTBmNlcnQtQjAg
+Fw0yNjA3MjExNzE1MTVaGA8yMTI2MDYyNzE3MTUxNVowETEPMA0GA1UEAxMGY2Vy
+dC1CMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE+Bg3XMx5P7ZU8XEJ++8vfozT
+BGEzw+Xvn94EzNnbBKhknfqChP1UfYVZ1tC9nf4VyuJKWjzbr5HdLB5vn4ktPjAK
+BggqhkjOPQQDAgNHADBEAiA7KZ0wBZdzeKZzWWJR0SPE9/R59zeNVeOhCsT6b6RL
+0wIgeor8WP6NGWfegdC4D1fGsam15j9nvRrRe6rPo0S1wQk=
+-----END CERTIFICATE-----
+`
+
+func TestCollectMount_Present(t *testing.T) {
+	caFile, err := os.CreateTemp("", "ca-*.crt")
+	require.NoError(t, err)
+	content := []byte(certA)
+	_, err = caFile.Write(content)
+	require.NoError(t, err)
+	caFile.Close()
+	defer os.Remove(caFile.Name())
+
+	// Write a fake mountinfo that lists the CA file's directory as a mountpoint
+	mntDir := filepath.Dir(caFile.Name())
+	mountInfo, err := os.CreateTemp("", "mountinfo-*")
+	require.NoError(t, err)
+	defer os.Remove(mountInfo.Name())
+	fmt.Fprintf(mountInfo, "100 99 0:1 / %s rw - tmpfs tmpfs rw\n", mntDir)
+	mountInfo.Close()
+
+	m := CollectMount(caFile.Name(), mntDir, mountInfo.Name())
+	assert.True(t, m.Present)
+	assert.NotEmpty(t, m.Hash)
+	assert.Equal(t, content, m.Content)
+}
+
+func TestCollectMount_Absent_NoMount(t *testing.T) {
+	// mountinfo does not list /etc/ssl/certs → no injected bundle
+	mountInfo, err := os.CreateTemp("", "mountinfo-*")
+	require.NoError(t, err)
+	defer os.Remove(mountInfo.Name())
+	fmt.Fprintf(mountInfo, "100 99 0:1 / / rw - overlay overlay rw\n")
+	mountInfo.Close()
+
+	m := CollectMount("/etc/ssl/certs/ca-certificates.crt", "/etc/ssl/certs", mountInfo.Name())
+	assert.False(t, m.Present)
+	assert.Empty(t, m.Hash)
+	assert.Nil(t, m.Content)
+}
+
+func TestDialTLS_OK(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+	pool := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	result, _ := DialTLS(srv.Listener.Addr().String(), pool, noProxy)
+	assert.Equal(t, TLSResultOK, result)
+}
+
+func TestDialTLS_X509(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	// Empty pool → certificate signed by unknown authority
+	result, subjects := DialTLS(srv.Listener.Addr().String(), x509.NewCertPool(), noProxy)
+	assert.Equal(t, TLSResultX509, result)
+	assert.Equal(t, []string{srv.Certificate().Subject.String()}, subjects)
+}
+
+func TestDialTLS_Other(t *testing.T) {
+	// Nothing listening on this port → connection refused → failed-other
+	result, _ := DialTLS("127.0.0.1:19999", x509.NewCertPool(), noProxy)
+	assert.Equal(t, TLSResultOther, result)
+}
+
+// newConnectProxy starts an HTTP proxy that tunnels CONNECT requests and counts them.
+func newConnectProxy(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+	connects := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		connects++
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		pipe := func(dst, src net.Conn) {
+			_, _ = io.Copy(dst, src)
+			dst.Close()
+			src.Close()
+		}
+		go pipe(target, conn)
+		go pipe(conn, target)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, &connects
+}
+
+// proxyTo routes all connections through the proxy at proxyURL.
+func proxyTo(t *testing.T, proxyURL string) ProxyFunc {
+	t.Helper()
+	u, err := url.Parse(proxyURL)
+	require.NoError(t, err)
+	return func(*url.URL) (*url.URL, error) { return u, nil }
+}
+
+func noProxy(*url.URL) (*url.URL, error) { return nil, nil }
+
+func TestDialTLS_ThroughProxy_OK(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	proxy, connects := newConnectProxy(t)
+
+	pool := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	result, _ := DialTLS(srv.Listener.Addr().String(), pool, proxyTo(t, proxy.URL))
+	assert.Equal(t, TLSResultOK, result)
+	assert.Equal(t, 1, *connects)
+}
+
+func TestDialTLS_ThroughProxy_X509(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	proxy, _ := newConnectProxy(t)
+
+	result, _ := DialTLS(srv.Listener.Addr().String(), x509.NewCertPool(), proxyTo(t, proxy.URL))
+	assert.Equal(t, TLSResultX509, result)
+}
+
+func TestDialTLS_ThroughProxy_Rejected(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	}))
+	defer proxy.Close()
+
+	result, _ := DialTLS("example.invalid:443", x509.NewCertPool(), proxyTo(t, proxy.URL))
+	assert.Equal(t, TLSResultOther, result)
+}
+
+func TestBuildCertPool_WithTrustBundle_TrustsServer(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	pool := BuildCertPool(MountSignal{Present: true, TrustBundle: serverCA})
+	result, _ := DialTLS(srv.Listener.Addr().String(), pool, noProxy)
+	assert.Equal(t, TLSResultOK, result)
+}
+
+func TestBuildCertPool_NoMount(t *testing.T) {
+	pool := BuildCertPool(MountSignal{Present: false})
+	assert.NotNil(t, pool)
+}
+
+func TestBuildCertPool_WithMount_CustomOnly(t *testing.T) {
+	pem := []byte("-----BEGIN CERTIFICATE-----\nfake\n-----END CERTIFICATE-----\n")
+	pool := BuildCertPool(MountSignal{Present: true, Content: pem})
+	assert.NotNil(t, pool)
+}
+
+func TestComputeSignal(t *testing.T) {
+	cases := []struct {
+		mount    bool
+		tls      string
+		expected string
+	}{
+		{false, TLSResultOK, v1alpha1.ProbeResultOK},
+		{false, TLSResultX509, v1alpha1.ProbeResultError},
+		{false, TLSResultOther, v1alpha1.ProbeResultError},
+		{true, TLSResultOK, v1alpha1.ProbeResultOK},
+		{true, TLSResultX509, v1alpha1.ProbeResultAlert},
+		{true, TLSResultOther, v1alpha1.ProbeResultError},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, ComputeSignal(c.mount, c.tls), "mount=%v tls=%s", c.mount, c.tls)
+	}
+}
+
+func TestHashCertBundle_EmptyInput(t *testing.T) {
+	assert.Empty(t, HashCertBundle(nil))
+	assert.Empty(t, HashCertBundle([]byte{}))
+	assert.Empty(t, HashCertBundle([]byte("not a cert")))
+}
+
+func TestHashCertBundle_SingleCert_Stable(t *testing.T) {
+	data := []byte(certA)
+	h1 := HashCertBundle(data)
+	h2 := HashCertBundle(data)
+	require.NotEmpty(t, h1)
+	assert.Equal(t, h1, h2)
+}
+
+func TestHashCertBundle_OrderIndependent(t *testing.T) {
+	// Same two certs, different order — hash must be identical.
+	bundleAB := []byte(certA + certB)
+	bundleBA := []byte(certB + certA)
+	hAB := HashCertBundle(bundleAB)
+	hBA := HashCertBundle(bundleBA)
+	require.NotEmpty(t, hAB)
+	assert.Equal(t, hAB, hBA, "hash must not depend on cert order in the file")
+}
+
+func TestHashCertBundle_DifferentCerts_DifferentHash(t *testing.T) {
+	hA := HashCertBundle([]byte(certA))
+	hB := HashCertBundle([]byte(certB))
+	require.NotEmpty(t, hA)
+	require.NotEmpty(t, hB)
+	assert.NotEqual(t, hA, hB)
+}
+
+func TestHashCertBundle_DuplicateCerts_SameAsUnique(t *testing.T) {
+	// A bundle with A+A should hash the same as a bundle with just A.
+	hOnce := HashCertBundle([]byte(certA))
+	hTwice := HashCertBundle([]byte(certA + certA))
+	assert.Equal(t, hOnce, hTwice, "duplicated cert must not change the hash")
+}
+
+func TestCollectMount_HashIsOrderIndependent(t *testing.T) {
+	// Integration: CollectMount must produce the same hash regardless of cert order.
+	bundleAB := []byte(certA + certB)
+	bundleBA := []byte(certB + certA)
+
+	dir := t.TempDir()
+	mountInfo, err := os.CreateTemp("", "mountinfo-*")
+	require.NoError(t, err)
+	defer os.Remove(mountInfo.Name())
+	fmt.Fprintf(mountInfo, "100 99 0:1 / %s rw - tmpfs tmpfs rw\n", dir)
+	mountInfo.Close()
+
+	caAB := filepath.Join(dir, "ca-ab.crt")
+	caBA := filepath.Join(dir, "ca-ba.crt")
+	require.NoError(t, os.WriteFile(caAB, bundleAB, 0600))
+	require.NoError(t, os.WriteFile(caBA, bundleBA, 0600))
+
+	mAB := CollectMount(caAB, dir, mountInfo.Name())
+	mBA := CollectMount(caBA, dir, mountInfo.Name())
+
+	require.True(t, mAB.Present)
+	require.True(t, mBA.Present)
+	assert.Equal(t, mAB.Hash, mBA.Hash, "mount hash must be order-independent")
+}
+
+func TestMountSignal_WithTrustBundle(t *testing.T) {
+	m := MountSignal{}.WithTrustBundle([]byte(certA))
+	assert.True(t, m.Present)
+	assert.Equal(t, []byte(certA), m.TrustBundle)
+	assert.Empty(t, m.Hash)
+
+	assert.Equal(t, MountSignal{}, MountSignal{}.WithTrustBundle(nil))
+}
+
+func TestRun_Trusted(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	status := Run(context.Background(), Target{TokenURL: srv.URL + "/token"}, MountSignal{}.WithTrustBundle(serverCA), noProxy)
+
+	assert.Equal(t, v1alpha1.ProbeResultOK, status.Result)
+	assert.Equal(t, TLSResultOK, status.TLSResult)
+	assert.Equal(t, srv.URL+"/token", status.TargetURL)
+	assert.True(t, status.MountPresent)
+	assert.Empty(t, status.FailingCertificateSubjects)
+	assert.False(t, status.UpdatedAt.IsZero())
+}
+
+func TestRun_Untrusted(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	status := Run(context.Background(), Target{TokenURL: srv.URL}, MountSignal{}.WithTrustBundle([]byte(certA)), noProxy)
+
+	assert.Equal(t, v1alpha1.ProbeResultAlert, status.Result)
+	assert.Equal(t, TLSResultX509, status.TLSResult)
+	assert.Equal(t, []string{srv.Certificate().Subject.String()}, status.FailingCertificateSubjects)
+}
+
+func TestRun_InvalidURL(t *testing.T) {
+	status := Run(context.Background(), Target{TokenURL: "://token"}, MountSignal{}, noProxy)
+
+	assert.Equal(t, v1alpha1.ProbeResultError, status.Result)
+	assert.Empty(t, status.TLSResult)
+	assert.Contains(t, status.Error, "parse token URL")
+}
@@ -0,0 +1,283 @@
+package trustbundle
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	ConfigMapKind = "ConfigMap"
+	SecretKind    = "Secret"
+
+	VolumeName = "custom-ca-trust-bundle"
+	MountPath  = "/etc/ssl/custom-ca"
+	FileName   = "ca-bundle.crt"
+
+	HashAnnotationKey = "operator.kyma-project.io/trust-bundle-hash"
+
+	SslCertDirEnv = "SSL_CERT_DIR"
+
+	systemCertDir        = "/etc/ssl/certs"
+	operandContainerName = "manager"
+	deploymentKind       = "Deployment"
+)
+
+var ErrBothSourcesSet = errors.New("only one of TrustBundleConfigMap and TrustBundleSecret can be set")
+
+// Bundle describes the custom CA trust bundle currently configured for the operand.
+type Bundle struct {
+	Kind string
+	Name string
+	Key  string
+	Hash string
+	// Data is the PEM encoded trust bundle.
+	Data []byte
+}
+
+type TrustBundleManager interface {
+	Load(ctx context.Context) (*Bundle, error)
+	PrepareDeployment(ctx context.Context, resources []*unstructured.Unstructured) error
+}
+
+type Manager struct {
+	reader client.Reader
+}
+
+// NewManager creates a trust bundle manager. The reader should bypass the cache,
+// because the cached client only sees ConfigMaps and Secrets managed by btp-manager.
+func NewManager(reader client.Reader) *Manager {
+	return &Manager{reader: reader}
+}
+
+var _ TrustBundleManager = (*Manager)(nil)
+
+// Configured reports whether a custom CA trust bundle source is set.
+func Configured() bool {
+	cfg := config.Current()
+	return cfg.TrustBundleConfigMap != "" || cfg.TrustBundleSecret != ""
+}
+
+func source() (kind, name string, err error) {
+	cfg := config.Current()
+	switch {
+	case cfg.TrustBundleConfigMap != "" && cfg.TrustBundleSecret != "":
+		return "", "", ErrBothSourcesSet
+	case cfg.TrustBundleConfigMap != "":
+		return ConfigMapKind, cfg.TrustBundleConfigMap, nil
+	default:
+		return SecretKind, cfg.TrustBundleSecret, nil
+	}
+}
+
+// Load reads the configured trust bundle source and validates its content.
+// It returns nil if no trust bundle is configured.
+func (m *Manager) Load(ctx context.Context) (*Bundle, error) {
+	cfg := config.Current()
+	if !Configured() {
+		return nil, nil
+	}
+	kind, name, err := source()
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var found bool
+	objKey := client.ObjectKey{Namespace: cfg.ChartNamespace, Name: name}
+	switch kind {
+	case ConfigMapKind:
+		cm := &corev1.ConfigMap{}
+		err = m.reader.Get(ctx, objKey, cm)
+		if err == nil {
+			var value string
+			value, found = cm.Data[cfg.TrustBundleKey]
+			data = []byte(value)
+		}
+	case SecretKind:
+		secret := &corev1.Secret{}
+		err = m.reader.Get(ctx, objKey, secret)
+		if err == nil {
+			data, found = secret.Data[cfg.TrustBundleKey]
+		}
+	}
+	if k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("trust bundle %s %s not found in %s namespace", kind, name, cfg.ChartNamespace)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("while getting trust bundle %s %s: %w", kind, name, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("trust bundle %s %s does not contain key %s", kind, name, cfg.TrustBundleKey)
+	}
+	if err := validate(data); err != nil {
+		return nil, fmt.Errorf("invalid trust bundle in %s %s: %w", kind, name, err)
+	}
+
+	return &Bundle{
+		Kind: kind,
+		Name: name,
+		Key:  cfg.TrustBundleKey,
+		Hash: fmt.Sprintf("%x", sha256.Sum256(data)),
+		Data: data,
+	}, nil
+}
+
+func validate(data []byte) error {
+	certificates := 0
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certificates++
+	}
+	if certificates == 0 {
+		return errors.New("no PEM encoded certificates found")
+	}
+	return nil
+}
+
+// PrepareDeployment mounts the trust bundle into the sap-btp-operator Deployment and records the bundle hash
+// in the Pod template, so that the Pods are rolled out whenever the bundle content changes.
+func (m *Manager) PrepareDeployment(ctx context.Context, resources []*unstructured.Unstructured) error {
+	cfg := config.Current()
+	bundle, err := m.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if bundle == nil {
+		return nil
+	}
+	for _, u := range resources {
+		if u.GetKind() == deploymentKind && u.GetName() == cfg.DeploymentName {
+			return SetDeploymentTrustBundle(u, bundle)
+		}
+	}
+	return fmt.Errorf("deployment %s not found in module resources", cfg.DeploymentName)
+}
+
+// SetDeploymentTrustBundle adds the trust bundle volume, volume mount and SSL_CERT_DIR to the sap-btp-operator container.
+func SetDeploymentTrustBundle(u *unstructured.Unstructured, bundle *Bundle) error {
+	deployment := &appsv1.Deployment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, deployment); err != nil {
+		return fmt.Errorf("failed to convert %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+
+	podSpec := &deployment.Spec.Template.Spec
+	var container *corev1.Container
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == operandContainerName {
+			container = &podSpec.Containers[i]
+		}
+	}
+	if container == nil {
+		return fmt.Errorf("container %s not found in %s %s", operandContainerName, u.GetKind(), u.GetName())
+	}
+
+	podSpec.Volumes = append(removeVolume(podSpec.Volumes), Volume(bundle.Kind, bundle.Name, bundle.Key))
+	container.VolumeMounts = append(removeVolumeMount(container.VolumeMounts), VolumeMount())
+	container.Env = append(removeEnv(container.Env, SslCertDirEnv), corev1.EnvVar{Name: SslCertDirEnv, Value: SslCertDir()})
+
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations[HashAnnotationKey] = bundle.Hash
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(deployment)
+	if err != nil {
+		return fmt.Errorf("failed to convert %s %s to unstructured: %w", u.GetKind(), u.GetName(), err)
+	}
+	unstructured.RemoveNestedField(obj, "status")
+	u.Object = obj
+	return nil
+}
+
+// Volume returns the volume exposing the trust bundle from the given source as a single file named FileName.
+func Volume(kind, name, key string) corev1.Volume {
+	items := []corev1.KeyToPath{{Key: key, Path: FileName}}
+	volume := corev1.Volume{Name: VolumeName}
+	if kind == ConfigMapKind {
+		volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			Items:                items,
+		}
+	} else {
+		volume.Secret = &corev1.SecretVolumeSource{
+			SecretName: name,
+			Items:      items,
+		}
+	}
+	return volume
+}
+
+// ConfiguredVolume returns the trust bundle volume for the current configuration.
+// It returns false if no trust bundle is configured or the configuration is invalid.
+func ConfiguredVolume() (corev1.Volume, bool) {
+	if !Configured() {
+		return corev1.Volume{}, false
+	}
+	kind, name, err := source()
+	if err != nil {
+		return corev1.Volume{}, false
+	}
+	return Volume(kind, name, config.Current().TrustBundleKey), true
+}
+
+func VolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{Name: VolumeName, MountPath: MountPath, ReadOnly: true}
+}
+
+// SslCertDir keeps the system certificates trusted and adds the mounted bundle on top of them.
+func SslCertDir() string {
+	return systemCertDir + ":" + MountPath
+}
+
+func removeVolume(volumes []corev1.Volume) []corev1.Volume {
+	result := make([]corev1.Volume, 0, len(volumes))
+	for _, v := range volumes {
+		if v.Name != VolumeName {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func removeVolumeMount(mounts []corev1.VolumeMount) []corev1.VolumeMount {
+	result := make([]corev1.VolumeMount, 0, len(mounts))
+	for _, m := range mounts {
+		if m.Name != VolumeName {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func removeEnv(env []corev1.EnvVar, name string) []corev1.EnvVar {
+	result := make([]corev1.EnvVar, 0, len(env))
+	for _, e := range env {
+		if e.Name != name {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,256 @@
+package trustbundle_test
+
+import (
+	"context"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/trustbundle"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("Trust Bundle Manager", func() {
+	var (
+		ctx                                               context.Context
+		origConfigMap, origSecret, origKey, origNamespace string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		origConfigMap, origSecret, origKey, origNamespace = config.TrustBundleConfigMap, config.TrustBundleSecret, config.TrustBundleKey, config.ChartNamespace
+		config.ChartNamespace = kymaNamespace
+	})
+
+	AfterEach(func() {
+		config.TrustBundleConfigMap, config.TrustBundleSecret, config.TrustBundleKey, config.ChartNamespace = origConfigMap, origSecret, origKey, origNamespace
+	})
+
+	Describe("Load", func() {
+		It("should return nil when no trust bundle is configured", func() {
+			mgr := trustbundle.NewManager(newFakeClient())
+
+			bundle, err := mgr.Load(ctx)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bundle).To(BeNil())
+		})
+
+		It("should load the bundle from a ConfigMap", func() {
+			config.TrustBundleConfigMap = "corporate-ca"
+			mgr := trustbundle.NewManager(newFakeClient(trustBundleConfigMap("corporate-ca", string(caBundle))))
+
+			bundle, err := mgr.Load(ctx)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bundle.Kind).To(Equal(trustbundle.ConfigMapKind))
+			Expect(bundle.Name).To(Equal("corporate-ca"))
+			Expect(bundle.Key).To(Equal("ca-bundle.crt"))
+			Expect(bundle.Hash).NotTo(BeEmpty())
+		})
+
+		It("should load the bundle from a Secret with a custom key", func() {
+			config.TrustBundleSecret = "corporate-ca"
+			config.TrustBundleKey = "ca.pem"
+			mgr := trustbundle.NewManager(newFakeClient(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "corporate-ca", Namespace: kymaNamespace},
+				Data:       map[string][]byte{"ca.pem": caBundle},
+			}))
+
+			bundle, err := mgr.Load(ctx)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bundle.Kind).To(Equal(trustbundle.SecretKind))
+			Expect(bundle.Key).To(Equal("ca.pem"))
+		})
+
+		It("should change the hash when the bundle content changes", func() {
+			config.TrustBundleConfigMap = "corporate-ca"
+			cm := trustBundleConfigMap("corporate-ca", string(caBundle))
+			fakeClient := newFakeClient(cm)
+			mgr := trustbundle.NewManager(fakeClient)
+			before, err := mgr.Load(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			cm.Data["ca-bundle.crt"] = string(caBundle) + string(caBundle)
+			Expect(fakeClient.Update(ctx, cm)).To(Succeed())
+			after, err := mgr.Load(ctx)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(after.Hash).NotTo(Equal(before.Hash))
+		})
+
+		It("should fail when both sources are configured", func() {
+			config.TrustBundleConfigMap = "corporate-ca"
+			config.TrustBundleSecret = "corporate-ca"
+			mgr := trustbundle.NewManager(newFakeClient())
+
+			_, err := mgr.Load(ctx)
+
+			Expect(err).To(MatchError(trustbundle.ErrBothSourcesSet))
+		})
+
+		It("should fail when the source does not exist", func() {
+			config.TrustBundleConfigMap = "corporate-ca"
+			mgr := trustbundle.NewManager(newFakeClient())
+
+			_, err := mgr.Load(ctx)
+
+			Expect(err).To(MatchError(ContainSubstring("trust bundle ConfigMap corporate-ca not found")))
+		})
+
+		It("should fail when the key is missing", func() {
+			config.TrustBundleConfigMap = "corporate-ca"
+			config.TrustBundleKey = "other.crt"
+			mgr := trustbundle.NewManager(newFakeClient(trustBundleConfigMap("corporate-ca", string(caBundle))))
+
+			_, err := mgr.Load(ctx)
+
+			Expect(err).To(MatchError(ContainSubstring("does not contain key other.crt")))
+		})
+
+		It("should fail when the bundle contains no certificates", func() {
+			config.TrustBundleConfigMap = "corporate-ca"
+			mgr := trustbundle.NewManager(newFakeClient(trustBundleConfigMap("corporate-ca", "not a certificate")))
+
+			_, err := mgr.Load(ctx)
+
+			Expect(err).To(MatchError(ContainSubstring("no PEM encoded certificates found")))
+		})
+	})
+
+	Describe("PrepareDeployment", func() {
+		var origDeploymentName string
+
+		BeforeEach(func() {
+			origDeploymentName = config.DeploymentName
+			config.DeploymentName = deploymentName
+		})
+
+		AfterEach(func() {
+			config.DeploymentName = origDeploymentName
+		})
+
+		It("should leave the Deployment untouched when no trust bundle is configured", func() {
+			deployment := operandDeployment()
+			expected := deployment.DeepCopy()
+
+			Expect(trustbundle.NewManager(newFakeClient()).PrepareDeployment(ctx, []*unstructured.Unstructured{deployment})).To(Succeed())
+
+			Expect(deployment).To(Equal(expected))
+		})
+
+		It("should mount the bundle and annotate the Pod template with its hash", func() {
+			config.TrustBundleConfigMap = "corporate-ca"
+			mgr := trustbundle.NewManager(newFakeClient(trustBundleConfigMap("corporate-ca", string(caBundle))))
+			u := operandDeployment()
+
+			Expect(mgr.PrepareDeployment(ctx, []*unstructured.Unstructured{u})).To(Succeed())
+
+			bundle, err := mgr.Load(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			deployment := toDeployment(u)
+			Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue(trustbundle.HashAnnotationKey, bundle.Hash))
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(trustbundle.Volume(trustbundle.ConfigMapKind, "corporate-ca", "ca-bundle.crt")))
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.VolumeMounts).To(ContainElement(trustbundle.VolumeMount()))
+			Expect(container.Env).To(ContainElements(
+				corev1.EnvVar{Name: "APP_VERSION", Value: "v1"},
+				corev1.EnvVar{Name: trustbundle.SslCertDirEnv, Value: "/etc/ssl/certs:/etc/ssl/custom-ca"},
+			))
+		})
+
+		It("should not duplicate the volume when applied twice", func() {
+			config.TrustBundleConfigMap = "corporate-ca"
+			mgr := trustbundle.NewManager(newFakeClient(trustBundleConfigMap("corporate-ca", string(caBundle))))
+			u := operandDeployment()
+
+			Expect(mgr.PrepareDeployment(ctx, []*unstructured.Unstructured{u})).To(Succeed())
+			Expect(mgr.PrepareDeployment(ctx, []*unstructured.Unstructured{u})).To(Succeed())
+
+			deployment := toDeployment(u)
+			Expect(deployment.Spec.Template.Spec.Volumes).To(HaveLen(1))
+			Expect(deployment.Spec.Template.Spec.Containers[0].VolumeMounts).To(HaveLen(1))
+			Expect(deployment.Spec.Template.Spec.Containers[0].Env).To(HaveLen(2))
+		})
+
+		It("should fail when the trust bundle cannot be loaded", func() {
+			config.TrustBundleConfigMap = "corporate-ca"
+			mgr := trustbundle.NewManager(newFakeClient())
+
+			Expect(mgr.PrepareDeployment(ctx, []*unstructured.Unstructured{operandDeployment()})).NotTo(Succeed())
+		})
+	})
+
+	Describe("WatchHandlers", func() {
+		It("should only match the configured trust bundle source", func() {
+			config.TrustBundleSecret = "corporate-ca"
+			handlers := trustbundle.WatchHandlers(nil)
+			Expect(handlers).To(HaveLen(2))
+
+			configMapHandler, secretHandler := handlers[0], handlers[1]
+			secret := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "corporate-ca", Namespace: kymaNamespace}}
+			otherSecret := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: kymaNamespace}}
+			cm := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "corporate-ca", Namespace: kymaNamespace}}
+
+			Expect(secretHandler.Predicates().Create(event.CreateEvent{Object: secret})).To(BeTrue())
+			Expect(secretHandler.Predicates().Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: secret})).To(BeTrue())
+			Expect(secretHandler.Predicates().Create(event.CreateEvent{Object: otherSecret})).To(BeFalse())
+			Expect(configMapHandler.Predicates().Create(event.CreateEvent{Object: cm})).To(BeFalse())
+			Expect(secretHandler.Reconcile(ctx, secret)).To(HaveLen(1))
+		})
+
+		It("should watch only the metadata through the trust bundle cache", func() {
+			watchCache, err := trustbundle.NewWatchCache(&rest.Config{Host: "https://localhost"}, scheme, meta.NewDefaultRESTMapper(nil), kymaNamespace)
+			Expect(err).NotTo(HaveOccurred())
+
+			for i, kind := range []string{"ConfigMap", "Secret"} {
+				handler, ok := trustbundle.WatchHandlers(watchCache)[i].(config.CacheWatchHandler)
+				Expect(ok).To(BeTrue())
+				Expect(handler.Cache()).To(BeIdenticalTo(watchCache))
+				Expect(handler.Object()).To(BeAssignableToTypeOf(&metav1.PartialObjectMetadata{}))
+				Expect(handler.Object().GetObjectKind().GroupVersionKind().Kind).To(Equal(kind))
+			}
+		})
+	})
+})
+
+func trustBundleConfigMap(name, content string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: kymaNamespace},
+		Data:       map[string]string{"ca-bundle.crt": content},
+	}
+}
+
+func operandDeployment() *unstructured.Unstructured {
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: deploymentName, Namespace: kymaNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "manager",
+						Env:  []corev1.EnvVar{{Name: "APP_VERSION", Value: "v1"}},
+					}},
+				},
+			},
+		},
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(deployment)
+	Expect(err).NotTo(HaveOccurred())
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func toDeployment(u *unstructured.Unstructured) *appsv1.Deployment {
+	deployment := &appsv1.Deployment{}
+	Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, deployment)).To(Succeed())
+	return deployment
+}
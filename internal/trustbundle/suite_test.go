@@ -0,0 +1,46 @@
+package trustbundle_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kyma-project/btp-manager/internal/certs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	kymaNamespace  = "kyma-system"
+	deploymentName = "sap-btp-operator-controller-manager"
+)
+
+var (
+	scheme   *runtime.Scheme
+	caBundle []byte
+)
+
+func TestTrustBundle(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Trust Bundle Suite")
+}
+
+var _ = BeforeSuite(func() {
+	scheme = runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+
+	var err error
+	caBundle, _, err = certs.GenerateSelfSignedCertificate(time.Now().Add(time.Hour))
+	Expect(err).NotTo(HaveOccurred())
+})
+
+func newFakeClient(objects ...client.Object) client.Client {
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		Build()
+}
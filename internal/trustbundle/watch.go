@@ -0,0 +1,83 @@
+package trustbundle
+
+import (
+	"context"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// WatchHandler requeues the BtpOperator CR when the configured trust bundle ConfigMap or Secret changes.
+// It watches only the metadata of the objects, through a cache that, unlike the manager cache, isn't limited to
+// the objects labeled as managed by BTP Manager, so that changes of user-provided trust bundles are picked up immediately.
+type WatchHandler struct {
+	object client.Object
+	name   func() string
+	cache  cache.Cache
+}
+
+// NewWatchCache returns the cache for the WatchHandlers. It holds the metadata of the ConfigMaps and Secrets in the namespace.
+// Add it to the manager, so that it's started with the controllers.
+func NewWatchCache(cfg *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper, namespace string) (cache.Cache, error) {
+	return cache.New(cfg, cache.Options{
+		Scheme:            scheme,
+		Mapper:            mapper,
+		DefaultNamespaces: map[string]cache.Config{namespace: {}},
+	})
+}
+
+// WatchHandlers returns the handlers for both supported trust bundle sources, watched through the cache created with NewWatchCache.
+func WatchHandlers(c cache.Cache) []config.WatchHandler {
+	return []config.WatchHandler{
+		&WatchHandler{object: metadataOf("ConfigMap"), name: func() string { return config.Current().TrustBundleConfigMap }, cache: c},
+		&WatchHandler{object: metadataOf("Secret"), name: func() string { return config.Current().TrustBundleSecret }, cache: c},
+	}
+}
+
+func metadataOf(kind string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: kind}}
+}
+
+var _ config.CacheWatchHandler = (*WatchHandler)(nil)
+
+func (h *WatchHandler) Object() client.Object {
+	return h.object
+}
+
+func (h *WatchHandler) Cache() cache.Cache {
+	return h.cache
+}
+
+func (h *WatchHandler) Predicates() predicate.Funcs {
+	nameMatches := func(o client.Object) bool {
+		name := h.name()
+		return name != "" && o.GetName() == name && o.GetNamespace() == config.Current().ChartNamespace
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return nameMatches(e.Object)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return nameMatches(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return nameMatches(e.ObjectNew)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+func (h *WatchHandler) Reconcile(ctx context.Context, obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: config.BtpOperatorCrName, Namespace: config.KymaSystemNamespaceName}}}
+}
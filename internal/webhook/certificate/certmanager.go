@@ -0,0 +1,243 @@
+package certificate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/certs"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	SelfSignedMode  = "self-signed"
+	CertManagerMode = "cert-manager"
+
+	SelfSignedIssuerName   = "btp-manager-selfsigned-issuer"
+	CaIssuerName           = "btp-manager-ca-issuer"
+	CaCertificateName      = "btp-manager-ca"
+	WebhookCertificateName = "btp-manager-webhook"
+
+	// CertManagerCaField is the field in which cert-manager stores the issuing CA certificate.
+	CertManagerCaField = "ca.crt"
+
+	certManagerCertificateNameAnnotation = "cert-manager.io/certificate-name"
+
+	issuerKind      = "Issuer"
+	certificateKind = "Certificate"
+)
+
+var (
+	certManagerGroupVersion = schema.GroupVersion{Group: "cert-manager.io", Version: "v1"}
+
+	certManagerSecretPollInterval = time.Second
+)
+
+// CertManagerResourceTypes returns empty objects of the cert-manager kinds created in cert-manager mode.
+// Certificates come first, so that cert-manager stops reissuing Secrets before they are deleted.
+func CertManagerResourceTypes() []*unstructured.Unstructured {
+	return []*unstructured.Unstructured{
+		newCertManagerObject(certificateKind, ""),
+		newCertManagerObject(issuerKind, ""),
+	}
+}
+
+func certManagerModeEnabled() bool {
+	return config.Current().WebhookCertificateMode == CertManagerMode
+}
+
+// prepareWithCertManager makes sure the cert-manager Issuers and Certificates for the webhook Service exist,
+// waits until cert-manager issues the webhook certificate, and injects the issuing CA into the webhooks.
+func (m *Manager) prepareWithCertManager(ctx context.Context, webhookResources []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("preparing admission webhooks with cert-manager")
+
+	if m.client == nil {
+		return nil, fmt.Errorf("cert-manager mode requires a Kubernetes client")
+	}
+
+	for _, resource := range certManagerResources() {
+		if err := m.applyCertManagerResource(ctx, resource); err != nil {
+			return nil, err
+		}
+	}
+
+	caBundle, err := m.waitForIssuedWebhookCert(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("webhook certificate issued by cert-manager")
+	return m.prepareWebhooksManifests(ctx, webhookResources, caBundle)
+}
+
+func (m *Manager) applyCertManagerResource(ctx context.Context, u *unstructured.Unstructured) error {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(u.GroupVersionKind())
+	err := m.client.Get(ctx, client.ObjectKey{Name: u.GetName(), Namespace: u.GetNamespace()}, existing)
+	switch {
+	case meta.IsNoMatchError(err):
+		return fmt.Errorf("cert-manager %s kind is not available in the cluster, install cert-manager or set WebhookCertificateMode to %s: %w", u.GetKind(), SelfSignedMode, err)
+	case k8serrors.IsNotFound(err):
+		if err := m.client.Create(ctx, u, client.FieldOwner(operatorName)); err != nil {
+			return fmt.Errorf("while creating %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("while getting %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+
+	u.SetResourceVersion(existing.GetResourceVersion())
+	if err := m.client.Update(ctx, u, client.FieldOwner(operatorName)); err != nil {
+		return fmt.Errorf("while updating %s %s: %w", u.GetKind(), u.GetName(), err)
+	}
+	return nil
+}
+
+// waitForIssuedWebhookCert polls the webhook cert Secret until cert-manager has written it
+// for the webhook Certificate, and returns the CA bundle stored next to the certificate.
+func (m *Manager) waitForIssuedWebhookCert(ctx context.Context) ([]byte, error) {
+	logger := log.FromContext(ctx)
+	waitCtx, cancel := context.WithTimeout(ctx, config.Current().ReadyTimeout)
+	defer cancel()
+
+	for {
+		secret, err := m.secretsManager.GetWebhookServerCertSecret(waitCtx)
+		if err != nil && waitCtx.Err() == nil {
+			return nil, err
+		}
+		if caBundle, ok := issuedCaBundle(secret); ok {
+			return caBundle, nil
+		}
+
+		logger.Info("waiting for cert-manager to issue the webhook certificate")
+		select {
+		case <-waitCtx.Done():
+			return nil, fmt.Errorf("timed out waiting for cert-manager to issue secret %q", WebhookCertSecretName)
+		case <-time.After(certManagerSecretPollInterval):
+		}
+	}
+}
+
+func issuedCaBundle(secret *corev1.Secret) ([]byte, bool) {
+	if secret == nil || secret.Annotations[certManagerCertificateNameAnnotation] != WebhookCertificateName {
+		return nil, false
+	}
+	if len(secret.Data[WebhookCertSecretCertField]) == 0 || len(secret.Data[WebhookCertSecretKeyField]) == 0 {
+		return nil, false
+	}
+	caBundle := secret.Data[CertManagerCaField]
+	if len(caBundle) == 0 {
+		return nil, false
+	}
+	return caBundle, true
+}
+
+// isIssuedByCertManager reports whether the secret was written by cert-manager, e.g. before switching back to self-signed mode.
+func isIssuedByCertManager(secret *corev1.Secret) bool {
+	if secret == nil {
+		return false
+	}
+	_, ok := secret.Annotations[certManagerCertificateNameAnnotation]
+	return ok
+}
+
+// deleteCertManagerResources removes the Certificates and Issuers left over from cert-manager mode,
+// so that cert-manager does not overwrite the self-signed certificates.
+func (m *Manager) deleteCertManagerResources(ctx context.Context) error {
+	if m.client == nil {
+		return nil
+	}
+	log.FromContext(ctx).Info("deleting cert-manager resources left over from cert-manager mode")
+	for _, u := range certManagerResources() {
+		if err := m.client.Delete(ctx, u); err != nil && !k8serrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("while deleting %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+	}
+	return nil
+}
+
+func certManagerResources() []*unstructured.Unstructured {
+	cfg := config.Current()
+	secretTemplate := map[string]interface{}{
+		"labels": map[string]interface{}{managedByKey: operatorName},
+	}
+
+	selfSignedIssuer := newCertManagerObject(issuerKind, SelfSignedIssuerName)
+	selfSignedIssuer.Object["spec"] = map[string]interface{}{
+		"selfSigned": map[string]interface{}{},
+	}
+
+	caCertificate := newCertManagerObject(certificateKind, CaCertificateName)
+	caCertificate.Object["spec"] = map[string]interface{}{
+		"isCA":           true,
+		"commonName":     CaCertificateName,
+		"secretName":     CaCertSecretName,
+		"duration":       cfg.CaCertificateExpiration.String(),
+		"secretTemplate": secretTemplate,
+		"privateKey":     certManagerPrivateKey(),
+		"issuerRef": map[string]interface{}{
+			"name": SelfSignedIssuerName,
+			"kind": issuerKind,
+		},
+	}
+
+	caIssuer := newCertManagerObject(issuerKind, CaIssuerName)
+	caIssuer.Object["spec"] = map[string]interface{}{
+		"ca": map[string]interface{}{
+			"secretName": CaCertSecretName,
+		},
+	}
+
+	dnsNames := make([]interface{}, 0)
+	for _, name := range certs.WebhookDnsNames() {
+		dnsNames = append(dnsNames, name)
+	}
+	webhookCertificate := newCertManagerObject(certificateKind, WebhookCertificateName)
+	webhookCertificate.Object["spec"] = map[string]interface{}{
+		"secretName":     WebhookCertSecretName,
+		"dnsNames":       dnsNames,
+		"duration":       cfg.WebhookCertificateExpiration.String(),
+		"renewBefore":    (-cfg.ExpirationBoundary).String(),
+		"secretTemplate": secretTemplate,
+		"privateKey":     certManagerPrivateKey(),
+		"issuerRef": map[string]interface{}{
+			"name": CaIssuerName,
+			"kind": issuerKind,
+		},
+	}
+
+	return []*unstructured.Unstructured{selfSignedIssuer, caCertificate, caIssuer, webhookCertificate}
+}
+
+// certManagerPrivateKey maps the configured key algorithm to the privateKey field of a cert-manager Certificate.
+func certManagerPrivateKey() map[string]interface{} {
+	switch certs.KeyAlgorithm() {
+	case certs.EcdsaP256KeyAlgorithm:
+		return map[string]interface{}{"algorithm": "ECDSA", "size": int64(256)}
+	case certs.EcdsaP384KeyAlgorithm:
+		return map[string]interface{}{"algorithm": "ECDSA", "size": int64(384)}
+	case certs.Ed25519KeyAlgorithm:
+		return map[string]interface{}{"algorithm": "Ed25519"}
+	default:
+		return map[string]interface{}{"algorithm": "RSA", "size": int64(certs.RsaKeyBits())}
+	}
+}
+
+func newCertManagerObject(kind, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(certManagerGroupVersion.WithKind(kind))
+	if name != "" {
+		u.SetName(name)
+		u.SetNamespace(config.Current().ChartNamespace)
+		u.SetLabels(map[string]string{managedByKey: operatorName})
+	}
+	return u
+}
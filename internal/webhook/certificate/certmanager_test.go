@@ -0,0 +1,154 @@
+package certificate_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/certs"
+	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Certificate Manager in cert-manager mode", func() {
+	var (
+		mgr         *certificate.Manager
+		secretsMgr  *fakeSecretsManager
+		metrics     *fakeWebhookMetrics
+		k8sClient   client.Client
+		ctx         context.Context
+		origMode    string
+		origTimeout time.Duration
+		origChartNs string
+	)
+
+	BeforeEach(func() {
+		origMode, origTimeout, origChartNs = config.WebhookCertificateMode, config.ReadyTimeout, config.ChartNamespace
+		config.WebhookCertificateMode = certificate.CertManagerMode
+		config.ChartNamespace = kymaNamespace
+
+		secretsMgr = &fakeSecretsManager{webhookCertSecret: certManagerWebhookSecret()}
+		metrics = &fakeWebhookMetrics{}
+		k8sClient = fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+		mgr = certificate.NewManager(secretsMgr, metrics, k8sClient)
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		config.WebhookCertificateMode, config.ReadyTimeout, config.ChartNamespace = origMode, origTimeout, origChartNs
+	})
+
+	It("creates the Issuers and Certificates for the webhook Service", func() {
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(getCertManagerObject(ctx, k8sClient, "Issuer", certificate.SelfSignedIssuerName)).To(Succeed())
+		Expect(getCertManagerObject(ctx, k8sClient, "Issuer", certificate.CaIssuerName)).To(Succeed())
+		Expect(getCertManagerObject(ctx, k8sClient, "Certificate", certificate.CaCertificateName)).To(Succeed())
+
+		webhookCert := &unstructured.Unstructured{}
+		webhookCert.SetGroupVersionKind(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"})
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: certificate.WebhookCertificateName, Namespace: kymaNamespace}, webhookCert)).To(Succeed())
+		secretName, _, _ := unstructured.NestedString(webhookCert.Object, "spec", "secretName")
+		Expect(secretName).To(Equal(certificate.WebhookCertSecretName))
+		issuer, _, _ := unstructured.NestedString(webhookCert.Object, "spec", "issuerRef", "name")
+		Expect(issuer).To(Equal(certificate.CaIssuerName))
+		dnsNames, _, _ := unstructured.NestedStringSlice(webhookCert.Object, "spec", "dnsNames")
+		Expect(dnsNames).To(ContainElement("sap-btp-operator-webhook-service.kyma-system.svc"))
+		Expect(webhookCert.GetLabels()).To(HaveKeyWithValue("app.kubernetes.io/managed-by", "btp-manager"))
+	})
+
+	It("requests private keys of the configured algorithm", func() {
+		Expect(certs.SetKeyAlgorithm(certs.EcdsaP384KeyAlgorithm)).To(Succeed())
+		defer func() { Expect(certs.SetKeyAlgorithm(certs.RsaKeyAlgorithm)).To(Succeed()) }()
+
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		webhookCert := &unstructured.Unstructured{}
+		webhookCert.SetGroupVersionKind(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"})
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: certificate.WebhookCertificateName, Namespace: kymaNamespace}, webhookCert)).To(Succeed())
+		privateKey, _, _ := unstructured.NestedMap(webhookCert.Object, "spec", "privateKey")
+		Expect(privateKey).To(Equal(map[string]interface{}{"algorithm": "ECDSA", "size": int64(384)}))
+	})
+
+	It("injects the CA issued by cert-manager and does not return any Secrets", func() {
+		result, err := mgr.PrepareAdmissionWebhooks(ctx, []*unstructured.Unstructured{
+			validatingWebhookConfig("test-validating"),
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secretNamesIn(result)).To(BeEmpty())
+		Expect(caBundleIn(webhookResourceIn(result))).To(Equal(validCACert))
+	})
+
+	It("skips certificate regeneration", func() {
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metrics.counter).To(BeZero())
+	})
+
+	It("updates Certificates that already exist", func() {
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = mgr.PrepareAdmissionWebhooks(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("times out when cert-manager does not issue the webhook certificate", func() {
+		config.ReadyTimeout = 100 * time.Millisecond
+		secretsMgr.webhookCertSecret = webhookSecret(validWebhookCert, validWebhookKey)
+
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+		Expect(err).To(MatchError(ContainSubstring("timed out waiting for cert-manager")))
+	})
+
+	It("fails without a Kubernetes client", func() {
+		mgr = certificate.NewManager(secretsMgr, metrics, nil)
+
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("deletes the cert-manager resources and regenerates certificates after switching back to self-signed mode", func() {
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		config.WebhookCertificateMode = certificate.SelfSignedMode
+		caSecretFromCertManager := caSecret(validCACert, nil)
+		caSecretFromCertManager.Annotations = map[string]string{"cert-manager.io/certificate-name": certificate.CaCertificateName}
+		secretsMgr.caCertSecret = caSecretFromCertManager
+
+		result, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secretNamesIn(result)).To(ConsistOf(certificate.CaCertSecretName, certificate.WebhookCertSecretName))
+		Expect(metrics.counter).To(Equal(1))
+		Expect(getCertManagerObject(ctx, k8sClient, "Certificate", certificate.WebhookCertificateName)).NotTo(Succeed())
+		Expect(getCertManagerObject(ctx, k8sClient, "Issuer", certificate.CaIssuerName)).NotTo(Succeed())
+	})
+})
+
+func getCertManagerObject(ctx context.Context, k8sClient client.Client, kind, name string) error {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: kind})
+	return k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: kymaNamespace}, u)
+}
+
+func certManagerWebhookSecret() *corev1.Secret {
+	s := webhookSecret(validWebhookCert, validWebhookKey)
+	s.Annotations = map[string]string{"cert-manager.io/certificate-name": certificate.WebhookCertificateName}
+	s.Data[certificate.CertManagerCaField] = validCACert
+	return s
+}
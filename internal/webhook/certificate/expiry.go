@@ -0,0 +1,174 @@
+package certificate
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/certs"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	CaCertificateLabel          = "ca"
+	WebhookCertificateLabel     = "webhook"
+	CredentialsCertificateLabel = "credentials"
+
+	// CredentialsCertField is the field of the required Secret with the client certificate used instead of the client secret.
+	CredentialsCertField = "tls.crt"
+)
+
+var certificateExpiryCheckInterval = time.Minute
+
+// ExpiryMetrics is the metrics sink for the remaining lifetime of the certificates.
+type ExpiryMetrics interface {
+	SetCertificateExpiration(certificate string, notAfter time.Time)
+	DeleteCertificateExpiration(certificate string)
+}
+
+// ExpiryMonitor is a controller-runtime Runnable that periodically reports the expiration of the CA, webhook
+// and credentials certificates, and sets the CertificatesValid condition of the BtpOperator CR to False when
+// the CA or webhook certificate stays within ExpirationBoundary for CertificateRegenerationGracePeriod,
+// which means that its regeneration keeps failing.
+type ExpiryMonitor struct {
+	client        client.Client
+	apiReader     client.Reader
+	metrics       ExpiryMetrics
+	now           func() time.Time
+	expiringSince map[string]time.Time
+}
+
+func NewExpiryMonitor(k8sClient client.Client, metrics ExpiryMetrics) *ExpiryMonitor {
+	return &ExpiryMonitor{
+		client:        k8sClient,
+		metrics:       metrics,
+		now:           func() time.Time { return time.Now().UTC() },
+		expiringSince: make(map[string]time.Time),
+	}
+}
+
+// WithAPIReader sets the uncached reader used to read the external CA Secret.
+func (m *ExpiryMonitor) WithAPIReader(apiReader client.Reader) *ExpiryMonitor {
+	m.apiReader = apiReader
+	return m
+}
+
+// Start implements manager.Runnable.
+func (m *ExpiryMonitor) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("certificate-expiry-monitor")
+	logger.Info("certificate expiry monitor started", "interval", certificateExpiryCheckInterval)
+
+	ticker := time.NewTicker(certificateExpiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Check(log.IntoContext(ctx, logger)); err != nil {
+			logger.Error(err, "certificate expiry check failed")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check updates the expiration metrics and the CertificatesValid condition.
+func (m *ExpiryMonitor) Check(ctx context.Context) error {
+	cfg := config.Current()
+	monitored := []struct {
+		label, secretName, certField string
+		regenerated                  bool
+		reader                       client.Reader
+	}{
+		{label: CaCertificateLabel, secretName: CaCertSecretName, certField: CaCertSecretCertField, regenerated: true, reader: m.client},
+		{label: WebhookCertificateLabel, secretName: WebhookCertSecretName, certField: WebhookCertSecretCertField, regenerated: true, reader: m.client},
+		{label: CredentialsCertificateLabel, secretName: cfg.SecretName, certField: CredentialsCertField, reader: m.client},
+	}
+	if externalCaEnabled() {
+		// The external CA is renewed by its owner, so only its lifetime is reported.
+		monitored[0].secretName, monitored[0].certField, monitored[0].regenerated = cfg.ExternalCaSecret, ExternalCaCertField, false
+		if m.apiReader != nil {
+			monitored[0].reader = m.apiReader
+		}
+	}
+
+	now := m.now()
+	failing := make([]string, 0)
+	for _, c := range monitored {
+		cert, err := readCertificate(ctx, c.reader, c.secretName, c.certField)
+		if err != nil {
+			return err
+		}
+		if cert == nil {
+			m.metrics.DeleteCertificateExpiration(c.label)
+			delete(m.expiringSince, c.label)
+			continue
+		}
+		m.metrics.SetCertificateExpiration(c.label, cert.NotAfter)
+
+		if !c.regenerated || !certs.CertificateExpires(cert, cfg.ExpirationBoundary) {
+			delete(m.expiringSince, c.label)
+			continue
+		}
+		since, ok := m.expiringSince[c.label]
+		if !ok {
+			since = now
+			m.expiringSince[c.label] = now
+		}
+		if now.Sub(since) >= cfg.CertificateRegenerationGracePeriod {
+			failing = append(failing, fmt.Sprintf("%s expires at %s", c.secretName, cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+
+	return m.setCondition(ctx, failing)
+}
+
+// readCertificate returns nil if the Secret or the certificate does not exist, or the certificate cannot be parsed.
+func readCertificate(ctx context.Context, reader client.Reader, secretName, certField string) (*x509.Certificate, error) {
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: secretName, Namespace: config.Current().ChartNamespace}, secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("while getting secret %q: %w", secretName, err)
+	}
+	if len(secret.Data[certField]) == 0 {
+		return nil, nil
+	}
+	block, err := certs.DecodeCertificate(secret.Data[certField])
+	if err != nil {
+		log.FromContext(ctx).Info("cannot decode certificate", "secret", secretName, "error", err.Error())
+		return nil, nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		log.FromContext(ctx).Info("cannot parse certificate", "secret", secretName, "error", err.Error())
+		return nil, nil
+	}
+	return cert, nil
+}
+
+func (m *ExpiryMonitor) setCondition(ctx context.Context, failing []string) error {
+	condition := metav1.Condition{
+		Type:    conditions.CertificatesValidType,
+		Status:  metav1.ConditionTrue,
+		Reason:  string(conditions.CertificatesValid),
+		Message: "Certificates are valid",
+	}
+	if len(failing) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = string(conditions.CertificateRegenerationFailing)
+		condition.Message = fmt.Sprintf("Regeneration of certificates within the expiration boundary keeps failing: %s", strings.Join(failing, ", "))
+	}
+
+	return setBtpOperatorCondition(ctx, m.client, condition)
+}
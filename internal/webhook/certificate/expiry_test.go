@@ -0,0 +1,150 @@
+package certificate_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Certificate Expiry Monitor", func() {
+	var (
+		monitor     *certificate.ExpiryMonitor
+		metrics     *fakeExpiryMetrics
+		k8sClient   client.Client
+		ctx         context.Context
+		origChartNs string
+		origGrace   time.Duration
+	)
+
+	newClient := func(objs ...client.Object) client.Client {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: config.BtpOperatorCrName, Namespace: kymaNamespace}}
+		return fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objs, cr)...).WithStatusSubresource(cr).Build()
+	}
+
+	certificatesValid := func() *metav1.Condition {
+		cr := &v1alpha1.BtpOperator{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: config.BtpOperatorCrName, Namespace: kymaNamespace}, cr)).To(Succeed())
+		for _, c := range cr.Status.Conditions {
+			if c.Type == conditions.CertificatesValidType {
+				return c
+			}
+		}
+		return nil
+	}
+
+	BeforeEach(func() {
+		origChartNs, origGrace = config.ChartNamespace, config.CertificateRegenerationGracePeriod
+		config.ChartNamespace = kymaNamespace
+		metrics = &fakeExpiryMetrics{expirations: map[string]time.Time{}}
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		config.ChartNamespace, config.CertificateRegenerationGracePeriod = origChartNs, origGrace
+	})
+
+	It("reports the remaining lifetime of the certificates", func() {
+		credentials := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: config.SecretName, Namespace: kymaNamespace},
+			Data:       map[string][]byte{certificate.CredentialsCertField: expiringWebhookCert},
+		}
+		k8sClient = newClient(caSecret(validCACert, validCAKey), webhookSecret(validWebhookCert, validWebhookKey), credentials)
+		monitor = certificate.NewExpiryMonitor(k8sClient, metrics)
+
+		Expect(monitor.Check(ctx)).To(Succeed())
+
+		Expect(metrics.expirations).To(HaveLen(3))
+		Expect(metrics.expirations).To(HaveKey(certificate.CaCertificateLabel))
+		Expect(metrics.expirations).To(HaveKey(certificate.WebhookCertificateLabel))
+		Expect(metrics.expirations).To(HaveKey(certificate.CredentialsCertificateLabel))
+		Expect(certificatesValid().Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("removes the metric of a certificate that does not exist", func() {
+		k8sClient = newClient(caSecret(validCACert, validCAKey))
+		monitor = certificate.NewExpiryMonitor(k8sClient, metrics)
+		metrics.expirations[certificate.CredentialsCertificateLabel] = time.Now()
+
+		Expect(monitor.Check(ctx)).To(Succeed())
+
+		Expect(metrics.expirations).To(HaveLen(1))
+		Expect(metrics.expirations).To(HaveKey(certificate.CaCertificateLabel))
+	})
+
+	It("does not report failing regeneration within the grace period", func() {
+		k8sClient = newClient(caSecret(expiringCACert, expiringCAKey), webhookSecret(validWebhookCert, validWebhookKey))
+		monitor = certificate.NewExpiryMonitor(k8sClient, metrics)
+
+		Expect(monitor.Check(ctx)).To(Succeed())
+
+		Expect(certificatesValid().Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("reports failing regeneration after the grace period", func() {
+		config.CertificateRegenerationGracePeriod = 0
+		k8sClient = newClient(caSecret(validCACert, validCAKey), webhookSecret(expiringWebhookCert, expiringWebhookKey))
+		monitor = certificate.NewExpiryMonitor(k8sClient, metrics)
+
+		Expect(monitor.Check(ctx)).To(Succeed())
+
+		condition := certificatesValid()
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(conditions.CertificateRegenerationFailing)))
+		Expect(condition.Message).To(ContainSubstring(certificate.WebhookCertSecretName))
+		Expect(condition.Message).NotTo(ContainSubstring(certificate.CaCertSecretName))
+	})
+
+	It("reports the external CA without treating it as regenerated", func() {
+		origSecret := config.ExternalCaSecret
+		defer func() { config.ExternalCaSecret = origSecret }()
+		config.ExternalCaSecret, config.CertificateRegenerationGracePeriod = externalCaSecretName, 0
+		k8sClient = newClient(externalCaSecret(expiringCACert, expiringCAKey), webhookSecret(validWebhookCert, validWebhookKey))
+		monitor = certificate.NewExpiryMonitor(k8sClient, metrics)
+
+		Expect(monitor.Check(ctx)).To(Succeed())
+
+		Expect(metrics.expirations).To(HaveKey(certificate.CaCertificateLabel))
+		Expect(certificatesValid().Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("clears the condition once the certificate is regenerated", func() {
+		config.CertificateRegenerationGracePeriod = 0
+		k8sClient = newClient(caSecret(validCACert, validCAKey), webhookSecret(expiringWebhookCert, expiringWebhookKey))
+		monitor = certificate.NewExpiryMonitor(k8sClient, metrics)
+		Expect(monitor.Check(ctx)).To(Succeed())
+		Expect(certificatesValid().Status).To(Equal(metav1.ConditionFalse))
+
+		Expect(k8sClient.Update(ctx, webhookSecret(validWebhookCert, validWebhookKey))).To(Succeed())
+		Expect(monitor.Check(ctx)).To(Succeed())
+
+		Expect(certificatesValid().Status).To(Equal(metav1.ConditionTrue))
+	})
+})
+
+// fakeExpiryMetrics is a minimal test double for certificate.ExpiryMetrics.
+type fakeExpiryMetrics struct {
+	expirations map[string]time.Time
+}
+
+func (f *fakeExpiryMetrics) SetCertificateExpiration(certificate string, notAfter time.Time) {
+	f.expirations[certificate] = notAfter
+}
+
+func (f *fakeExpiryMetrics) DeleteCertificateExpiration(certificate string) {
+	delete(f.expirations, certificate)
+}
@@ -0,0 +1,143 @@
+package certificate
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/certs"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ExternalCaCertField holds the external CA certificate, optionally followed by the rest of its chain.
+	ExternalCaCertField = "tls.crt"
+	ExternalCaKeyField  = "tls.key"
+)
+
+func externalCaEnabled() bool {
+	return config.Current().ExternalCaSecret != "" && !certManagerModeEnabled()
+}
+
+// prepareWithExternalCa signs the webhook certificate with the CA from the ExternalCaSecret Secret.
+// The external CA is read-only: BTP Manager never regenerates or rotates it, and only regenerates the webhook certificate.
+func (m *Manager) prepareWithExternalCa(ctx context.Context, webhookResources []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	logger := log.FromContext(ctx)
+	cfg := config.Current()
+	logger.Info("preparing admission webhooks with external CA", "secret", cfg.ExternalCaSecret)
+
+	caSecret, err := m.getExternalCaSecret(ctx)
+	if err != nil {
+		return nil, err
+	}
+	caCert, caKey, err := validateExternalCa(ctx, caSecret)
+	if err != nil {
+		return nil, fmt.Errorf("external CA secret %q: %w", cfg.ExternalCaSecret, err)
+	}
+	if err := m.deleteSelfSignedCa(ctx); err != nil {
+		return nil, err
+	}
+	caCertSecretData := map[string][]byte{CaCertSecretCertField: caSecret.Data[ExternalCaCertField], CaCertSecretKeyField: caKey}
+
+	logger.Info("checking webhook certificate")
+	webhookCertSecret, err := m.secretsManager.GetWebhookServerCertSecret(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if webhookCertSecret == nil {
+		logger.Info("webhook cert secret does not exist")
+		return m.regenerateWebhookCertificate(ctx, webhookResources, caCertSecretData)
+	}
+	if err := m.validateWebhookCert(webhookCertSecret, caSecret.Data[ExternalCaCertField]); err != nil {
+		// A webhook certificate cannot outlive the CA, so it is not regenerated on every reconciliation when the external CA expires soon.
+		caExpires := certs.CertificateExpires(caCert, cfg.ExpirationBoundary)
+		if !errors.Is(err, errCertExpiresSoon) || !caExpires {
+			logger.Info(fmt.Sprintf("webhook cert is not valid: %s", err))
+			return m.regenerateWebhookCertificate(ctx, webhookResources, caCertSecretData)
+		}
+		if err := verifyCASign(caSecret.Data[ExternalCaCertField], webhookCertSecret.Data[WebhookCertSecretCertField]); err != nil {
+			logger.Info(fmt.Sprintf("webhook cert is not valid: %s", err))
+			return m.regenerateWebhookCertificate(ctx, webhookResources, caCertSecretData)
+		}
+	}
+
+	logger.Info("webhook certificate signed by the external CA is valid")
+	return m.prepareWebhooksManifests(ctx, webhookResources, caSecret.Data[ExternalCaCertField])
+}
+
+func (m *Manager) getExternalCaSecret(ctx context.Context) (*corev1.Secret, error) {
+	cfg := config.Current()
+	reader := m.apiReader
+	if reader == nil {
+		reader = m.client
+	}
+	if reader == nil {
+		return nil, fmt.Errorf("external CA requires a Kubernetes client")
+	}
+
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: cfg.ExternalCaSecret, Namespace: cfg.ChartNamespace}, secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, fmt.Errorf("external CA secret %q not found in namespace %q", cfg.ExternalCaSecret, cfg.ChartNamespace)
+		}
+		return nil, fmt.Errorf("while getting external CA secret %q: %w", cfg.ExternalCaSecret, err)
+	}
+	return secret, nil
+}
+
+// validateExternalCa checks that the Secret holds a CA certificate that can sign the webhook certificate.
+// An expiring CA is only reported, because it has to be renewed by its owner.
+func validateExternalCa(ctx context.Context, secret *corev1.Secret) (*x509.Certificate, []byte, error) {
+	encodedCert, err := getSecretDataValueByKey(ExternalCaCertField, secret.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+	encodedKey, err := getSecretDataValueByKey(ExternalCaKeyField, secret.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := certs.VerifyKeyPair(encodedCert, encodedKey); err != nil {
+		return nil, nil, err
+	}
+	block, err := certs.DecodeCertificate(encodedCert)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !cert.IsCA || (cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0) {
+		return nil, nil, fmt.Errorf("cert is not allowed to sign certificates")
+	}
+	if time.Now().After(cert.NotAfter) {
+		return nil, nil, fmt.Errorf("cert expired at %s", cert.NotAfter.Format(time.RFC3339))
+	}
+	if certs.CertificateExpires(cert, config.Current().ExpirationBoundary) {
+		log.FromContext(ctx).Info("external CA expires soon and has to be renewed by its owner", "secret", secret.Name, "expiration", cert.NotAfter)
+	}
+	return cert, encodedKey, nil
+}
+
+// deleteSelfSignedCa removes the self-signed CA left from the self-signed mode, so that no self-signed CA remains in the cluster.
+func (m *Manager) deleteSelfSignedCa(ctx context.Context) error {
+	caCertSecret, err := m.secretsManager.GetCaServerCertSecret(ctx)
+	if err != nil {
+		return err
+	}
+	if caCertSecret == nil || m.client == nil {
+		return nil
+	}
+	log.FromContext(ctx).Info("deleting self-signed CA secret", "secret", CaCertSecretName)
+	if err := m.client.Delete(ctx, caCertSecret); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("while deleting secret %q: %w", CaCertSecretName, err)
+	}
+	return nil
+}
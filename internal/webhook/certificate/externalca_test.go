@@ -0,0 +1,153 @@
+package certificate_test
+
+import (
+	"context"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/certs"
+	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const externalCaSecretName = "org-intermediate-ca"
+
+var _ = Describe("Certificate Manager with external CA", func() {
+	var (
+		mgr         *certificate.Manager
+		secretsMgr  *fakeSecretsManager
+		metrics     *fakeWebhookMetrics
+		k8sClient   client.Client
+		ctx         context.Context
+		origChartNs string
+		origSecret  string
+	)
+
+	newManager := func(objs ...client.Object) {
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build()
+		mgr = certificate.NewManager(secretsMgr, metrics, k8sClient)
+	}
+
+	BeforeEach(func() {
+		origChartNs, origSecret = config.ChartNamespace, config.ExternalCaSecret
+		config.ChartNamespace, config.ExternalCaSecret = kymaNamespace, externalCaSecretName
+		secretsMgr = &fakeSecretsManager{}
+		metrics = &fakeWebhookMetrics{}
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		config.ChartNamespace, config.ExternalCaSecret = origChartNs, origSecret
+	})
+
+	It("signs the webhook certificate with the external CA", func() {
+		newManager(externalCaSecret(validCACert, validCAKey))
+
+		result, err := mgr.PrepareAdmissionWebhooks(ctx, []*unstructured.Unstructured{mutatingWebhookConfig("test-mutating")})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secretNamesIn(result)).To(ConsistOf(certificate.WebhookCertSecretName))
+		Expect(caBundleIn(webhookResourceIn(result))).To(Equal(validCACert))
+		signed, err := certs.VerifyIfLeafIsSignedByGivenCA(validCACert, secretIn(result, certificate.WebhookCertSecretName).Data[webhookCertField])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signed).To(BeTrue())
+		Expect(metrics.counter).To(Equal(1))
+	})
+
+	It("keeps a valid webhook certificate signed by the external CA", func() {
+		secretsMgr.webhookCertSecret = webhookSecret(validWebhookCert, validWebhookKey)
+		newManager(externalCaSecret(validCACert, validCAKey))
+
+		result, err := mgr.PrepareAdmissionWebhooks(ctx, []*unstructured.Unstructured{mutatingWebhookConfig("test-mutating")})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secretNamesIn(result)).To(BeEmpty())
+		Expect(caBundleIn(webhookResourceIn(result))).To(Equal(validCACert))
+	})
+
+	It("regenerates only the webhook certificate when it is signed by another CA", func() {
+		secretsMgr.webhookCertSecret = webhookSecret(wrongSignedWebhookCert, wrongSignedWebhookKey)
+		newManager(externalCaSecret(validCACert, validCAKey))
+
+		result, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secretNamesIn(result)).To(ConsistOf(certificate.WebhookCertSecretName))
+	})
+
+	It("does not regenerate the webhook certificate on every reconciliation when the external CA expires soon", func() {
+		newManager(externalCaSecret(expiringCACert, expiringCAKey))
+		result, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+		Expect(err).NotTo(HaveOccurred())
+		secretsMgr.webhookCertSecret = secretIn(result, certificate.WebhookCertSecretName)
+
+		result, err = mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secretNamesIn(result)).To(BeEmpty())
+		Expect(metrics.counter).To(Equal(1))
+	})
+
+	It("deletes the self-signed CA secret", func() {
+		secretsMgr.caCertSecret = caSecret(validCACert, validCAKey)
+		newManager(externalCaSecret(validCACert, validCAKey), caSecret(validCACert, validCAKey))
+
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+		Expect(err).NotTo(HaveOccurred())
+		err = k8sClient.Get(ctx, client.ObjectKey{Name: certificate.CaCertSecretName, Namespace: kymaNamespace}, &corev1.Secret{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("does not modify the external CA secret", func() {
+		newManager(externalCaSecret(validCACert, validCAKey))
+
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getSecret(ctx, k8sClient, externalCaSecretName).Data).To(Equal(externalCaSecret(validCACert, validCAKey).Data))
+	})
+
+	It("fails when the external CA secret does not exist", func() {
+		newManager()
+
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+	})
+
+	It("fails when the external certificate is not a CA", func() {
+		newManager(externalCaSecret(validWebhookCert, validWebhookKey))
+
+		_, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+		Expect(err).To(MatchError(ContainSubstring("not allowed to sign certificates")))
+	})
+})
+
+func externalCaSecret(cert, key []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: externalCaSecretName, Namespace: kymaNamespace},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{certificate.ExternalCaCertField: cert, certificate.ExternalCaKeyField: key},
+	}
+}
+
+func secretIn(resources []*unstructured.Unstructured, name string) *corev1.Secret {
+	for _, r := range resources {
+		if r.GetKind() == "Secret" && r.GetName() == name {
+			secret := &corev1.Secret{}
+			Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(r.Object, secret)).To(Succeed())
+			return secret
+		}
+	}
+	return nil
+}
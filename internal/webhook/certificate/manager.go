@@ -14,6 +14,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -79,18 +80,34 @@ func PartitionWebhooks(resources []*unstructured.Unstructured) (webhooks, rest [
 type Manager struct {
 	secretsManager secrets.Manager
 	webhookMetrics WebhookMetrics
+	// client manages cert-manager resources when WebhookCertificateMode is cert-manager.
+	client client.Client
+	// apiReader reads the external CA Secret, which is not labeled and therefore not cached.
+	apiReader client.Reader
 }
 
-func NewManager(secretsManager secrets.Manager, webhookMetrics WebhookMetrics) *Manager {
+func NewManager(secretsManager secrets.Manager, webhookMetrics WebhookMetrics, k8sClient client.Client) *Manager {
 	return &Manager{
 		secretsManager: secretsManager,
 		webhookMetrics: webhookMetrics,
+		client:         k8sClient,
 	}
 }
 
+// WithAPIReader sets the uncached reader used to read the external CA Secret.
+func (m *Manager) WithAPIReader(apiReader client.Reader) *Manager {
+	m.apiReader = apiReader
+	return m
+}
+
 var _ CertificateManager = (*Manager)(nil)
 
+var errCertExpiresSoon = errors.New("cert expires soon")
+
 func (m *Manager) IsWebhookCertSignedBySelfSignedCA(ctx context.Context) (bool, error) {
+	if externalCaEnabled() {
+		return false, nil
+	}
 	caSecret, err := m.secretsManager.GetCaServerCertSecret(ctx)
 	if err != nil {
 		return false, err
@@ -141,6 +158,13 @@ func (m *Manager) GetSecretData(ctx context.Context, name string) (map[string][]
 }
 
 func (m *Manager) PrepareAdmissionWebhooks(ctx context.Context, webhookResources []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	if certManagerModeEnabled() {
+		return m.prepareWithCertManager(ctx, webhookResources)
+	}
+	if externalCaEnabled() {
+		return m.prepareWithExternalCa(ctx, webhookResources)
+	}
+
 	logger := log.FromContext(ctx)
 	logger.Info("preparing admission webhooks")
 
@@ -149,6 +173,12 @@ func (m *Manager) PrepareAdmissionWebhooks(ctx context.Context, webhookResources
 	if err != nil {
 		return nil, err
 	}
+	if isIssuedByCertManager(caCertSecret) {
+		if err := m.deleteCertManagerResources(ctx); err != nil {
+			return nil, err
+		}
+		return m.regenerateCertificates(ctx, webhookResources)
+	}
 	if caCertSecret == nil {
 		logger.Info("CA cert secret does not exist")
 		return m.regenerateCertificates(ctx, webhookResources)
@@ -158,7 +188,7 @@ func (m *Manager) PrepareAdmissionWebhooks(ctx context.Context, webhookResources
 		return m.regenerateCertificates(ctx, webhookResources)
 	}
 
-	caBundle := caCertSecret.Data[CaCertSecretCertField]
+	caBundle := trustedCaBundle(caCertSecret.Data)
 
 	logger.Info("checking webhook certificate")
 	webhookCertSecret, err := m.secretsManager.GetWebhookServerCertSecret(ctx)
@@ -191,7 +221,7 @@ func (m *Manager) regenerateCertificates(ctx context.Context, webhookResources [
 		return nil, fmt.Errorf("while generating CA self signed cert: %w", err)
 	}
 
-	caSecret, err := m.buildCertificateSecret(CaCertSecretName, caCertificate, caPrivateKey)
+	caSecret, err := buildCertificateSecret(CaCertSecretName, caCertificate, caPrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("while building secret with regenerated CA self signed cert: %w", err)
 	}
@@ -201,7 +231,7 @@ func (m *Manager) regenerateCertificates(ctx context.Context, webhookResources [
 		return nil, fmt.Errorf("while generating webhook signed cert: %w", err)
 	}
 
-	webhookSecret, err := m.buildCertificateSecret(WebhookCertSecretName, webhookCertificate, webhookPrivateKey)
+	webhookSecret, err := buildCertificateSecret(WebhookCertSecretName, webhookCertificate, webhookPrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("while building regenerated webhook signed cert secret: %w", err)
 	}
@@ -225,12 +255,12 @@ func (m *Manager) regenerateWebhookCertificate(ctx context.Context, webhookResou
 		return nil, fmt.Errorf("while regenerating webhook signed cert: %w", err)
 	}
 
-	webhookSecret, err := m.buildCertificateSecret(WebhookCertSecretName, webhookCertificate, webhookPrivateKey)
+	webhookSecret, err := buildCertificateSecret(WebhookCertSecretName, webhookCertificate, webhookPrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("while building regenerated webhook signed cert secret: %w", err)
 	}
 
-	preparedWebhooks, err := m.prepareWebhooksManifests(ctx, webhookResources, caCertSecretData[CaCertSecretCertField])
+	preparedWebhooks, err := m.prepareWebhooksManifests(ctx, webhookResources, trustedCaBundle(caCertSecretData))
 	if err != nil {
 		return nil, fmt.Errorf("while preparing webhooks manifests: %w", err)
 	}
@@ -243,7 +273,7 @@ func (m *Manager) regenerateWebhookCertificate(ctx context.Context, webhookResou
 func (m *Manager) generateSelfSignedCert(ctx context.Context) ([]byte, []byte, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("generating self signed cert")
-	caCertificate, caPrivateKey, err := certs.GenerateSelfSignedCertificate(time.Now().UTC().Add(config.CaCertificateExpiration))
+	caCertificate, caPrivateKey, err := certs.GenerateSelfSignedCertificate(time.Now().UTC().Add(config.Current().CaCertificateExpiration))
 	if err != nil {
 		return nil, nil, fmt.Errorf("while generating self signed cert: %w", err)
 	}
@@ -253,14 +283,21 @@ func (m *Manager) generateSelfSignedCert(ctx context.Context) ([]byte, []byte, e
 func (m *Manager) generateSignedCert(ctx context.Context, caCert, caPrivateKey []byte) ([]byte, []byte, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("generating webhook signed cert")
-	webhookCertificate, webhookPrivateKey, err := certs.GenerateSignedCertificate(time.Now().UTC().Add(config.WebhookCertificateExpiration), caCert, caPrivateKey)
+	expiration := time.Now().UTC().Add(config.Current().WebhookCertificateExpiration)
+	// The webhook certificate must not outlive the CA, which matters for an external CA.
+	if block, err := certs.DecodeCertificate(caCert); err == nil {
+		if ca, err := x509.ParseCertificate(block.Bytes); err == nil && ca.NotAfter.Before(expiration) {
+			expiration = ca.NotAfter
+		}
+	}
+	webhookCertificate, webhookPrivateKey, err := certs.GenerateSignedCertificate(expiration, caCert, caPrivateKey)
 	if err != nil {
 		return nil, nil, fmt.Errorf("while generating webhook signed cert: %w", err)
 	}
 	return webhookCertificate, webhookPrivateKey, nil
 }
 
-func (m *Manager) buildCertificateSecret(secretName string, certificate, privateKey []byte) (*unstructured.Unstructured, error) {
+func buildCertificateSecret(secretName string, certificate, privateKey []byte) (*unstructured.Unstructured, error) {
 	certFieldName, err := certFieldFromSecretBySecretName(secretName)
 	if err != nil {
 		return nil, err
@@ -274,7 +311,7 @@ func (m *Manager) buildCertificateSecret(secretName string, certificate, private
 		TypeMeta: metav1.TypeMeta{Kind: secretKind, APIVersion: "v1"},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      secretName,
-			Namespace: config.ChartNamespace,
+			Namespace: config.Current().ChartNamespace,
 			Labels:    map[string]string{managedByKey: operatorName},
 		},
 		Data: map[string][]byte{
@@ -358,7 +395,11 @@ func (m *Manager) validateCert(secret *corev1.Secret) error {
 	if err != nil {
 		return err
 	}
-	if _, err = getSecretDataValueByKey(privateKeyFieldName, secret.Data); err != nil {
+	encodedKey, err := getSecretDataValueByKey(privateKeyFieldName, secret.Data)
+	if err != nil {
+		return err
+	}
+	if err := certs.VerifyKeyPair(encodedCert, encodedKey); err != nil {
 		return err
 	}
 	block, err := certs.DecodeCertificate(encodedCert)
@@ -369,30 +410,56 @@ func (m *Manager) validateCert(secret *corev1.Secret) error {
 	if err != nil {
 		return err
 	}
-	if certs.CertificateExpires(cert, config.ExpirationBoundary) {
-		return fmt.Errorf("cert expires soon")
+	if certs.CertificateExpires(cert, config.Current().ExpirationBoundary) {
+		return errCertExpiresSoon
 	}
 	return nil
 }
 
+// validateWebhookCert also checks the key algorithm of the webhook certificate, which is reissued under the current CA
+// if the algorithm changed. The CA keeps its algorithm until the Rotator replaces it, because replacing the CA
+// in one step breaks the published caBundle until sap-btp-operator reloads the serving certificate.
 func (m *Manager) validateWebhookCert(webhookCertSecret *corev1.Secret, caCert []byte) error {
 	if err := m.validateCert(webhookCertSecret); err != nil {
 		return err
 	}
+	if err := validateKeyAlgorithm(webhookCertSecret.Data[WebhookCertSecretCertField]); err != nil {
+		return err
+	}
 	return verifyCASign(caCert, webhookCertSecret.Data[WebhookCertSecretCertField])
 }
 
-func verifyCASign(caCert, signedCert []byte) error {
-	ok, err := certs.VerifyIfLeafIsSignedByGivenCA(caCert, signedCert)
+func validateKeyAlgorithm(encodedCert []byte) error {
+	block, err := certs.DecodeCertificate(encodedCert)
 	if err != nil {
-		return NewCertificateSignError(err.Error())
+		return err
 	}
-	if !ok {
-		return NewCertificateSignError("certificate is not signed by the provided CA")
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	if algorithm := certs.CertificateKeyAlgorithm(cert); algorithm != certs.KeyAlgorithm() {
+		return fmt.Errorf("cert uses %s key while %s is configured", algorithm, certs.KeyAlgorithm())
 	}
 	return nil
 }
 
+// verifyCASign checks that the certificate is signed by one of the CAs in the bundle,
+// which holds both the old and the new CA during a rotation.
+func verifyCASign(caBundle, signedCert []byte) error {
+	signErr := NewCertificateSignError("certificate is not signed by the provided CA")
+	for _, caCert := range splitCertificates(caBundle) {
+		ok, err := certs.VerifyIfLeafIsSignedByGivenCA(caCert, signedCert)
+		if err == nil && ok {
+			return nil
+		}
+		if err != nil {
+			signErr = NewCertificateSignError(err.Error())
+		}
+	}
+	return signErr
+}
+
 func getSecretDataValueByKey(key string, data map[string][]byte) ([]byte, error) {
 	value, ok := data[key]
 	if !ok {
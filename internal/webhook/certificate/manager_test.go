@@ -2,8 +2,10 @@ package certificate_test
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 
+	"github.com/kyma-project/btp-manager/internal/certs"
 	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -21,7 +23,7 @@ var _ = Describe("Certificate Manager", func() {
 	BeforeEach(func() {
 		secretsMgr = &fakeSecretsManager{}
 		metrics = &fakeWebhookMetrics{}
-		mgr = certificate.NewManager(secretsMgr, metrics)
+		mgr = certificate.NewManager(secretsMgr, metrics, nil)
 		ctx = context.Background()
 	})
 
@@ -190,6 +192,51 @@ var _ = Describe("Certificate Manager", func() {
 			})
 		})
 
+		Context("when the certificate keys do not match the configured key algorithm", func() {
+			BeforeEach(func() {
+				Expect(certs.SetKeyAlgorithm(certs.EcdsaP256KeyAlgorithm)).To(Succeed())
+				secretsMgr.caCertSecret = caSecret(validCACert, validCAKey)
+				secretsMgr.webhookCertSecret = webhookSecret(validWebhookCert, validWebhookKey)
+			})
+
+			AfterEach(func() {
+				Expect(certs.SetKeyAlgorithm(certs.RsaKeyAlgorithm)).To(Succeed())
+			})
+
+			It("reissues only the webhook certificate with the configured algorithm under the current CA", func() {
+				result, err := mgr.PrepareAdmissionWebhooks(ctx, []*unstructured.Unstructured{
+					validatingWebhookConfig("test-validating"),
+				})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(secretNamesIn(result)).To(ConsistOf(certificate.WebhookCertSecretName))
+				Expect(caBundleIn(webhookResourceIn(result))).To(Equal(validCACert))
+				Expect(metrics.counter).To(Equal(1))
+
+				webhookCert := secretIn(result, certificate.WebhookCertSecretName).Data[certificate.WebhookCertSecretCertField]
+				block, err := certs.DecodeCertificate(webhookCert)
+				Expect(err).NotTo(HaveOccurred())
+				cert, err := x509.ParseCertificate(block.Bytes)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(certs.CertificateKeyAlgorithm(cert)).To(Equal(certs.EcdsaP256KeyAlgorithm))
+				Expect(certs.VerifyIfLeafIsSignedByGivenCA(validCACert, webhookCert)).To(BeTrue())
+			})
+		})
+
+		Context("when the CA cert private key does not belong to the certificate", func() {
+			BeforeEach(func() {
+				secretsMgr.caCertSecret = caSecret(validCACert, expiringCAKey)
+				secretsMgr.webhookCertSecret = webhookSecret(validWebhookCert, validWebhookKey)
+			})
+
+			It("returns both the CA and webhook cert secrets", func() {
+				result, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(secretNamesIn(result)).To(ConsistOf(certificate.CaCertSecretName, certificate.WebhookCertSecretName))
+			})
+		})
+
 		Context("error paths", func() {
 			It("returns an error when fetching the CA cert secret fails", func() {
 				secretsMgr.caCertSecretErr = errors.New("api server unavailable")
@@ -0,0 +1,390 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/certs"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// CaCertSecretNextCertField and CaCertSecretNextKeyField hold the CA that replaces the current one
+	// once the serving certificate is rolled.
+	CaCertSecretNextCertField = "ca-next.crt"
+	CaCertSecretNextKeyField  = "ca-next.key"
+	// CaCertSecretBundleField holds the old and new CA published in the webhooks while a rotation is in progress.
+	CaCertSecretBundleField = "ca-bundle.crt"
+
+	// CaRotationRolledAtAnnotation records when the serving certificate was signed by the new CA.
+	CaRotationRolledAtAnnotation = "operator.kyma-project.io/ca-rotation-rolled-at"
+
+	servedCertificateDialTimeout = 5 * time.Second
+)
+
+var caRotationCheckInterval = time.Minute
+
+// ServedCertificateFunc returns the DER encoded certificate served by the sap-btp-operator webhook server.
+type ServedCertificateFunc func(ctx context.Context) ([]byte, error)
+
+// Rotator is a controller-runtime Runnable that replaces the self-signed CA CaRotationAdvance before
+// it reaches ExpirationBoundary, or when its key doesn't match KeyAlgorithm, in stages, so that the webhooks trust the serving certificate at all times:
+//  1. the new CA is published next to the old one in the webhooks' caBundle,
+//  2. the serving certificate is signed by the new CA, which becomes the current one,
+//  3. the old CA is dropped from the caBundle once sap-btp-operator serves the new certificate.
+//
+// It is disabled when CaRotationAdvance is 0 or WebhookCertificateMode is cert-manager.
+type Rotator struct {
+	client            client.Client
+	webhookMetrics    WebhookMetrics
+	servedCertificate ServedCertificateFunc
+	now               func() time.Time
+}
+
+func NewRotator(k8sClient client.Client, webhookMetrics WebhookMetrics) *Rotator {
+	return &Rotator{
+		client:            k8sClient,
+		webhookMetrics:    webhookMetrics,
+		servedCertificate: dialServedCertificate,
+		now:               func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// WithServedCertificateFunc replaces the function used to check which certificate sap-btp-operator serves.
+func (r *Rotator) WithServedCertificateFunc(f ServedCertificateFunc) *Rotator {
+	r.servedCertificate = f
+	return r
+}
+
+// Start implements manager.Runnable.
+func (r *Rotator) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("ca-rotator")
+	logger.Info("CA rotator started", "interval", caRotationCheckInterval)
+
+	ticker := time.NewTicker(caRotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Rotate(log.IntoContext(ctx, logger)); err != nil {
+			logger.Error(err, "CA rotation failed")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rotate advances the CA rotation by at most one stage. The stage is derived from the fields of the CA cert Secret,
+// so that the rotation continues after a restart.
+func (r *Rotator) Rotate(ctx context.Context) error {
+	if config.Current().CaRotationAdvance == 0 || certManagerModeEnabled() || externalCaEnabled() {
+		return nil
+	}
+
+	caCertSecret, err := r.getSecret(ctx, CaCertSecretName)
+	if err != nil {
+		return err
+	}
+	if caCertSecret == nil || isIssuedByCertManager(caCertSecret) {
+		return nil
+	}
+
+	switch {
+	case len(caCertSecret.Data[CaCertSecretNextCertField]) > 0:
+		return r.rollServingCert(ctx, caCertSecret)
+	case len(caCertSecret.Data[CaCertSecretBundleField]) > 0:
+		return r.dropPreviousCa(ctx, caCertSecret)
+	default:
+		return r.publishNextCa(ctx, caCertSecret)
+	}
+}
+
+func (r *Rotator) publishNextCa(ctx context.Context, caCertSecret *corev1.Secret) error {
+	cfg := config.Current()
+	caCert, err := getSecretDataValueByKey(CaCertSecretCertField, caCertSecret.Data)
+	if err != nil {
+		return fmt.Errorf("CA secret %q: %w", CaCertSecretName, err)
+	}
+	block, err := certs.DecodeCertificate(caCert)
+	if err != nil {
+		return err
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	// Within ExpirationBoundary the reconciliation regenerates the certificates in one step.
+	if certs.CertificateExpires(cert, cfg.ExpirationBoundary) {
+		return nil
+	}
+	// A changed KeyAlgorithm replaces the CA in the same stages as an expiring one.
+	algorithmChanged := certs.CertificateKeyAlgorithm(cert) != certs.KeyAlgorithm()
+	if !algorithmChanged && !certs.CertificateExpires(cert, cfg.ExpirationBoundary-cfg.CaRotationAdvance) {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+	logger.Info("publishing the next CA next to the current one", "currentCaExpiration", cert.NotAfter, "keyAlgorithmChanged", algorithmChanged)
+
+	nextCaCert, nextCaKey, err := certs.GenerateSelfSignedCertificate(r.now().Add(cfg.CaCertificateExpiration))
+	if err != nil {
+		return fmt.Errorf("while generating the next CA: %w", err)
+	}
+	caBundle := joinCertificates(caCert, nextCaCert)
+
+	caCertSecret.Data[CaCertSecretNextCertField] = nextCaCert
+	caCertSecret.Data[CaCertSecretNextKeyField] = nextCaKey
+	caCertSecret.Data[CaCertSecretBundleField] = caBundle
+	if err := r.client.Update(ctx, caCertSecret, client.FieldOwner(operatorName)); err != nil {
+		return fmt.Errorf("while storing the next CA in secret %q: %w", CaCertSecretName, err)
+	}
+	return r.publishCaBundle(ctx, caBundle)
+}
+
+func (r *Rotator) rollServingCert(ctx context.Context, caCertSecret *corev1.Secret) error {
+	logger := log.FromContext(ctx)
+
+	// The webhooks must trust both CAs before the serving certificate changes.
+	if err := r.publishCaBundle(ctx, caCertSecret.Data[CaCertSecretBundleField]); err != nil {
+		return err
+	}
+
+	logger.Info("signing the webhook certificate with the next CA")
+	nextCaCert, nextCaKey := caCertSecret.Data[CaCertSecretNextCertField], caCertSecret.Data[CaCertSecretNextKeyField]
+	if err := r.resignWebhookCert(ctx, nextCaCert, nextCaKey); err != nil {
+		return err
+	}
+
+	caCertSecret.Data[CaCertSecretCertField] = nextCaCert
+	caCertSecret.Data[CaCertSecretKeyField] = nextCaKey
+	delete(caCertSecret.Data, CaCertSecretNextCertField)
+	delete(caCertSecret.Data, CaCertSecretNextKeyField)
+	setAnnotation(caCertSecret, CaRotationRolledAtAnnotation, r.now().Format(time.RFC3339))
+	if err := r.client.Update(ctx, caCertSecret, client.FieldOwner(operatorName)); err != nil {
+		return fmt.Errorf("while promoting the next CA in secret %q: %w", CaCertSecretName, err)
+	}
+
+	r.webhookMetrics.IncrementCertsRegenerationCounter()
+	return nil
+}
+
+func (r *Rotator) dropPreviousCa(ctx context.Context, caCertSecret *corev1.Secret) error {
+	logger := log.FromContext(ctx)
+	cfg := config.Current()
+
+	webhookCertSecret, err := r.getSecret(ctx, WebhookCertSecretName)
+	if err != nil {
+		return err
+	}
+	caCert, caKey := caCertSecret.Data[CaCertSecretCertField], caCertSecret.Data[CaCertSecretKeyField]
+	if webhookCertSecret == nil || verifyCASign(caCert, webhookCertSecret.Data[WebhookCertSecretCertField]) != nil {
+		logger.Info("webhook certificate is not signed by the current CA, signing it again")
+		if err := r.resignWebhookCert(ctx, caCert, caKey); err != nil {
+			return err
+		}
+		setAnnotation(caCertSecret, CaRotationRolledAtAnnotation, r.now().Format(time.RFC3339))
+		return r.client.Update(ctx, caCertSecret, client.FieldOwner(operatorName))
+	}
+
+	if !r.operandReloaded(ctx, webhookCertSecret.Data[WebhookCertSecretCertField]) {
+		rolledAt, err := time.Parse(time.RFC3339, caCertSecret.Annotations[CaRotationRolledAtAnnotation])
+		if err == nil && r.now().Before(rolledAt.Add(cfg.CaRotationReloadTimeout)) {
+			logger.Info("waiting for sap-btp-operator to serve the webhook certificate signed by the new CA")
+			return nil
+		}
+		logger.Info("sap-btp-operator did not confirm serving the new webhook certificate, dropping the previous CA after timeout", "timeout", cfg.CaRotationReloadTimeout)
+	}
+
+	logger.Info("dropping the previous CA from the webhooks")
+	delete(caCertSecret.Data, CaCertSecretBundleField)
+	delete(caCertSecret.Annotations, CaRotationRolledAtAnnotation)
+	if err := r.client.Update(ctx, caCertSecret, client.FieldOwner(operatorName)); err != nil {
+		return fmt.Errorf("while dropping the previous CA from secret %q: %w", CaCertSecretName, err)
+	}
+	if err := r.publishCaBundle(ctx, caCert); err != nil {
+		return err
+	}
+	logger.Info("CA rotation completed")
+	return nil
+}
+
+func (r *Rotator) resignWebhookCert(ctx context.Context, caCert, caKey []byte) error {
+	webhookCert, webhookKey, err := certs.GenerateSignedCertificate(r.now().Add(config.Current().WebhookCertificateExpiration), caCert, caKey)
+	if err != nil {
+		return fmt.Errorf("while generating webhook signed cert: %w", err)
+	}
+
+	webhookCertSecret, err := r.getSecret(ctx, WebhookCertSecretName)
+	if err != nil {
+		return err
+	}
+	if webhookCertSecret == nil {
+		u, err := buildCertificateSecret(WebhookCertSecretName, webhookCert, webhookKey)
+		if err != nil {
+			return err
+		}
+		return r.client.Create(ctx, u, client.FieldOwner(operatorName))
+	}
+	if webhookCertSecret.Data == nil {
+		webhookCertSecret.Data = map[string][]byte{}
+	}
+	webhookCertSecret.Data[WebhookCertSecretCertField] = webhookCert
+	webhookCertSecret.Data[WebhookCertSecretKeyField] = webhookKey
+	if err := r.client.Update(ctx, webhookCertSecret, client.FieldOwner(operatorName)); err != nil {
+		return fmt.Errorf("while updating secret %q: %w", WebhookCertSecretName, err)
+	}
+	return nil
+}
+
+// publishCaBundle sets the caBundle of all webhooks managed by BTP Manager.
+func (r *Rotator) publishCaBundle(ctx context.Context, caBundle []byte) error {
+	managedBy := client.MatchingLabels{managedByKey: operatorName}
+
+	mutating := &admissionregistrationv1.MutatingWebhookConfigurationList{}
+	if err := r.client.List(ctx, mutating, managedBy); err != nil {
+		return fmt.Errorf("while listing %ss: %w", MutatingWebhookConfigurationKind, err)
+	}
+	for i := range mutating.Items {
+		webhookConfig := &mutating.Items[i]
+		changed := false
+		for j := range webhookConfig.Webhooks {
+			changed = setCaBundle(&webhookConfig.Webhooks[j].ClientConfig, caBundle) || changed
+		}
+		if err := r.updateIfChanged(ctx, webhookConfig, changed); err != nil {
+			return err
+		}
+	}
+
+	validating := &admissionregistrationv1.ValidatingWebhookConfigurationList{}
+	if err := r.client.List(ctx, validating, managedBy); err != nil {
+		return fmt.Errorf("while listing %ss: %w", ValidatingWebhookConfigurationKind, err)
+	}
+	for i := range validating.Items {
+		webhookConfig := &validating.Items[i]
+		changed := false
+		for j := range webhookConfig.Webhooks {
+			changed = setCaBundle(&webhookConfig.Webhooks[j].ClientConfig, caBundle) || changed
+		}
+		if err := r.updateIfChanged(ctx, webhookConfig, changed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Rotator) updateIfChanged(ctx context.Context, obj client.Object, changed bool) error {
+	if !changed {
+		return nil
+	}
+	if err := r.client.Update(ctx, obj, client.FieldOwner(operatorName)); err != nil {
+		return fmt.Errorf("while setting caBundle in %s: %w", obj.GetName(), err)
+	}
+	return nil
+}
+
+func (r *Rotator) operandReloaded(ctx context.Context, webhookCert []byte) bool {
+	block, err := certs.DecodeCertificate(webhookCert)
+	if err != nil {
+		return false
+	}
+	served, err := r.servedCertificate(ctx)
+	if err != nil {
+		log.FromContext(ctx).Info("cannot read the certificate served by sap-btp-operator", "error", err.Error())
+		return false
+	}
+	return bytes.Equal(served, block.Bytes)
+}
+
+func (r *Rotator) getSecret(ctx context.Context, name string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: name, Namespace: config.Current().ChartNamespace}, secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("while getting secret %q: %w", name, err)
+	}
+	return secret, nil
+}
+
+// dialServedCertificate connects to the webhook Service and returns the leaf certificate it serves.
+// The certificate is only compared with the Secret, so it is not verified.
+func dialServedCertificate(ctx context.Context) ([]byte, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: servedCertificateDialTimeout},
+		Config:    &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // the served certificate is compared with the Secret, not trusted
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(certs.WebhookDnsNames()[0], "443"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	peerCerts := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(peerCerts) == 0 {
+		return nil, fmt.Errorf("webhook server did not present a certificate")
+	}
+	return peerCerts[0].Raw, nil
+}
+
+// trustedCaBundle returns the CAs to publish in the webhooks' caBundle: both CAs while a rotation is in progress,
+// the current CA otherwise.
+func trustedCaBundle(caCertSecretData map[string][]byte) []byte {
+	if caBundle := caCertSecretData[CaCertSecretBundleField]; len(caBundle) > 0 {
+		return caBundle
+	}
+	return caCertSecretData[CaCertSecretCertField]
+}
+
+func setCaBundle(clientConfig *admissionregistrationv1.WebhookClientConfig, caBundle []byte) bool {
+	if bytes.Equal(clientConfig.CABundle, caBundle) {
+		return false
+	}
+	clientConfig.CABundle = caBundle
+	return true
+}
+
+func setAnnotation(obj client.Object, key, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
+
+func joinCertificates(pemCerts ...[]byte) []byte {
+	joined := new(bytes.Buffer)
+	for _, pemCert := range pemCerts {
+		joined.Write(bytes.TrimSpace(pemCert))
+		joined.WriteByte('\n')
+	}
+	return joined.Bytes()
+}
+
+// splitCertificates returns every certificate of a PEM bundle as a separate PEM block.
+func splitCertificates(caBundle []byte) [][]byte {
+	var split [][]byte
+	for rest := caBundle; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return split
+		}
+		if block.Type == "CERTIFICATE" {
+			split = append(split, pem.EncodeToMemory(block))
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package certificate_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/certs"
+	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("CA Rotator", func() {
+	var (
+		rotator           *certificate.Rotator
+		metrics           *fakeWebhookMetrics
+		k8sClient         client.Client
+		ctx               context.Context
+		oldCaCert         []byte
+		servedCert        []byte
+		servedCertErr     error
+		origChartNs       string
+		origAdvance       time.Duration
+		origReloadTimeout time.Duration
+	)
+
+	BeforeEach(func() {
+		origChartNs, origAdvance, origReloadTimeout = config.ChartNamespace, config.CaRotationAdvance, config.CaRotationReloadTimeout
+		config.ChartNamespace = kymaNamespace
+
+		var oldCaKey []byte
+		var err error
+		// The CA expires 12 hours after it enters the rotation window before ExpirationBoundary.
+		oldCaCert, oldCaKey, err = certs.GenerateSelfSignedCertificate(time.Now().UTC().Add(-config.ExpirationBoundary + config.CaRotationAdvance - 12*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		webhookCert, webhookKey, err := certs.GenerateSignedCertificate(time.Now().UTC().Add(config.WebhookCertificateExpiration), oldCaCert, oldCaKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		servedCert, servedCertErr = nil, errors.New("connection refused")
+		metrics = &fakeWebhookMetrics{}
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			caSecret(oldCaCert, oldCaKey),
+			webhookSecret(webhookCert, webhookKey),
+			managedMutatingWebhookConfig(oldCaCert),
+			managedValidatingWebhookConfig(oldCaCert),
+		).Build()
+		rotator = certificate.NewRotator(k8sClient, metrics).WithServedCertificateFunc(func(context.Context) ([]byte, error) {
+			return servedCert, servedCertErr
+		})
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		config.ChartNamespace, config.CaRotationAdvance, config.CaRotationReloadTimeout = origChartNs, origAdvance, origReloadTimeout
+	})
+
+	It("publishes both CAs before rolling the serving certificate", func() {
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+
+		ca := getSecret(ctx, k8sClient, certificate.CaCertSecretName)
+		Expect(ca.Data[caCertField]).To(Equal(oldCaCert))
+		Expect(ca.Data).To(HaveKey(certificate.CaCertSecretNextCertField))
+		Expect(ca.Data).To(HaveKey(certificate.CaCertSecretNextKeyField))
+		caBundle := ca.Data[certificate.CaCertSecretBundleField]
+		Expect(string(caBundle)).To(ContainSubstring(string(oldCaCert)))
+		Expect(string(caBundle)).To(ContainSubstring(string(ca.Data[certificate.CaCertSecretNextCertField])))
+		Expect(publishedCaBundles(ctx, k8sClient)).To(HaveEach(caBundle))
+
+		signed, err := certs.VerifyIfLeafIsSignedByGivenCA(oldCaCert, getSecret(ctx, k8sClient, certificate.WebhookCertSecretName).Data[webhookCertField])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signed).To(BeTrue())
+		Expect(metrics.counter).To(BeZero())
+	})
+
+	It("signs the serving certificate with the new CA while both CAs are trusted", func() {
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+		nextCaCert := getSecret(ctx, k8sClient, certificate.CaCertSecretName).Data[certificate.CaCertSecretNextCertField]
+
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+
+		ca := getSecret(ctx, k8sClient, certificate.CaCertSecretName)
+		Expect(ca.Data[caCertField]).To(Equal(nextCaCert))
+		Expect(ca.Data).NotTo(HaveKey(certificate.CaCertSecretNextCertField))
+		Expect(ca.Data).To(HaveKey(certificate.CaCertSecretBundleField))
+		Expect(ca.Annotations).To(HaveKey(certificate.CaRotationRolledAtAnnotation))
+		Expect(publishedCaBundles(ctx, k8sClient)).To(HaveEach(ca.Data[certificate.CaCertSecretBundleField]))
+
+		signed, err := certs.VerifyIfLeafIsSignedByGivenCA(nextCaCert, getSecret(ctx, k8sClient, certificate.WebhookCertSecretName).Data[webhookCertField])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signed).To(BeTrue())
+		Expect(metrics.counter).To(Equal(1))
+	})
+
+	It("keeps both CAs until sap-btp-operator serves the new certificate", func() {
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+		ca := getSecret(ctx, k8sClient, certificate.CaCertSecretName)
+		Expect(ca.Data).To(HaveKey(certificate.CaCertSecretBundleField))
+
+		block, err := certs.DecodeCertificate(getSecret(ctx, k8sClient, certificate.WebhookCertSecretName).Data[webhookCertField])
+		Expect(err).NotTo(HaveOccurred())
+		servedCert, servedCertErr = block.Bytes, nil
+
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+		ca = getSecret(ctx, k8sClient, certificate.CaCertSecretName)
+		Expect(ca.Data).NotTo(HaveKey(certificate.CaCertSecretBundleField))
+		Expect(ca.Annotations).NotTo(HaveKey(certificate.CaRotationRolledAtAnnotation))
+		Expect(publishedCaBundles(ctx, k8sClient)).To(HaveEach(ca.Data[caCertField]))
+	})
+
+	It("drops the previous CA after CaRotationReloadTimeout", func() {
+		config.CaRotationReloadTimeout = 0
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+
+		ca := getSecret(ctx, k8sClient, certificate.CaCertSecretName)
+		Expect(ca.Data).NotTo(HaveKey(certificate.CaCertSecretBundleField))
+		Expect(publishedCaBundles(ctx, k8sClient)).To(HaveEach(ca.Data[caCertField]))
+	})
+
+	It("keeps the certificates valid for the certificate manager in every stage", func() {
+		secretsMgr := &fakeSecretsManager{}
+		mgr := certificate.NewManager(secretsMgr, metrics, nil)
+
+		for stage := 0; stage < 3; stage++ {
+			Expect(rotator.Rotate(ctx)).To(Succeed())
+			secretsMgr.caCertSecret = getSecret(ctx, k8sClient, certificate.CaCertSecretName)
+			secretsMgr.webhookCertSecret = getSecret(ctx, k8sClient, certificate.WebhookCertSecretName)
+
+			result, err := mgr.PrepareAdmissionWebhooks(ctx, nil)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secretNamesIn(result)).To(BeEmpty())
+		}
+	})
+
+	It("does not rotate a CA that is not about to expire", func() {
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(caSecret(validCACert, validCAKey)).Build()
+		rotator = certificate.NewRotator(k8sClient, metrics)
+
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+
+		Expect(getSecret(ctx, k8sClient, certificate.CaCertSecretName).Data).NotTo(HaveKey(certificate.CaCertSecretNextCertField))
+	})
+
+	It("rotates a CA whose key doesn't match the configured algorithm", func() {
+		Expect(certs.SetKeyAlgorithm(certs.EcdsaP256KeyAlgorithm)).To(Succeed())
+		defer func() { Expect(certs.SetKeyAlgorithm(certs.RsaKeyAlgorithm)).To(Succeed()) }()
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			caSecret(validCACert, validCAKey),
+			managedMutatingWebhookConfig(validCACert),
+			managedValidatingWebhookConfig(validCACert),
+		).Build()
+		rotator = certificate.NewRotator(k8sClient, metrics)
+
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+
+		ca := getSecret(ctx, k8sClient, certificate.CaCertSecretName)
+		Expect(ca.Data[caCertField]).To(Equal(validCACert))
+		block, err := certs.DecodeCertificate(ca.Data[certificate.CaCertSecretNextCertField])
+		Expect(err).NotTo(HaveOccurred())
+		nextCa, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs.CertificateKeyAlgorithm(nextCa)).To(Equal(certs.EcdsaP256KeyAlgorithm))
+		Expect(publishedCaBundles(ctx, k8sClient)).To(HaveEach(ca.Data[certificate.CaCertSecretBundleField]))
+	})
+
+	It("does nothing with an external CA", func() {
+		origSecret := config.ExternalCaSecret
+		defer func() { config.ExternalCaSecret = origSecret }()
+		config.ExternalCaSecret = "org-intermediate-ca"
+
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+
+		Expect(getSecret(ctx, k8sClient, certificate.CaCertSecretName).Data).NotTo(HaveKey(certificate.CaCertSecretNextCertField))
+	})
+
+	It("does nothing when CaRotationAdvance is 0", func() {
+		config.CaRotationAdvance = 0
+
+		Expect(rotator.Rotate(ctx)).To(Succeed())
+
+		Expect(getSecret(ctx, k8sClient, certificate.CaCertSecretName).Data).NotTo(HaveKey(certificate.CaCertSecretNextCertField))
+	})
+})
+
+func getSecret(ctx context.Context, k8sClient client.Client, name string) *corev1.Secret {
+	secret := &corev1.Secret{}
+	Expect(k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: kymaNamespace}, secret)).To(Succeed())
+	return secret
+}
+
+func publishedCaBundles(ctx context.Context, k8sClient client.Client) [][]byte {
+	var caBundles [][]byte
+	mutating := &admissionregistrationv1.MutatingWebhookConfigurationList{}
+	Expect(k8sClient.List(ctx, mutating)).To(Succeed())
+	for _, c := range mutating.Items {
+		for _, w := range c.Webhooks {
+			caBundles = append(caBundles, w.ClientConfig.CABundle)
+		}
+	}
+	validating := &admissionregistrationv1.ValidatingWebhookConfigurationList{}
+	Expect(k8sClient.List(ctx, validating)).To(Succeed())
+	for _, c := range validating.Items {
+		for _, w := range c.Webhooks {
+			caBundles = append(caBundles, w.ClientConfig.CABundle)
+		}
+	}
+	return caBundles
+}
+
+func managedMutatingWebhookConfig(caBundle []byte) *admissionregistrationv1.MutatingWebhookConfiguration {
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-mutating", Labels: map[string]string{"app.kubernetes.io/managed-by": "btp-manager"}},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{Name: "test-webhook", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: caBundle, Service: webhookServiceReference()}},
+		},
+	}
+}
+
+func managedValidatingWebhookConfig(caBundle []byte) *admissionregistrationv1.ValidatingWebhookConfiguration {
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-validating", Labels: map[string]string{"app.kubernetes.io/managed-by": "btp-manager"}},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{Name: "test-webhook", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: caBundle, Service: webhookServiceReference()}},
+		},
+	}
+}
+
+func webhookServiceReference() *admissionregistrationv1.ServiceReference {
+	return &admissionregistrationv1.ServiceReference{Name: "sap-btp-operator-webhook-service", Namespace: kymaNamespace}
+}
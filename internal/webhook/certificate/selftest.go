@@ -0,0 +1,378 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// WebhookSelfTestObjectName is the name of the synthetic object sent to the webhooks in the dry-run AdmissionReview.
+	WebhookSelfTestObjectName = "btp-manager-webhook-self-test"
+
+	defaultWebhookServicePort    = 443
+	defaultWebhookTimeoutSeconds = 10
+)
+
+var webhookSelfTestCheckInterval = time.Second * 10
+
+// SelfTestMetrics is the metrics sink for the results of the webhook self-test.
+type SelfTestMetrics interface {
+	SetWebhookSelfTestResult(webhook string, success bool)
+}
+
+// AdmissionReviewFunc sends the AdmissionReview to the webhook endpoint, trusting only the CAs from caBundle,
+// and returns the body of the response.
+type AdmissionReviewFunc func(ctx context.Context, endpoint string, caBundle []byte, timeout time.Duration, review []byte) ([]byte, error)
+
+// SelfTester is a controller-runtime Runnable that sends a synthetic dry-run AdmissionReview to every managed webhook,
+// verifying TLS against the published caBundle, and reports the result in the WebhooksReachable condition
+// of the BtpOperator CR and in metrics. It tests the webhooks every WebhookSelfTestInterval and right after the
+// certificates or the caBundle change. After a change, failures are retried for WebhookSelfTestTimeout, so that
+// sap-btp-operator has time to reload the certificate, before the condition is set to False.
+// The state is kept in memory, so a restart starts with a new WebhookSelfTestTimeout window.
+type SelfTester struct {
+	client        client.Client
+	metrics       SelfTestMetrics
+	admissionCall AdmissionReviewFunc
+	now           func() time.Time
+
+	fingerprint string
+	verifyUntil time.Time
+	lastRun     time.Time
+	failing     bool
+}
+
+func NewSelfTester(k8sClient client.Client, metrics SelfTestMetrics) *SelfTester {
+	return &SelfTester{
+		client:        k8sClient,
+		metrics:       metrics,
+		admissionCall: postAdmissionReview,
+		now:           func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// WithAdmissionReviewFunc replaces the HTTPS call to the webhooks, which is useful in tests.
+func (t *SelfTester) WithAdmissionReviewFunc(f AdmissionReviewFunc) *SelfTester {
+	t.admissionCall = f
+	return t
+}
+
+// Start implements manager.Runnable.
+func (t *SelfTester) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("webhook-self-test")
+	logger.Info("webhook self-test started", "interval", webhookSelfTestCheckInterval)
+
+	ticker := time.NewTicker(webhookSelfTestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.Check(log.IntoContext(ctx, logger)); err != nil {
+			logger.Error(err, "webhook self-test failed")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+type webhookEndpoint struct {
+	name                    string
+	endpoint                string
+	caBundle                []byte
+	timeout                 time.Duration
+	admissionReviewVersions []string
+	rules                   []admissionregistrationv1.RuleWithOperations
+}
+
+// Check tests the webhooks if the certificates changed or WebhookSelfTestInterval elapsed since the last test.
+func (t *SelfTester) Check(ctx context.Context) error {
+	cfg := config.Current()
+	if cfg.WebhookSelfTestInterval == 0 {
+		return nil
+	}
+
+	endpoints, err := t.listEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	now := t.now()
+	if fingerprint := t.fingerprintOf(ctx, endpoints); fingerprint != t.fingerprint {
+		t.fingerprint = fingerprint
+		t.verifyUntil = now.Add(cfg.WebhookSelfTestTimeout)
+		t.lastRun = time.Time{}
+	}
+	reloading := now.Before(t.verifyUntil)
+	// Failing webhooks are tested on every check, so that the condition recovers quickly.
+	if !reloading && !t.failing && !t.lastRun.IsZero() && now.Sub(t.lastRun) < cfg.WebhookSelfTestInterval {
+		return nil
+	}
+	t.lastRun = now
+
+	failures := make([]string, 0)
+	for _, e := range endpoints {
+		err := t.test(ctx, e)
+		t.metrics.SetWebhookSelfTestResult(e.name, err == nil)
+		if err != nil {
+			log.FromContext(ctx).Info("webhook self-test failed", "webhook", e.name, "error", err.Error())
+			failures = append(failures, fmt.Sprintf("%s: %s", e.name, err))
+		}
+	}
+	if len(failures) > 0 && reloading {
+		return nil
+	}
+	t.verifyUntil = time.Time{}
+	t.failing = len(failures) > 0
+
+	condition := metav1.Condition{
+		Type:    conditions.WebhooksReachableType,
+		Status:  metav1.ConditionTrue,
+		Reason:  string(conditions.WebhooksReachable),
+		Message: "Webhooks answered the dry-run AdmissionReview",
+	}
+	if len(failures) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = string(conditions.WebhookUnreachable)
+		condition.Message = fmt.Sprintf("Webhooks failed the dry-run AdmissionReview: %s", strings.Join(failures, "; "))
+	}
+	return setBtpOperatorCondition(ctx, t.client, condition)
+}
+
+func (t *SelfTester) listEndpoints(ctx context.Context) ([]webhookEndpoint, error) {
+	managedBy := client.MatchingLabels{managedByKey: operatorName}
+	var endpoints []webhookEndpoint
+
+	mutating := &admissionregistrationv1.MutatingWebhookConfigurationList{}
+	if err := t.client.List(ctx, mutating, managedBy); err != nil {
+		return nil, fmt.Errorf("while listing %ss: %w", MutatingWebhookConfigurationKind, err)
+	}
+	for _, c := range mutating.Items {
+		for _, w := range c.Webhooks {
+			endpoints = append(endpoints, newWebhookEndpoint(c.Name, w.Name, w.ClientConfig, w.TimeoutSeconds, w.AdmissionReviewVersions, w.Rules))
+		}
+	}
+
+	validating := &admissionregistrationv1.ValidatingWebhookConfigurationList{}
+	if err := t.client.List(ctx, validating, managedBy); err != nil {
+		return nil, fmt.Errorf("while listing %ss: %w", ValidatingWebhookConfigurationKind, err)
+	}
+	for _, c := range validating.Items {
+		for _, w := range c.Webhooks {
+			endpoints = append(endpoints, newWebhookEndpoint(c.Name, w.Name, w.ClientConfig, w.TimeoutSeconds, w.AdmissionReviewVersions, w.Rules))
+		}
+	}
+	return endpoints, nil
+}
+
+func newWebhookEndpoint(configName, webhookName string, clientConfig admissionregistrationv1.WebhookClientConfig, timeoutSeconds *int32,
+	admissionReviewVersions []string, rules []admissionregistrationv1.RuleWithOperations) webhookEndpoint {
+	e := webhookEndpoint{
+		name:                    configName + "/" + webhookName,
+		caBundle:                clientConfig.CABundle,
+		timeout:                 time.Second * defaultWebhookTimeoutSeconds,
+		admissionReviewVersions: admissionReviewVersions,
+		rules:                   rules,
+	}
+	if timeoutSeconds != nil && *timeoutSeconds > 0 {
+		e.timeout = time.Second * time.Duration(*timeoutSeconds)
+	}
+	switch {
+	case clientConfig.URL != nil:
+		e.endpoint = *clientConfig.URL
+	case clientConfig.Service != nil:
+		port := int32(defaultWebhookServicePort)
+		if clientConfig.Service.Port != nil {
+			port = *clientConfig.Service.Port
+		}
+		path := ""
+		if clientConfig.Service.Path != nil {
+			path = *clientConfig.Service.Path
+		}
+		host := fmt.Sprintf("%s.%s.svc", clientConfig.Service.Name, clientConfig.Service.Namespace)
+		e.endpoint = "https://" + net.JoinHostPort(host, strconv.Itoa(int(port))) + path
+	}
+	return e
+}
+
+// fingerprintOf identifies the published caBundles and the webhook certificate, so that a regeneration triggers a test.
+func (t *SelfTester) fingerprintOf(ctx context.Context, endpoints []webhookEndpoint) string {
+	h := sha256.New()
+	for _, e := range endpoints {
+		h.Write(e.caBundle)
+	}
+	if webhookCert, err := readCertificate(ctx, t.client, WebhookCertSecretName, WebhookCertSecretCertField); err == nil && webhookCert != nil {
+		h.Write(webhookCert.Raw)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *SelfTester) test(ctx context.Context, e webhookEndpoint) error {
+	if e.endpoint == "" {
+		return fmt.Errorf("webhook has neither a URL nor a service")
+	}
+	if len(e.caBundle) == 0 {
+		return fmt.Errorf("caBundle is empty")
+	}
+
+	request, err := t.admissionRequest(e)
+	if err != nil {
+		return err
+	}
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionReviewAPIVersion(e.admissionReviewVersions), Kind: "AdmissionReview"},
+		Request:  request,
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		return fmt.Errorf("while marshalling AdmissionReview: %w", err)
+	}
+
+	responseBody, err := t.admissionCall(ctx, e.endpoint, e.caBundle, e.timeout, body)
+	if err != nil {
+		return err
+	}
+	response := admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		return fmt.Errorf("while unmarshalling AdmissionReview response: %w", err)
+	}
+	if response.Response == nil || response.Response.UID != request.UID {
+		return fmt.Errorf("AdmissionReview response does not match the request")
+	}
+	return nil
+}
+
+// admissionRequest builds a dry-run CREATE request for a synthetic object of the first resource handled by the webhook.
+func (t *SelfTester) admissionRequest(e webhookEndpoint) (*admissionv1.AdmissionRequest, error) {
+	cfg := config.Current()
+	gvr := schema.GroupVersionResource{}
+	operation := admissionv1.Create
+	if len(e.rules) > 0 {
+		rule := e.rules[0]
+		gvr = schema.GroupVersionResource{Group: firstOf(rule.APIGroups), Version: firstOf(rule.APIVersions), Resource: firstOf(rule.Resources)}
+		if len(rule.Operations) > 0 && !containsOperation(rule.Operations, admissionregistrationv1.Create) && !containsOperation(rule.Operations, admissionregistrationv1.OperationAll) {
+			operation = admissionv1.Operation(rule.Operations[0])
+		}
+	}
+	gvk := gvr.GroupVersion().WithKind("")
+	if kind, err := t.client.RESTMapper().KindFor(gvr); err == nil {
+		gvk = kind
+	}
+
+	object, err := json.Marshal(map[string]interface{}{
+		"apiVersion": gvk.GroupVersion().String(),
+		"kind":       gvk.Kind,
+		"metadata":   map[string]interface{}{"name": WebhookSelfTestObjectName, "namespace": cfg.ChartNamespace},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while marshalling the synthetic object: %w", err)
+	}
+
+	dryRun := true
+	request := &admissionv1.AdmissionRequest{
+		UID:       types.UID(uuid.NewUUID()),
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Resource:  metav1.GroupVersionResource{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource},
+		Name:      WebhookSelfTestObjectName,
+		Namespace: cfg.ChartNamespace,
+		Operation: operation,
+		UserInfo:  authenticationv1.UserInfo{Username: "system:serviceaccount:" + config.KymaSystemNamespaceName + ":" + WebhookSelfTestObjectName},
+		DryRun:    &dryRun,
+	}
+	if operation != admissionv1.Delete {
+		request.Object = runtime.RawExtension{Raw: object}
+	}
+	if operation == admissionv1.Update || operation == admissionv1.Delete {
+		request.OldObject = runtime.RawExtension{Raw: object}
+	}
+	return request, nil
+}
+
+func admissionReviewAPIVersion(versions []string) string {
+	for _, v := range versions {
+		if v == "v1" {
+			return admissionv1.SchemeGroupVersion.String()
+		}
+	}
+	if len(versions) > 0 {
+		return "admission.k8s.io/" + versions[0]
+	}
+	return admissionv1.SchemeGroupVersion.String()
+}
+
+func firstOf(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func containsOperation(operations []admissionregistrationv1.OperationType, operation admissionregistrationv1.OperationType) bool {
+	for _, o := range operations {
+		if o == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// postAdmissionReview calls the webhook the same way the API server does: over TLS verified with the caBundle, without a proxy.
+func postAdmissionReview(ctx context.Context, endpoint string, caBundle []byte, timeout time.Duration, review []byte) ([]byte, error) {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("caBundle does not contain any PEM certificate")
+	}
+	httpClient := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(review))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return body, nil
+}
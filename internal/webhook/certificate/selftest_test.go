@@ -0,0 +1,213 @@
+package certificate_test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Webhook Self-Test", func() {
+	var (
+		tester       *certificate.SelfTester
+		metrics      *fakeSelfTestMetrics
+		k8sClient    client.Client
+		ctx          context.Context
+		requests     []admissionv1.AdmissionReview
+		callErr      error
+		origInterval time.Duration
+		origTimeout  time.Duration
+	)
+
+	echo := func(_ context.Context, _ string, _ []byte, _ time.Duration, body []byte) ([]byte, error) {
+		review := admissionv1.AdmissionReview{}
+		Expect(json.Unmarshal(body, &review)).To(Succeed())
+		requests = append(requests, review)
+		if callErr != nil {
+			return nil, callErr
+		}
+		review.Response = &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+		review.Request = nil
+		return json.Marshal(review)
+	}
+
+	newClient := func(objs ...client.Object) client.Client {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: config.BtpOperatorCrName, Namespace: kymaNamespace}}
+		return fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objs, cr)...).WithStatusSubresource(cr).Build()
+	}
+
+	webhooksReachable := func() *metav1.Condition {
+		cr := &v1alpha1.BtpOperator{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: config.BtpOperatorCrName, Namespace: kymaNamespace}, cr)).To(Succeed())
+		for _, c := range cr.Status.Conditions {
+			if c.Type == conditions.WebhooksReachableType {
+				return c
+			}
+		}
+		return nil
+	}
+
+	BeforeEach(func() {
+		origInterval, origTimeout = config.WebhookSelfTestInterval, config.WebhookSelfTestTimeout
+		requests, callErr = nil, nil
+		metrics = &fakeSelfTestMetrics{results: map[string]bool{}}
+		ctx = context.Background()
+		k8sClient = newClient(managedMutatingWebhookConfig(validCACert), managedValidatingWebhookConfig(validCACert))
+		tester = certificate.NewSelfTester(k8sClient, metrics).WithAdmissionReviewFunc(echo)
+	})
+
+	AfterEach(func() {
+		config.WebhookSelfTestInterval, config.WebhookSelfTestTimeout = origInterval, origTimeout
+	})
+
+	It("reports reachable webhooks", func() {
+		Expect(tester.Check(ctx)).To(Succeed())
+
+		Expect(requests).To(HaveLen(2))
+		for _, review := range requests {
+			Expect(*review.Request.DryRun).To(BeTrue())
+			Expect(review.Request.Operation).To(Equal(admissionv1.Create))
+			Expect(review.Request.Name).To(Equal(certificate.WebhookSelfTestObjectName))
+		}
+		Expect(metrics.results).To(Equal(map[string]bool{"test-mutating/test-webhook": true, "test-validating/test-webhook": true}))
+		Expect(webhooksReachable().Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("reports unreachable webhooks after WebhookSelfTestTimeout", func() {
+		config.WebhookSelfTestTimeout = 0
+		callErr = errors.New("x509: certificate signed by unknown authority")
+
+		Expect(tester.Check(ctx)).To(Succeed())
+
+		condition := webhooksReachable()
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(conditions.WebhookUnreachable)))
+		Expect(condition.Message).To(ContainSubstring("test-mutating/test-webhook: x509: certificate signed by unknown authority"))
+		Expect(metrics.results).To(HaveEach(BeFalse()))
+	})
+
+	It("retries failing webhooks while sap-btp-operator reloads the certificate", func() {
+		callErr = errors.New("connection refused")
+
+		Expect(tester.Check(ctx)).To(Succeed())
+		Expect(webhooksReachable()).To(BeNil())
+		Expect(metrics.results).To(HaveEach(BeFalse()))
+
+		callErr = nil
+		Expect(tester.Check(ctx)).To(Succeed())
+		Expect(webhooksReachable().Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("tests the webhooks again only after WebhookSelfTestInterval", func() {
+		Expect(tester.Check(ctx)).To(Succeed())
+		Expect(tester.Check(ctx)).To(Succeed())
+
+		Expect(requests).To(HaveLen(2))
+	})
+
+	It("tests the webhooks at once when the caBundle changes", func() {
+		Expect(tester.Check(ctx)).To(Succeed())
+		mutating := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "test-mutating"}, mutating)).To(Succeed())
+		mutating.Webhooks[0].ClientConfig.CABundle = expiringCACert
+		Expect(k8sClient.Update(ctx, mutating)).To(Succeed())
+
+		Expect(tester.Check(ctx)).To(Succeed())
+
+		Expect(requests).To(HaveLen(4))
+	})
+
+	It("rejects a response that does not match the request", func() {
+		config.WebhookSelfTestTimeout = 0
+		tester.WithAdmissionReviewFunc(func(context.Context, string, []byte, time.Duration, []byte) ([]byte, error) {
+			return []byte(`{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview","response":{"uid":"other","allowed":true}}`), nil
+		})
+
+		Expect(tester.Check(ctx)).To(Succeed())
+
+		Expect(webhooksReachable().Message).To(ContainSubstring("does not match the request"))
+	})
+
+	It("does nothing when WebhookSelfTestInterval is 0", func() {
+		config.WebhookSelfTestInterval = 0
+
+		Expect(tester.Check(ctx)).To(Succeed())
+
+		Expect(requests).To(BeEmpty())
+		Expect(webhooksReachable()).To(BeNil())
+	})
+
+	Context("over TLS", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			config.WebhookSelfTestTimeout = 0
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				review := admissionv1.AdmissionReview{}
+				Expect(json.NewDecoder(r.Body).Decode(&review)).To(Succeed())
+				review.Response = &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+				Expect(json.NewEncoder(w).Encode(review)).To(Succeed())
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		urlWebhookConfig := func(caBundle []byte) *admissionregistrationv1.ValidatingWebhookConfiguration {
+			webhookConfig := managedValidatingWebhookConfig(caBundle)
+			webhookConfig.Webhooks[0].ClientConfig.Service = nil
+			webhookConfig.Webhooks[0].ClientConfig.URL = &server.URL
+			return webhookConfig
+		}
+
+		It("trusts the webhook certificate signed by the caBundle", func() {
+			serverCaBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+			k8sClient = newClient(urlWebhookConfig(serverCaBundle))
+			tester = certificate.NewSelfTester(k8sClient, metrics)
+
+			Expect(tester.Check(ctx)).To(Succeed())
+
+			Expect(webhooksReachable().Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("does not trust the webhook certificate signed by another CA", func() {
+			k8sClient = newClient(urlWebhookConfig(validCACert))
+			tester = certificate.NewSelfTester(k8sClient, metrics)
+
+			Expect(tester.Check(ctx)).To(Succeed())
+
+			Expect(webhooksReachable().Status).To(Equal(metav1.ConditionFalse))
+			Expect(webhooksReachable().Message).To(ContainSubstring("certificate"))
+		})
+	})
+})
+
+// fakeSelfTestMetrics is a minimal test double for certificate.SelfTestMetrics.
+type fakeSelfTestMetrics struct {
+	results map[string]bool
+}
+
+func (f *fakeSelfTestMetrics) SetWebhookSelfTestResult(webhook string, success bool) {
+	f.results[webhook] = success
+}
@@ -0,0 +1,40 @@
+package certificate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/conditions"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// setBtpOperatorCondition sets the condition in the BtpOperator CR status, next to the Ready condition set by the reconciler.
+// The CR is updated only when the condition changes. A conflict is resolved with the next check.
+func setBtpOperatorCondition(ctx context.Context, k8sClient client.Client, condition metav1.Condition) error {
+	cr := &v1alpha1.BtpOperator{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: config.BtpOperatorCrName, Namespace: config.KymaSystemNamespaceName}, cr); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("while getting BtpOperator CR: %w", err)
+	}
+	for _, existing := range cr.Status.Conditions {
+		if existing != nil && existing.Type == condition.Type && existing.Status == condition.Status &&
+			existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return nil
+		}
+	}
+
+	log.FromContext(ctx).Info("setting condition", "type", condition.Type, "status", condition.Status, "reason", condition.Reason, "message", condition.Message)
+	conditions.SetStatusCondition(&cr.Status.Conditions, condition)
+	if err := k8sClient.Status().Update(ctx, cr); err != nil {
+		return fmt.Errorf("while setting %s condition: %w", condition.Type, err)
+	}
+	return nil
+}
@@ -43,16 +43,20 @@ import (
 	"github.com/kyma-project/btp-manager/api/v1alpha1"
 	"github.com/kyma-project/btp-manager/controllers"
 	"github.com/kyma-project/btp-manager/controllers/config"
+	"github.com/kyma-project/btp-manager/internal/certs"
 	"github.com/kyma-project/btp-manager/internal/configurator"
 	"github.com/kyma-project/btp-manager/internal/credentials/drift"
 	"github.com/kyma-project/btp-manager/internal/deprovisioning"
+	"github.com/kyma-project/btp-manager/internal/k8s/authz"
 	"github.com/kyma-project/btp-manager/internal/k8s/generic"
 	"github.com/kyma-project/btp-manager/internal/k8s/networkpolicy"
 	"github.com/kyma-project/btp-manager/internal/k8s/secrets"
 	"github.com/kyma-project/btp-manager/internal/manager/moduleresource"
 	"github.com/kyma-project/btp-manager/internal/manifest"
 	btpmanagermetrics "github.com/kyma-project/btp-manager/internal/metrics"
+	"github.com/kyma-project/btp-manager/internal/operandexport"
 	"github.com/kyma-project/btp-manager/internal/provisioning"
+	"github.com/kyma-project/btp-manager/internal/trustbundle"
 	"github.com/kyma-project/btp-manager/internal/webhook/certificate"
 	//+kubebuilder:scaffold:imports
 )
@@ -91,12 +95,36 @@ func main() {
 	flag.DurationVar(&config.ReadyCheckInterval, "ready-check-interval", config.ReadyCheckInterval, "Ready check retry interval.")
 	flag.DurationVar(&config.HardDeleteCheckInterval, "hard-delete-check-interval", config.HardDeleteCheckInterval, "Hard delete retry interval.")
 	flag.DurationVar(&config.HardDeleteTimeout, "hard-delete-timeout", config.HardDeleteTimeout, "Hard delete timeout.")
+	flag.IntVar(&config.HardDeleteConcurrency, "hard-delete-concurrency", config.HardDeleteConcurrency, "Number of namespaces hard deleted in parallel.")
+	flag.IntVar(&config.HardDeleteQPS, "hard-delete-qps", config.HardDeleteQPS, "Maximum number of delete requests per second during the hard delete. 0 disables the limit.")
 	flag.DurationVar(&config.DeleteRequestTimeout, "delete-request-timeout", config.DeleteRequestTimeout, "Delete request timeout in hard delete.")
 	flag.StringVar(&config.EnableLimitedCache, "enable-limited-cache", config.EnableLimitedCache, "Enable limited cache for sap-btp-operator.")
 	flag.DurationVar(&config.ProbeInterval, "probe-interval", config.ProbeInterval, "CA bundle probe interval. 0 disables the probe.")
+	flag.StringVar(&config.ProbeMode, "probe-mode", config.ProbeMode, "CA bundle probe mode: Job runs the probe in a Job, InProcess runs it in BTP Manager.")
+	flag.IntVar(&config.ProbeHistoryLength, "probe-history-length", config.ProbeHistoryLength, "Number of CA bundle probe results kept in the BtpOperator status.")
+	flag.IntVar(&config.ProbeAlertThreshold, "probe-alert-threshold", config.ProbeAlertThreshold, "Number of consecutive CA bundle probe alerts before the alert is reported.")
+	flag.IntVar(&config.ProbeErrorThreshold, "probe-error-threshold", config.ProbeErrorThreshold, "Number of consecutive CA bundle probe errors before the error is reported.")
+	flag.DurationVar(&config.ProbeRestartMinInterval, "probe-restart-min-interval", config.ProbeRestartMinInterval, "Minimum time between restarts of the SAP BTP service operator pods after the CA bundle changes.")
+	flag.DurationVar(&config.ProbeCAExpiryWarning, "probe-ca-expiry-warning", config.ProbeCAExpiryWarning, "How long before their expiry the CA bundle probe reports certificates in the mounted CA bundle as expiring.")
 	flag.DurationVar(&config.StatusUpdateTimeout, "status-update-timeout", config.StatusUpdateTimeout, "Status update timeout.")
 	flag.DurationVar(&config.StatusUpdateCheckInterval, "status-update-check-interval", config.StatusUpdateCheckInterval, "Status update retry interval.")
 	flag.StringVar(&config.ManagerResourcesPath, "manager-resources-path", config.ManagerResourcesPath, "Path to the directory with BTP Manager resources.")
+	flag.StringVar(&config.HttpProxy, "http-proxy", config.HttpProxy, "HTTP_PROXY value passed to sap-btp-operator and the CA bundle probe.")
+	flag.StringVar(&config.HttpsProxy, "https-proxy", config.HttpsProxy, "HTTPS_PROXY value passed to sap-btp-operator and the CA bundle probe.")
+	flag.StringVar(&config.NoProxy, "no-proxy", config.NoProxy, "NO_PROXY value passed to sap-btp-operator and the CA bundle probe.")
+	flag.StringVar(&config.TrustBundleConfigMap, "trust-bundle-configmap", config.TrustBundleConfigMap, "Name of the ConfigMap with a custom CA trust bundle mounted into sap-btp-operator and the CA bundle probe.")
+	flag.StringVar(&config.TrustBundleSecret, "trust-bundle-secret", config.TrustBundleSecret, "Name of the Secret with a custom CA trust bundle mounted into sap-btp-operator and the CA bundle probe.")
+	flag.StringVar(&config.WebhookCertificateMode, "webhook-certificate-mode", config.WebhookCertificateMode, `Source of the admission webhook certificates: "self-signed" or "cert-manager".`)
+	flag.StringVar(&config.TrustBundleKey, "trust-bundle-key", config.TrustBundleKey, "Key of the custom CA trust bundle in the ConfigMap or Secret.")
+	flag.DurationVar(&config.CaRotationAdvance, "ca-rotation-advance", config.CaRotationAdvance, "How long before ExpirationBoundary the staged CA rotation starts. 0 disables the staged rotation.")
+	flag.DurationVar(&config.CaRotationReloadTimeout, "ca-rotation-reload-timeout", config.CaRotationReloadTimeout, "How long the staged CA rotation waits for sap-btp-operator to serve the new webhook certificate before it drops the previous CA.")
+	flag.StringVar(&config.ExternalCaSecret, "external-ca-secret", config.ExternalCaSecret, "Name of the Secret with an external CA that signs the webhook certificate instead of a self-signed CA.")
+	flag.DurationVar(&config.WebhookSelfTestInterval, "webhook-self-test-interval", config.WebhookSelfTestInterval, "How often a dry-run AdmissionReview is sent to the sap-btp-operator webhooks. 0 disables the self-test.")
+	flag.DurationVar(&config.WebhookSelfTestTimeout, "webhook-self-test-timeout", config.WebhookSelfTestTimeout, "How long the webhook self-test retries after the certificates change before it reports the webhooks as unreachable.")
+	flag.BoolVar(&config.RestoreServiceInstancesAndBindings, "restore-service-instances-and-bindings", config.RestoreServiceInstancesAndBindings, "Re-create the service instances and bindings exported during the previous deprovisioning once the module is ready.")
+	flag.BoolVar(&config.ForceDeleteConfirmationRequired, "force-delete-confirmation-required", config.ForceDeleteConfirmationRequired, "Require the force-delete-confirmation annotation before force deleting service instances and bindings.")
+	flag.DurationVar(&config.CertificateRegenerationGracePeriod, "certificate-regeneration-grace-period", config.CertificateRegenerationGracePeriod, "How long the CA or webhook certificate may stay within the expiration boundary before the CertificatesValid condition reports that its regeneration keeps failing.")
+	flag.Func("key-algorithm", `Algorithm of the admission webhook certificate keys: "rsa", "ecdsa-p256", "ecdsa-p384" or "ed25519" (default "rsa").`, certs.SetKeyAlgorithm)
 	opts := zap.Options{
 		Development: false,
 	}
@@ -111,6 +139,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := config.ValidateProxyFlags(); err != nil {
+		setupLog.Error(err, "invalid proxy settings")
+		os.Exit(1)
+	}
+
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
 	restCfg := ctrl.GetConfigOrDie()
@@ -133,18 +166,31 @@ func main() {
 		os.Exit(1)
 	}
 
+	trustBundleCache, err := trustbundle.NewWatchCache(restCfg, scheme, mgr.GetRESTMapper(), config.Current().ChartNamespace)
+	if err != nil {
+		setupLog.Error(err, "unable to create trust bundle cache")
+		os.Exit(1)
+	}
+	if err := mgr.Add(trustBundleCache); err != nil {
+		setupLog.Error(err, "unable to register trust bundle cache as runnable")
+		os.Exit(1)
+	}
+
 	signalContext := ctrl.SetupSignalHandler()
 	webhookMetrics := btpmanagermetrics.NewWebhookMetrics(ctrlmetrics.Registry)
 	configMetrics := btpmanagermetrics.NewConfigMetrics(ctrlmetrics.Registry)
+	certificateMetrics := btpmanagermetrics.NewCertificateMetrics(ctrlmetrics.Registry)
+	deprovisioningMetrics := btpmanagermetrics.NewDeprovisioningMetrics(ctrlmetrics.Registry)
+	reconcileMetrics := btpmanagermetrics.NewReconcileMetrics(ctrlmetrics.Registry)
 	cleanupReconciler := controllers.NewInstanceBindingControllerManager(signalContext, mgr.GetClient(), mgr.GetScheme(), restCfg)
-	configHandler := config.NewHandler(mgr.GetClient(), scheme, configMetrics)
+	configHandler := config.NewHandler(mgr.GetClient(), scheme, configMetrics).WithEventRecorder(mgr.GetEventRecorder("btp-manager")).WithFlags(flag.CommandLine)
 	manifestHandler := &manifest.Handler{Scheme: scheme}
 	networkPolicyManager := networkpolicy.NewManager(mgr.GetClient(), manifestHandler)
 	driftDetector := drift.NewDetector(mgr.GetClient(), apiServerClient)
 	moduleResourceManager := moduleresource.NewManager(mgr.GetClient(), scheme, driftDetector)
 	secretsManager := secrets.NewManager(generic.NewObjectManager[*corev1.Secret, *corev1.SecretList](mgr.GetClient()))
-	certManager := certificate.NewManager(secretsManager, webhookMetrics)
-	provisioningHandler := provisioning.NewHandler(mgr.GetClient(), driftDetector, moduleResourceManager, networkPolicyManager, certManager, cleanupReconciler)
+	certManager := certificate.NewManager(secretsManager, webhookMetrics, mgr.GetClient()).WithAPIReader(apiServerClient)
+	provisioningHandler := provisioning.NewHandler(mgr.GetClient(), driftDetector, moduleResourceManager, networkPolicyManager, certManager, trustbundle.NewManager(apiServerClient), cleanupReconciler, reconcileMetrics)
 	sapBtpConfigurator := configurator.NewConfigurator(driftDetector)
 	reconciler := controllers.NewBtpOperatorReconciler(
 		mgr.GetClient(),
@@ -152,15 +198,16 @@ func main() {
 		scheme,
 		cleanupReconciler,
 		webhookMetrics,
-		[]config.WatchHandler{
-			configHandler,
-		},
+		append([]config.WatchHandler{configHandler, configHandler.ConfigurationWatchHandler()}, trustbundle.WatchHandlers(trustBundleCache)...),
 		networkPolicyManager,
 		certManager,
 		provisioningHandler,
 		sapBtpConfigurator,
 	)
-	reconciler.SetDeprovisioningHandler(deprovisioning.NewHandler(mgr.GetClient(), apiServerClient, reconciler, reconciler, cleanupReconciler, driftDetector, moduleResourceManager, networkPolicyManager))
+	reconciler.SetReconcileMetrics(reconcileMetrics)
+	operandExporter := operandexport.NewExporter(mgr.GetClient(), apiServerClient)
+	reconciler.SetDeprovisioningHandler(deprovisioning.NewHandler(mgr.GetClient(), apiServerClient, reconciler, reconciler, cleanupReconciler, driftDetector, moduleResourceManager, networkPolicyManager, operandExporter, deprovisioningMetrics))
+	reconciler.SetOperandRestorer(operandExporter)
 
 	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "BtpOperator")
@@ -172,18 +219,41 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Apply ConfigMap config synchronously before starting the manager so that
+	if err := mgr.AddMetricsServerExtraHandler(config.DebugConfigPath, authz.WithAuthorization(apiServerClient, configHandler)); err != nil {
+		setupLog.Error(err, "unable to register effective configuration endpoint")
+		os.Exit(1)
+	}
+
+	// Apply the BtpManagerConfiguration or the ConfigMap synchronously before starting the manager so that
 	// runnables like ProbeRunner read the correct config values at startup.
 	if applyErr := configHandler.ApplyFromAPI(context.Background(), mgr.GetAPIReader()); applyErr != nil {
-		setupLog.Info("config ConfigMap not applied at startup (will be applied on first reconcile)", "reason", applyErr)
+		setupLog.Info("configuration not applied at startup (will be applied on first reconcile)", "reason", applyErr)
 	}
 
-	probeRunner := controllers.NewProbeRunner(mgr.GetClient(), ctrlmetrics.Registry)
+	probeRunner := controllers.NewProbeRunner(mgr.GetClient(), ctrlmetrics.Registry).WithAPIReader(apiServerClient)
 	if err := mgr.Add(probeRunner); err != nil {
 		setupLog.Error(err, "unable to register probe runner as runnable")
 		os.Exit(1)
 	}
 
+	caRotator := certificate.NewRotator(mgr.GetClient(), webhookMetrics)
+	if err := mgr.Add(caRotator); err != nil {
+		setupLog.Error(err, "unable to register CA rotator as runnable")
+		os.Exit(1)
+	}
+
+	expiryMonitor := certificate.NewExpiryMonitor(mgr.GetClient(), certificateMetrics).WithAPIReader(apiServerClient)
+	if err := mgr.Add(expiryMonitor); err != nil {
+		setupLog.Error(err, "unable to register certificate expiry monitor as runnable")
+		os.Exit(1)
+	}
+
+	webhookSelfTester := certificate.NewSelfTester(mgr.GetClient(), webhookMetrics)
+	if err := mgr.Add(webhookSelfTester); err != nil {
+		setupLog.Error(err, "unable to register webhook self-test as runnable")
+		os.Exit(1)
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
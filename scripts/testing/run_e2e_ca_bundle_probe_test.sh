@@ -29,24 +29,24 @@ FORCE_REGEN=${FORCE_REGEN:-"false"}
 
 # ─── helpers ─────────────────────────────────────────────────────────────────
 
-printAnnotations() {
-  echo "--- BtpOperator CR annotations:"
+printProbeStatus() {
+  echo "--- BtpOperator CR probe status:"
   kubectl get btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" \
-    -o jsonpath='{.metadata.annotations}' 2>/dev/null \
-    | tr ',' '\n' | tr -d '{}' | grep tls-probe | sed 's/^/      /'
+    -o jsonpath='{.status.probe}' 2>/dev/null \
+    | tr ',' '\n' | tr -d '{}' | sed 's/^/      /'
   echo ""
 }
 
-assertCRAnnotation() {
-  local key=$1 expected=$2
+assertProbeStatus() {
+  local field=$1 expected=$2
   local actual
   actual=$(kubectl get btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" \
-    -o jsonpath="{.metadata.annotations.$key}" 2>/dev/null || echo "")
+    -o jsonpath="{.status.probe.$field}" 2>/dev/null || echo "")
   if [[ "$actual" != "$expected" ]]; then
-    echo "--- FAIL: annotation $key: expected='$expected' got='$actual'"
+    echo "--- FAIL: probe status $field: expected='$expected' got='$actual'"
     return 1
   fi
-  echo "--- PASS: annotation $key=$expected"
+  echo "--- PASS: probe status $field=$expected"
 }
 
 assertBtpOperatorRestarted() {
@@ -92,7 +92,7 @@ getBtpOperatorPodName() {
 
 getUpdatedAt() {
   kubectl get btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" \
-    -o jsonpath='{.metadata.annotations.tls-probe-updated-at}' 2>/dev/null || echo ""
+    -o jsonpath='{.status.probe.updatedAt}' 2>/dev/null || echo ""
 }
 
 waitForNextCycle() {
@@ -112,24 +112,24 @@ waitForNextCycle() {
   return 1
 }
 
-# waitForLastHashToBe waits for tls-probe-last-hash to equal the expected value.
+# waitForLastHashToBe waits for the probe lastHash to equal the expected value.
 # When this is true, probe_runner has finished processing the cycle (restart was already triggered).
 waitForLastHashToBe() {
   local expected=$1 timeout=${2:-30} seconds=0
   while [[ $seconds -lt $timeout ]]; do
     local current
     current=$(kubectl get btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" \
-      -o jsonpath='{.metadata.annotations.tls-probe-last-hash}' 2>/dev/null || echo "")
+      -o jsonpath='{.status.probe.lastHash}' 2>/dev/null || echo "")
     if [[ "$current" == "$expected" ]]; then
       return 0
     fi
     sleep 2; seconds=$((seconds + 2))
   done
-  echo "--- FAIL: tls-probe-last-hash did not reach '$expected' within ${timeout}s (got '$current')"
+  echo "--- FAIL: the probe lastHash did not reach '$expected' within ${timeout}s (got '$current')"
   return 1
 }
 
-# waitForHashToChange waits for tls-probe-hash to differ from the given value.
+# waitForHashToChange waits for the probe hash to differ from the given value.
 # Used in Phase C to ensure the probe has read the new ca-bundle before we
 # check for the restart — Secret propagation may lag one probe cycle.
 waitForHashToChange() {
@@ -137,7 +137,7 @@ waitForHashToChange() {
   while [[ $seconds -lt $timeout ]]; do
     local current
     current=$(kubectl get btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" \
-      -o jsonpath='{.metadata.annotations.tls-probe-hash}' 2>/dev/null || echo "")
+      -o jsonpath='{.status.probe.hash}' 2>/dev/null || echo "")
     if [[ -n "$current" && "$current" != "$old_hash" ]]; then
       echo "--- Hash changed to '$current'" >&2
       echo "$current"
@@ -145,7 +145,7 @@ waitForHashToChange() {
     fi
     sleep 5; seconds=$((seconds + 5))
   done
-  echo "--- ERROR: tls-probe-hash did not change from '$old_hash' within ${timeout}s" >&2
+  echo "--- ERROR: the probe hash did not change from '$old_hash' within ${timeout}s" >&2
   return 1
 }
 
@@ -229,9 +229,9 @@ echo "--- World state: no ca-bundle Secret, webhook not injecting mount"
 BEFORE_UPDATED_AT=$(getUpdatedAt)
 BEFORE_POD=$(getBtpOperatorPodName)
 waitForNextCycle "$BEFORE_UPDATED_AT"
-assertCRAnnotation "tls-probe-status" "error"
+assertProbeStatus "result" "error"
 assertBtpOperatorNotRestarted "$BEFORE_POD"
-printAnnotations
+printProbeStatus
 
 echo ""
 echo "╔══════════════════════════════════════════════════════════════════╗"
@@ -246,9 +246,9 @@ CA_BUNDLE_B64=$CA_A_B64 envsubst < scripts/testing/yaml/ca-bundle-probe/ca-bundl
 BEFORE_UPDATED_AT=$(getUpdatedAt)
 
 waitForNextCycle "$BEFORE_UPDATED_AT"
-assertCRAnnotation "tls-probe-status" "ok"
+assertProbeStatus "result" "ok"
 assertBtpOperatorNotRestarted "$BEFORE_POD"
-printAnnotations
+printProbeStatus
 
 echo ""
 echo "╔══════════════════════════════════════════════════════════════════╗"
@@ -267,9 +267,9 @@ waitForDeploymentReady fake-server
 BEFORE_UPDATED_AT=$(getUpdatedAt)
 
 waitForNextCycle "$BEFORE_UPDATED_AT"
-assertCRAnnotation "tls-probe-status" "alert"
+assertProbeStatus "result" "alert"
 assertBtpOperatorNotRestarted "$BEFORE_POD"
-printAnnotations
+printProbeStatus
 
 echo ""
 echo "╔══════════════════════════════════════════════════════════════════╗"
@@ -278,22 +278,22 @@ echo "║  Expected: status=ok, btp-operator RESTARTED                     ║"
 echo "╚══════════════════════════════════════════════════════════════════╝"
 echo "--- World state: updating ca-bundle Secret to ca-B (matches server cert)"
 BEFORE_HASH=$(kubectl get btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" \
-  -o jsonpath='{.metadata.annotations.tls-probe-hash}' 2>/dev/null || echo "")
+  -o jsonpath='{.status.probe.hash}' 2>/dev/null || echo "")
 BEFORE_POD=$(getBtpOperatorPodName)
 CA_B_B64=$(base64 -w0 < "$CERTS_DIR/ca-B.crt")
 CA_BUNDLE_B64=$CA_B_B64 envsubst < scripts/testing/yaml/ca-bundle-probe/ca-bundle-secret.yaml | kubectl apply -f -
 
 # Secret propagation into the probe Job pod may lag one probe cycle.
-# Wait until tls-probe-hash actually changes — only then has the probe
+# Wait until the probe hash actually changes — only then has the probe
 # read the new ca-B bundle.
 EXPECTED_HASH=$(waitForHashToChange "$BEFORE_HASH")
-assertCRAnnotation "tls-probe-status" "ok"
-# Wait for probe_runner to finish processing: it sets tls-probe-last-hash after
+assertProbeStatus "result" "ok"
+# Wait for probe_runner to finish processing: it sets the probe lastHash after
 # triggering the restart, so when last-hash equals the current hash the restart
 # has already been triggered.
 waitForLastHashToBe "$EXPECTED_HASH" 60
 assertBtpOperatorRestarted "$BEFORE_POD"
-printAnnotations
+printProbeStatus
 
 echo ""
 echo "╔══════════════════════════════════════════════════════════════════╗"
@@ -317,9 +317,9 @@ CA_BUNDLE_B64=$CA_B2_B64 envsubst < scripts/testing/yaml/ca-bundle-probe/ca-bund
 BEFORE_UPDATED_AT=$(getUpdatedAt)
 
 waitForNextCycle "$BEFORE_UPDATED_AT"
-assertCRAnnotation "tls-probe-status" "alert"
+assertProbeStatus "result" "alert"
 assertBtpOperatorNotRestarted "$BEFORE_POD"
-printAnnotations
+printProbeStatus
 
 # ─── teardown ─────────────────────────────────────────────────────────────────
 
@@ -1,10 +1,10 @@
 #!/usr/bin/env bash
 # Simulates one btp-manager reconcile cycle for the CA bundle probe.
-# Runs the probe Job, reads its annotations from BtpOperator CR, restarts
-# sap-btp-operator if warranted, and advances tls-probe-last-hash.
+# Runs the probe Job, reads its result from the BtpOperator CR status, restarts
+# sap-btp-operator if warranted, and advances the probe lastHash.
 #
 # TODO: remove this script once btp-manager implements probe Job creation
-# and annotation-driven reconciliation.
+# and status-driven reconciliation.
 #
 # Usage:
 #   ./scripts/testing/simulate_btp_manager.sh           # single cycle
@@ -85,11 +85,11 @@ EOF
 reconcile() {
   local status hash lastHash
   status=$(kubectl get btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" \
-    -o jsonpath='{.metadata.annotations.tls-probe-status}' 2>/dev/null || echo "")
+    -o jsonpath='{.status.probe.result}' 2>/dev/null || echo "")
   hash=$(kubectl get btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" \
-    -o jsonpath='{.metadata.annotations.tls-probe-hash}' 2>/dev/null || echo "")
+    -o jsonpath='{.status.probe.hash}' 2>/dev/null || echo "")
   lastHash=$(kubectl get btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" \
-    -o jsonpath='{.metadata.annotations.tls-probe-last-hash}' 2>/dev/null || echo "")
+    -o jsonpath='{.status.probe.lastHash}' 2>/dev/null || echo "")
 
   echo "[sim] status='$status' hash=${hash:0:16}... lastHash=${lastHash:0:16}..."
 
@@ -103,8 +103,8 @@ reconcile() {
 
   # Advance last-hash only when probe wrote a non-empty hash (matches probe_runner.go behaviour)
   if [[ -n "$hash" ]]; then
-    kubectl annotate btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" \
-      --overwrite "tls-probe-last-hash=$hash" 2>/dev/null
+    kubectl patch btpoperator/"$BTPOPERATOR_NAME" -n "$NAMESPACE" --subresource=status \
+      --type merge -p "{\"status\":{\"probe\":{\"lastHash\":\"$hash\"}}}" 2>/dev/null
   fi
 }
 
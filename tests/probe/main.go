@@ -1,25 +1,16 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
 	"context"
-	"crypto/sha256"
-	"crypto/tls"
-	"crypto/x509"
-	"encoding/pem"
-	"errors"
 	"fmt"
 	"log/slog"
-	"net"
-	"net/url"
 	"os"
-	"sort"
-	"strings"
 	"time"
 
 	btpv1alpha1 "github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/internal/tlsprobe"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -31,18 +22,14 @@ const (
 	caBundlePath    = "/etc/ssl/certs/ca-certificates.crt"
 	caBundleMntPath = "/etc/ssl/certs"
 	mountInfoPath   = "/proc/self/mountinfo"
-	tlsResultOK     = "ok"
-	tlsResultX509   = "failed-x509"
-	tlsResultOther  = "failed-other"
-	signalOK        = "ok"
-	signalAlert     = "alert"
-	signalError     = "error"
 )
 
 type config struct {
 	Namespace        string
 	TLSSecret        string
 	TokenURLOverride string
+	TrustBundleFile  string
+	CAExpiryWarning  time.Duration
 }
 
 func loadConfig() config {
@@ -50,6 +37,8 @@ func loadConfig() config {
 		Namespace:        getEnv("PROBE_NAMESPACE", "kyma-system"),
 		TLSSecret:        getEnv("PROBE_TLS_SECRET", "sap-btp-manager"),
 		TokenURLOverride: getEnv("PROBE_TOKENURL_OVERRIDE", ""),
+		TrustBundleFile:  getEnv("PROBE_TRUST_BUNDLE_FILE", ""),
+		CAExpiryWarning:  getDurationEnv("PROBE_CA_EXPIRY_WARNING", 30*24*time.Hour),
 	}
 }
 
@@ -60,181 +49,87 @@ func getEnv(key, def string) string {
 	return def
 }
 
-type mountSignal struct {
-	Present bool
-	Hash    string
-	Content []byte
+func getDurationEnv(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return d
 }
 
-func collectMount() mountSignal {
+func collectMount() tlsprobe.MountSignal {
 	// PROBE_FORCE_HASH bypasses mount detection and file reading entirely.
 	// Useful for testing the restart-on-hash-change path on distroless images
 	// without modifying the image or filesystem. Set to any hex string; change
 	// the value between probe cycles to simulate CA bundle rotation.
 	// REMOVE before promoting to a stable release.
 	if hash := os.Getenv("PROBE_FORCE_HASH"); hash != "" {
-		return mountSignal{Present: true, Hash: hash}
+		return tlsprobe.MountSignal{Present: true, Hash: hash}
 	}
-	return collectMountFromPath(caBundlePath, caBundleMntPath, mountInfoPath)
+	return tlsprobe.CollectMount(caBundlePath, caBundleMntPath, mountInfoPath)
 }
 
-// collectMountFromPath is the testable inner implementation.
-// It considers the CA bundle injected only when caBundleMntDir is an explicit
-// mountpoint in mountInfoFile — distinguishing an injected volume from the CA
-// bundle that ships in the base image.
-func collectMountFromPath(caFile, caBundleMntDir, mountInfoFile string) mountSignal {
-	if !isMountPoint(caBundleMntDir, mountInfoFile) {
-		return mountSignal{}
+// withTrustBundle adds the managed CA trust bundle from path to the mount signal.
+// The signal is returned unchanged if path is empty or the file cannot be read.
+func withTrustBundle(m tlsprobe.MountSignal, path string) tlsprobe.MountSignal {
+	if path == "" {
+		return m
 	}
-	data, err := os.ReadFile(caFile)
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return mountSignal{}
+		return m
 	}
-	hash := hashCertBundle(data)
-	return mountSignal{Present: true, Hash: hash, Content: data}
+	return m.WithTrustBundle(data)
 }
 
-// hashCertBundle parses all PEM certificates in data, computes a SHA-256 fingerprint
-// (sha256(cert.Raw)) for each, deduplicates, sorts, and returns a SHA-256 hash of the
-// joined sorted fingerprint list. Returns "" when no valid certificates are found.
-// The result is order-independent: the same set of certs in any order produces the same hash.
-func hashCertBundle(data []byte) string {
-	seen := map[string]struct{}{}
-	rest := data
-	for len(rest) > 0 {
-		var block *pem.Block
-		block, rest = pem.Decode(rest)
-		if block == nil {
-			break
-		}
-		if block.Type != "CERTIFICATE" {
-			continue
-		}
-		cert, err := x509.ParseCertificate(block.Bytes)
-		if err != nil {
-			continue
+// resolveTarget reads the SAP Service Manager URLs and client credentials from the secret.
+// The token URL override replaces the token URL only.
+func resolveTarget(ctx context.Context, cl client.Client, cfg config) (tlsprobe.Target, error) {
+	secret := &corev1.Secret{}
+	if err := cl.Get(ctx, types.NamespacedName{Namespace: cfg.Namespace, Name: cfg.TLSSecret}, secret); err != nil {
+		if cfg.TokenURLOverride != "" {
+			return tlsprobe.Target{TokenURL: cfg.TokenURLOverride}, nil
 		}
-		fp := sha256.Sum256(cert.Raw)
-		seen[fmt.Sprintf("%x", fp)] = struct{}{}
-	}
-	if len(seen) == 0 {
-		return ""
+		return tlsprobe.Target{}, fmt.Errorf("reading secret %s: %w", cfg.TLSSecret, err)
 	}
-	fps := make([]string, 0, len(seen))
-	for fp := range seen {
-		fps = append(fps, fp)
+	target := tlsprobe.Target{
+		TokenURL:     string(secret.Data["tokenurl"]),
+		SMURL:        string(secret.Data["sm_url"]),
+		ClientID:     string(secret.Data["clientid"]),
+		ClientSecret: string(secret.Data["clientsecret"]),
 	}
-	sort.Strings(fps)
-	sum := sha256.Sum256([]byte(strings.Join(fps, ",")))
-	return fmt.Sprintf("%x", sum)
-}
-
-// isMountPoint reports whether path appears as a mount destination in mountInfoFile.
-// Field 5 (0-indexed) of each /proc/self/mountinfo line is the mount point.
-func isMountPoint(path, mountInfoFile string) bool {
-	data, err := os.ReadFile(mountInfoFile)
-	if err != nil {
-		return false
-	}
-	scanner := bufio.NewScanner(bytes.NewReader(data))
-	for scanner.Scan() {
-		fields := strings.Fields(scanner.Text())
-		if len(fields) >= 5 && fields[4] == path {
-			return true
-		}
-	}
-	return false
-}
-
-func buildCertPool(m mountSignal) *x509.CertPool {
-	if m.Present && len(m.Content) > 0 {
-		pool := x509.NewCertPool()
-		pool.AppendCertsFromPEM(m.Content)
-		return pool
-	}
-	pool, err := x509.SystemCertPool()
-	if err != nil {
-		return x509.NewCertPool()
-	}
-	return pool
-}
-
-func dialTLS(addr string, pool *x509.CertPool) string {
-	conn, err := tls.DialWithDialer(
-		&net.Dialer{Timeout: 10 * time.Second},
-		"tcp",
-		addr,
-		&tls.Config{RootCAs: pool},
-	)
-	if err != nil {
-		var x509Err *tls.CertificateVerificationError
-		if errors.As(err, &x509Err) {
-			return tlsResultX509
-		}
-		if isTLSCertError(err.Error()) {
-			return tlsResultX509
-		}
-		return tlsResultOther
-	}
-	conn.Close()
-	return tlsResultOK
-}
-
-func isTLSCertError(errStr string) bool {
-	return strings.Contains(errStr, "x509") || strings.Contains(errStr, "certificate")
-}
-
-func resolveTokenURL(ctx context.Context, cl client.Client, cfg config) (string, error) {
 	if cfg.TokenURLOverride != "" {
-		return cfg.TokenURLOverride, nil
-	}
-	secret := &corev1.Secret{}
-	if err := cl.Get(ctx, types.NamespacedName{Namespace: cfg.Namespace, Name: cfg.TLSSecret}, secret); err != nil {
-		return "", fmt.Errorf("reading secret %s: %w", cfg.TLSSecret, err)
+		target.TokenURL = cfg.TokenURLOverride
 	}
-	tokenURL := string(secret.Data["tokenurl"])
-	if tokenURL == "" {
-		return "", fmt.Errorf("secret %s missing tokenurl key", cfg.TLSSecret)
-	}
-	return tokenURL, nil
-}
-
-func computeSignal(mountPresent bool, tlsResult string) string {
-	switch tlsResult {
-	case tlsResultOK:
-		return signalOK
-	case tlsResultX509:
-		if mountPresent {
-			return signalAlert
-		}
-		return signalError
-	default: // failed-other
-		return signalError
+	if target.TokenURL == "" {
+		return tlsprobe.Target{}, fmt.Errorf("secret %s missing tokenurl key", cfg.TLSSecret)
 	}
+	return target, nil
 }
 
-func patchBtpOperatorAnnotations(ctx context.Context, cl client.Client, cfg config, annotations map[string]string) error {
+// patchBtpOperatorProbeStatus writes the probe result to the BtpOperator status.
+// LastHash, History and LastRestartTime are kept, because they're managed by BTP Manager.
+func patchBtpOperatorProbeStatus(ctx context.Context, cl client.Client, cfg config, status *btpv1alpha1.ProbeStatus) error {
 	cr := &btpv1alpha1.BtpOperator{}
 	if err := cl.Get(ctx, types.NamespacedName{Namespace: cfg.Namespace, Name: "btpoperator"}, cr); err != nil {
 		return fmt.Errorf("get BtpOperator: %w", err)
 	}
 	patch := client.MergeFrom(cr.DeepCopy())
-	if cr.Annotations == nil {
-		cr.Annotations = map[string]string{}
-	}
-	for k, v := range annotations {
-		cr.Annotations[k] = v
-	}
-	return cl.Patch(ctx, cr, patch)
+	status.KeepManagedFields(cr.Status.Probe)
+	cr.Status.Probe = status
+	return cl.Status().Patch(ctx, cr, patch)
 }
 
+// probeAnnotationKeys are the annotations previous probe versions wrote the results to.
 var probeAnnotationKeys = []string{
 	"tls-probe-status",
 	"tls-probe-hash",
 	"tls-probe-updated-at",
 	"tls-probe-last-hash",
+	"tls-probe-error",
 }
 
+// clearBtpOperatorProbeAnnotations removes the annotations written by previous probe versions.
 func clearBtpOperatorProbeAnnotations(ctx context.Context, cl client.Client, cfg config) error {
 	cr := &btpv1alpha1.BtpOperator{}
 	if err := cl.Get(ctx, types.NamespacedName{Namespace: cfg.Namespace, Name: "btpoperator"}, cr); err != nil {
@@ -279,63 +174,41 @@ func main() {
 		return
 	}
 
-	annotations, err := runProbe(ctx, cl, cfg)
-	if annotations == nil && err == nil {
-		logger.Info("no rt-bootstrapper mount detected — clearing stale probe annotations")
-		if clearErr := clearBtpOperatorProbeAnnotations(ctx, cl, cfg); clearErr != nil {
-			logger.Error("clear probe annotations", "err", clearErr)
-		}
-		return
+	status := runProbe(ctx, cl, cfg)
+	if status.Error != "" {
+		logger.Error("probe error", "err", status.Error)
 	}
-	if err != nil {
-		logger.Error("probe error", "err", err)
-		annotations = map[string]string{
-			"tls-probe-error": err.Error(),
-		}
+	if patchErr := patchBtpOperatorProbeStatus(ctx, cl, cfg, status); patchErr != nil {
+		logger.Error("patch BtpOperator probe status", "err", patchErr)
+		return
 	}
-
-	if patchErr := patchBtpOperatorAnnotations(ctx, cl, cfg, annotations); patchErr != nil {
-		logger.Error("patch BtpOperator annotations", "err", patchErr)
+	if clearErr := clearBtpOperatorProbeAnnotations(ctx, cl, cfg); clearErr != nil {
+		logger.Error("clear probe annotations", "err", clearErr)
 	}
 
-	if err == nil && annotations != nil {
-		logger.Info("probe run complete",
-			"status", annotations["tls-probe-status"],
-			"hash", annotations["tls-probe-hash"],
-		)
-	}
+	logger.Info("probe run complete",
+		"result", status.Result,
+		"tlsResult", status.TLSResult,
+		"mountPresent", status.MountPresent,
+		"hash", status.Hash,
+	)
 }
 
-func runProbe(ctx context.Context, cl client.Client, cfg config) (map[string]string, error) {
-	mount := collectMount()
-	pool := buildCertPool(mount)
-
-	tokenURL, err := resolveTokenURL(ctx, cl, cfg)
-	if err != nil {
-		return nil, fmt.Errorf("resolve token URL: %w", err)
-	}
+// runProbe dials the token URL with the mounted CA bundle, or the system CA bundle if none is mounted, and returns the result.
+// A probe that cannot run returns the error result with Error set.
+func runProbe(ctx context.Context, cl client.Client, cfg config) *btpv1alpha1.ProbeStatus {
+	mount := withTrustBundle(collectMount(), cfg.TrustBundleFile)
+	mount.ExpiryWarning = cfg.CAExpiryWarning
 
-	u, err := url.Parse(tokenURL)
+	target, err := resolveTarget(ctx, cl, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("parse token URL: %w", err)
-	}
-	host := u.Host
-	if u.Port() == "" {
-		host = u.Hostname() + ":443"
-	}
-
-	tlsResult := dialTLS(host, pool)
-	signal := computeSignal(mount.Present, tlsResult)
-
-	// No mount and TLS healthy — rt-bootstrapper not active, system CA works fine.
-	// No need to annotate the CR; probe exits silently.
-	if !mount.Present && tlsResult == tlsResultOK {
-		return nil, nil
+		return &btpv1alpha1.ProbeStatus{
+			Result:       btpv1alpha1.ProbeResultError,
+			MountPresent: mount.Present,
+			Hash:         mount.Hash,
+			Error:        fmt.Sprintf("resolve token URL: %s", err),
+			UpdatedAt:    metav1.Now(),
+		}
 	}
-
-	return map[string]string{
-		"tls-probe-status":     signal,
-		"tls-probe-hash":       mount.Hash,
-		"tls-probe-updated-at": time.Now().UTC().Format(time.RFC3339),
-	}, nil
+	return tlsprobe.Run(ctx, target, mount, tlsprobe.EnvironmentProxy())
 }
@@ -2,16 +2,13 @@ package main
 
 import (
 	"context"
-	"crypto/x509"
-	"fmt"
-	"net/http"
-	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	btpv1alpha1 "github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/internal/tlsprobe"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,8 +18,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
-// certA and certB are real self-signed test certificates valid for 100 years (expire 2126).
-// x509.ParseCertificate does not validate expiry, so these are stable test fixtures.
+// certA is a real self-signed test certificate valid for 100 years (expire 2126).
+// x509.ParseCertificate does not validate expiry, so it is a stable test fixture.
 const certA = `-----BEGIN CERTIFICATE-----
 MIIBETCBt6ADAgECAgEBMAoGCCqGSM49BAMCMBExDzANBgNVBAMTBmNlcnQtQTAg
 Fw0yNjA3MjExNzE1MTVaGA8yMTI2MDYyNzE3MTUxNVowETEPMA0GA1UEAxMGY2Vy
@@ -33,16 +30,6 @@ td4CIQCkEvs9atXyWaSeAdpCHPkVaqyx1r0M+q4DtPtm63K8ug==
 -----END CERTIFICATE-----
 `
 
-const certB = `-----BEGIN CERTIFICATE-----
-MIIBDzCBt6ADAgECAgEBMAoGCCqGSM49BAMCMBExDzANBgNVBAMTBmNlcnQtQjAg
-Fw0yNjA3MjExNzE1MTVaGA8yMTI2MDYyNzE3MTUxNVowETEPMA0GA1UEAxMGY2Vy
-dC1CMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE+Bg3XMx5P7ZU8XEJ++8vfozT
-BGEzw+Xvn94EzNnbBKhknfqChP1UfYVZ1tC9nf4VyuJKWjzbr5HdLB5vn4ktPjAK
-BggqhkjOPQQDAgNHADBEAiA7KZ0wBZdzeKZzWWJR0SPE9/R59zeNVeOhCsT6b6RL
-0wIgeor8WP6NGWfegdC4D1fGsam15j9nvRrRe6rPo0S1wQk=
------END CERTIFICATE-----
-`
-
 func testScheme() *runtime.Scheme {
 	s := runtime.NewScheme()
 	_ = clientgoscheme.AddToScheme(s)
@@ -65,133 +52,85 @@ func TestLoadConfig_EnvOverride(t *testing.T) {
 	assert.Equal(t, "https://fake.local/health", cfg.TokenURLOverride)
 }
 
-func TestCollectMount_Present(t *testing.T) {
-	caFile, err := os.CreateTemp("", "ca-*.crt")
-	require.NoError(t, err)
-	content := []byte(certA)
-	_, err = caFile.Write(content)
-	require.NoError(t, err)
-	caFile.Close()
-	defer os.Remove(caFile.Name())
+func TestWithTrustBundle_Present(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca-bundle.crt")
+	require.NoError(t, os.WriteFile(path, []byte(certA), 0o600))
 
-	// Write a fake mountinfo that lists the CA file's directory as a mountpoint
-	mntDir := filepath.Dir(caFile.Name())
-	mountInfo, err := os.CreateTemp("", "mountinfo-*")
-	require.NoError(t, err)
-	defer os.Remove(mountInfo.Name())
-	fmt.Fprintf(mountInfo, "100 99 0:1 / %s rw - tmpfs tmpfs rw\n", mntDir)
-	mountInfo.Close()
-
-	m := collectMountFromPath(caFile.Name(), mntDir, mountInfo.Name())
+	m := withTrustBundle(tlsprobe.MountSignal{}, path)
 	assert.True(t, m.Present)
-	assert.NotEmpty(t, m.Hash)
-	assert.Equal(t, content, m.Content)
-}
-
-func TestCollectMount_Absent_NoMount(t *testing.T) {
-	// mountinfo does not list /etc/ssl/certs → no injected bundle
-	mountInfo, err := os.CreateTemp("", "mountinfo-*")
-	require.NoError(t, err)
-	defer os.Remove(mountInfo.Name())
-	fmt.Fprintf(mountInfo, "100 99 0:1 / / rw - overlay overlay rw\n")
-	mountInfo.Close()
-
-	m := collectMountFromPath("/etc/ssl/certs/ca-certificates.crt", "/etc/ssl/certs", mountInfo.Name())
-	assert.False(t, m.Present)
+	assert.Equal(t, []byte(certA), m.TrustBundle)
 	assert.Empty(t, m.Hash)
-	assert.Nil(t, m.Content)
 }
 
-func TestDialTLS_OK(t *testing.T) {
-	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(200)
-	}))
-	defer srv.Close()
-	pool := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
-	assert.Equal(t, tlsResultOK, dialTLS(srv.Listener.Addr().String(), pool))
-}
-
-func TestDialTLS_X509(t *testing.T) {
-	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
-	defer srv.Close()
-	// Empty pool → certificate signed by unknown authority
-	assert.Equal(t, tlsResultX509, dialTLS(srv.Listener.Addr().String(), x509.NewCertPool()))
-}
+func TestWithTrustBundle_Absent(t *testing.T) {
+	m := withTrustBundle(tlsprobe.MountSignal{}, filepath.Join(t.TempDir(), "missing.crt"))
+	assert.False(t, m.Present)
+	assert.Nil(t, m.TrustBundle)
 
-func TestDialTLS_Other(t *testing.T) {
-	// Nothing listening on this port → connection refused → failed-other
-	assert.Equal(t, tlsResultOther, dialTLS("127.0.0.1:19999", x509.NewCertPool()))
+	assert.Equal(t, tlsprobe.MountSignal{}, withTrustBundle(tlsprobe.MountSignal{}, ""))
 }
 
-func TestBuildCertPool_NoMount(t *testing.T) {
-	pool := buildCertPool(mountSignal{Present: false})
-	assert.NotNil(t, pool)
-}
+func TestRunProbe_UpdatedAt(t *testing.T) {
+	// No mount in test environment, TLS will fail (nothing listening) → signal=error.
+	// Verifies that the target URL and updatedAt are written whenever the probe runs.
+	cl := fake.NewClientBuilder().WithScheme(testScheme()).Build()
+	cfg := config{Namespace: "kyma-system", TokenURLOverride: "https://127.0.0.1:19999/token"}
 
-func TestBuildCertPool_WithMount_CustomOnly(t *testing.T) {
-	pem := []byte("-----BEGIN CERTIFICATE-----\nfake\n-----END CERTIFICATE-----\n")
-	pool := buildCertPool(mountSignal{Present: true, Content: pem})
-	assert.NotNil(t, pool)
-}
+	before := time.Now().Truncate(time.Second)
+	status := runProbe(context.Background(), cl, cfg)
 
-func TestComputeSignal(t *testing.T) {
-	cases := []struct {
-		mount    bool
-		tls      string
-		expected string
-	}{
-		{false, tlsResultOK, signalOK},
-		{false, tlsResultX509, signalError},
-		{false, tlsResultOther, signalError},
-		{true, tlsResultOK, signalOK},
-		{true, tlsResultX509, signalAlert},
-		{true, tlsResultOther, signalError},
-	}
-	for _, c := range cases {
-		assert.Equal(t, c.expected, computeSignal(c.mount, c.tls), "mount=%v tls=%s", c.mount, c.tls)
-	}
+	assert.Equal(t, btpv1alpha1.ProbeResultError, status.Result)
+	assert.Equal(t, tlsprobe.TLSResultOther, status.TLSResult)
+	assert.Equal(t, "https://127.0.0.1:19999/token", status.TargetURL)
+	assert.False(t, status.MountPresent)
+	assert.Empty(t, status.Error)
+	assert.False(t, status.UpdatedAt.Time.Before(before), "updatedAt should be >= test start time")
 }
 
-func TestRunProbe_UpdatedAt(t *testing.T) {
-	// No mount in test environment, TLS will fail (nothing listening) → signal=error → non-silent path.
-	// Verifies that tls-probe-updated-at is written whenever the probe annotates the CR.
-	cr := &btpv1alpha1.BtpOperator{
-		ObjectMeta: metav1.ObjectMeta{Name: "btpoperator", Namespace: "kyma-system"},
-		Status:     btpv1alpha1.Status{State: btpv1alpha1.StateReady},
-	}
-	cl := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(cr).Build()
-	cfg := config{Namespace: "kyma-system", TokenURLOverride: "https://127.0.0.1:19999/token"}
+func TestRunProbe_TokenURLNotResolved(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(testScheme()).Build()
+	cfg := config{Namespace: "kyma-system", TLSSecret: "sap-btp-manager"}
 
-	before := time.Now().UTC().Truncate(time.Second)
-	annotations, err := runProbe(context.Background(), cl, cfg)
-	require.NoError(t, err)
-	require.NotNil(t, annotations)
+	status := runProbe(context.Background(), cl, cfg)
 
-	ts, ok := annotations["tls-probe-updated-at"]
-	require.True(t, ok, "tls-probe-updated-at missing")
-	parsed, err := time.Parse(time.RFC3339, ts)
-	require.NoError(t, err)
-	assert.False(t, parsed.Before(before), "updated-at should be >= test start time")
+	assert.Equal(t, btpv1alpha1.ProbeResultError, status.Result)
+	assert.Empty(t, status.TLSResult)
+	assert.Contains(t, status.Error, "resolve token URL")
+	assert.False(t, status.UpdatedAt.IsZero())
 }
 
-func TestPatchBtpOperatorAnnotations(t *testing.T) {
+func TestPatchBtpOperatorProbeStatus_KeepsManagedFields(t *testing.T) {
+	restartTime := metav1.Now()
+	history := []btpv1alpha1.ProbeHistoryEntry{{Result: btpv1alpha1.ProbeResultOK, Hash: "old", UpdatedAt: restartTime}}
 	cr := &btpv1alpha1.BtpOperator{
 		ObjectMeta: metav1.ObjectMeta{Name: "btpoperator", Namespace: "kyma-system"},
-		Status:     btpv1alpha1.Status{State: btpv1alpha1.StateReady},
+		Status: btpv1alpha1.Status{
+			State: btpv1alpha1.StateReady,
+			Probe: &btpv1alpha1.ProbeStatus{Result: btpv1alpha1.ProbeResultOK, Hash: "old", LastHash: "old", History: history, LastRestartTime: &restartTime},
+		},
 	}
-	cl := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(cr).Build()
+	cl := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(cr).WithStatusSubresource(cr).Build()
 
 	cfg := config{Namespace: "kyma-system"}
-	err := patchBtpOperatorAnnotations(context.Background(), cl, cfg, map[string]string{
-		"tls-probe-status": signalOK,
-		"tls-probe-hash":   "abc123",
+	err := patchBtpOperatorProbeStatus(context.Background(), cl, cfg, &btpv1alpha1.ProbeStatus{
+		Result:       btpv1alpha1.ProbeResultOK,
+		TLSResult:    tlsprobe.TLSResultOK,
+		MountPresent: true,
+		Hash:         "abc123",
+		UpdatedAt:    metav1.Now(),
 	})
 	require.NoError(t, err)
 
 	updated := &btpv1alpha1.BtpOperator{}
 	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Namespace: "kyma-system", Name: "btpoperator"}, updated))
-	assert.Equal(t, signalOK, updated.Annotations["tls-probe-status"])
-	assert.Equal(t, "abc123", updated.Annotations["tls-probe-hash"])
+	require.NotNil(t, updated.Status.Probe)
+	assert.Equal(t, "abc123", updated.Status.Probe.Hash)
+	assert.Equal(t, "old", updated.Status.Probe.LastHash)
+	require.Len(t, updated.Status.Probe.History, 1)
+	assert.Equal(t, "old", updated.Status.Probe.History[0].Hash)
+	require.NotNil(t, updated.Status.Probe.LastRestartTime)
+	assert.True(t, updated.Status.Probe.MountPresent)
+	assert.Equal(t, btpv1alpha1.StateReady, updated.Status.State)
 }
 
 func TestClearBtpOperatorProbeAnnotations_ClearsAll(t *testing.T) {
@@ -243,67 +182,3 @@ func TestClearBtpOperatorProbeAnnotations_NoOp(t *testing.T) {
 	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Namespace: "kyma-system", Name: "btpoperator"}, updated))
 	assert.Equal(t, "keep-me", updated.Annotations["some-other-annotation"])
 }
-
-func TestHashCertBundle_EmptyInput(t *testing.T) {
-	assert.Empty(t, hashCertBundle(nil))
-	assert.Empty(t, hashCertBundle([]byte{}))
-	assert.Empty(t, hashCertBundle([]byte("not a cert")))
-}
-
-func TestHashCertBundle_SingleCert_Stable(t *testing.T) {
-	data := []byte(certA)
-	h1 := hashCertBundle(data)
-	h2 := hashCertBundle(data)
-	require.NotEmpty(t, h1)
-	assert.Equal(t, h1, h2)
-}
-
-func TestHashCertBundle_OrderIndependent(t *testing.T) {
-	// Same two certs, different order — hash must be identical.
-	bundleAB := []byte(certA + certB)
-	bundleBA := []byte(certB + certA)
-	hAB := hashCertBundle(bundleAB)
-	hBA := hashCertBundle(bundleBA)
-	require.NotEmpty(t, hAB)
-	assert.Equal(t, hAB, hBA, "hash must not depend on cert order in the file")
-}
-
-func TestHashCertBundle_DifferentCerts_DifferentHash(t *testing.T) {
-	hA := hashCertBundle([]byte(certA))
-	hB := hashCertBundle([]byte(certB))
-	require.NotEmpty(t, hA)
-	require.NotEmpty(t, hB)
-	assert.NotEqual(t, hA, hB)
-}
-
-func TestHashCertBundle_DuplicateCerts_SameAsUnique(t *testing.T) {
-	// A bundle with A+A should hash the same as a bundle with just A.
-	hOnce := hashCertBundle([]byte(certA))
-	hTwice := hashCertBundle([]byte(certA + certA))
-	assert.Equal(t, hOnce, hTwice, "duplicated cert must not change the hash")
-}
-
-func TestCollectMount_HashIsOrderIndependent(t *testing.T) {
-	// Integration: collectMountFromPath must produce the same hash regardless of cert order.
-	bundleAB := []byte(certA + certB)
-	bundleBA := []byte(certB + certA)
-
-	dir := t.TempDir()
-	mountInfo, err := os.CreateTemp("", "mountinfo-*")
-	require.NoError(t, err)
-	defer os.Remove(mountInfo.Name())
-	fmt.Fprintf(mountInfo, "100 99 0:1 / %s rw - tmpfs tmpfs rw\n", dir)
-	mountInfo.Close()
-
-	caAB := filepath.Join(dir, "ca-ab.crt")
-	caBA := filepath.Join(dir, "ca-ba.crt")
-	require.NoError(t, os.WriteFile(caAB, bundleAB, 0600))
-	require.NoError(t, os.WriteFile(caBA, bundleBA, 0600))
-
-	mAB := collectMountFromPath(caAB, dir, mountInfo.Name())
-	mBA := collectMountFromPath(caBA, dir, mountInfo.Name())
-
-	require.True(t, mAB.Present)
-	require.True(t, mBA.Present)
-	assert.Equal(t, mAB.Hash, mBA.Hash, "mount hash must be order-independent")
-}